@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/AntonioCarlini/home-computer-prices/hcp"
+)
+
+// Implements "affordability -wages wages.csv [-o report.csv] [-config rules.json] [-aggregate min] [-systems A,B] data.csv ...".
+// Outputs, as CSV, how many weeks of the average UK weekly wage each system's published price cost in each quarter,
+// for comparing what the systems cost people at the time rather than in pounds. The wages come from an external series,
+// a CSV file with a "Year" column and a "Weekly wage" column in pounds, such as "1982,£128.40"; lines starting with "#"
+// are ignored. Quarters of years with no wage in the series are left out, and the years missing are listed with the diagnostics.
+func runAffordabilityReport(args []string) {
+	flags := flag.NewFlagSet("affordability", flag.ExitOnError)
+	wagesFilename := flags.String("wages", "", "CSV file of the average weekly wage in each year")
+	outputFilename := flags.String("o", "", "write the report to this file instead of standard output")
+	configFilename := flags.String("config", "", "JSON file of rules applied to the data (default: the built-in rules)")
+	aggregation := flags.String("aggregate", "min", "how each quarter's price is chosen from its adverts: min, mode or median")
+	systemList := flags.String("systems", "", "comma-separated list of systems to report instead of all of them, by their published names or aliases")
+	flags.Parse(args)
+	if flags.NArg() < 1 {
+		log.Fatalf("At least 1 argument required but %d supplied\n", flags.NArg())
+	}
+	if *wagesFilename == "" {
+		log.Fatalf("-wages is needed\n")
+	}
+	if _, ok := hcp.PriceAggregations[*aggregation]; !ok {
+		log.Fatalf("Unknown aggregation '%s'\n", *aggregation)
+	}
+
+	wages, err := readWages(*wagesFilename)
+	if err != nil {
+		log.Fatalf("Cannot read wages: %s\n", err.Error())
+	}
+	config, err := hcp.LoadConfiguration(*configFilename)
+	if err != nil {
+		log.Fatalf("Cannot load configuration: %s\n", err.Error())
+	}
+	adverts, minDate, maxDate, _ := loadAdverts(flags.Args())
+	systems, _ := hcp.PublishedPrices(adverts, minDate, maxDate, config, *aggregation)
+	keys := sortedKeys(systems)
+	if *systemList != "" {
+		keys = splitSystems(*systemList, config)
+		for _, key := range keys {
+			if _, ok := systems[key]; !ok {
+				log.Fatalf("No prices found for system '%s'\n", key)
+			}
+		}
+	}
+
+	missing := make(map[int]bool)
+	writeOutput(*outputFilename, func(w io.Writer) {
+		out := csv.NewWriter(w)
+		out.Write([]string{"System", "Quarter", "Price", "Weekly wage", "Weeks of wage"})
+		for _, key := range keys {
+			prices := systems[key]
+			for index := minDate; index <= maxDate; index++ {
+				if prices[index-minDate] <= 0 {
+					continue
+				}
+				year, _ := hcp.DecodeIndexByQuarter(index)
+				wage, ok := wages[year]
+				if !ok {
+					missing[year] = true
+					continue
+				}
+				weeks := float64(prices[index-minDate]) / float64(wage)
+				out.Write([]string{key, hcp.FormatQuarter(index), formatPrice(prices[index-minDate], "exact"), formatPrice(wage, "exact"), fmt.Sprintf("%.2f", weeks)})
+			}
+		}
+		out.Flush()
+		if err := out.Error(); err != nil {
+			log.Fatalln("Cannot write CSV data:", err.Error())
+		}
+	})
+	years := make([]int, 0, len(missing))
+	for year := range missing {
+		years = append(years, year)
+	}
+	sort.Ints(years)
+	for _, year := range years {
+		logf("No average wage for %d in '%s', so its quarters are not reported\n", year, *wagesFilename)
+	}
+}
+
+// Read a series of average weekly wages, returning the wage in pence for each year
+func readWages(filename string) (map[int]int, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	rows, _, err := hcp.ReadCSVRows(f, 0)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read [%s] (%w)", filename, err)
+	}
+
+	wages := make(map[int]int)
+	yearColumn, wageColumn := -1, -1
+	for i, row := range rows {
+		if hcp.IsComment(row) || (len(strings.Join(row, "")) == 0) {
+			continue
+		}
+		if yearColumn < 0 {
+			for column, heading := range row {
+				switch strings.TrimSpace(heading) {
+				case "Year":
+					yearColumn = column
+				case "Weekly wage":
+					wageColumn = column
+				}
+			}
+			if (yearColumn < 0) || (wageColumn < 0) {
+				return nil, fmt.Errorf("[%s] line %d: expected a header with \"Year\" and \"Weekly wage\" columns", filename, i+1)
+			}
+			continue
+		}
+		if (yearColumn >= len(row)) || (wageColumn >= len(row)) {
+			return nil, fmt.Errorf("[%s] line %d: too few fields", filename, i+1)
+		}
+		year, err := strconv.Atoi(strings.TrimSpace(row[yearColumn]))
+		if err != nil {
+			return nil, fmt.Errorf("[%s] line %d: bad year [%s]", filename, i+1, row[yearColumn])
+		}
+		text := strings.NewReplacer("£", "", ",", "").Replace(strings.TrimSpace(row[wageColumn]))
+		pounds, err := strconv.ParseFloat(strings.TrimSpace(text), 64)
+		if (err != nil) || (pounds <= 0) {
+			return nil, fmt.Errorf("[%s] line %d: bad weekly wage [%s]", filename, i+1, row[wageColumn])
+		}
+		if _, ok := wages[year]; ok {
+			return nil, fmt.Errorf("[%s] line %d: a second wage for %d", filename, i+1, year)
+		}
+		wages[year] = int(math.Round(pounds * 100))
+	}
+	return wages, nil
+}
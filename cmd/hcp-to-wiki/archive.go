@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"log"
+
+	"github.com/AntonioCarlini/home-computer-prices/hcp"
+)
+
+// The archive output is a single HTML file holding everything needed to browse the prices offline, such as at a
+// retro-computing event without network access: a section per system with a chart and a table of its prices, and
+// a search box that hides the systems whose names do not match. Nothing is loaded from elsewhere.
+// The data itself is also embedded, as the document written to plugins, in a <script id="hcp-data"> element.
+
+// Styles and script for the archive page
+const archive_style = `body { font-family: sans-serif; margin: 2em; }
+input#search { font-size: 1.2em; width: 30em; max-width: 100%; }
+section { border-top: 1px solid #ccc; margin-top: 1.5em; }
+table { border-collapse: collapse; }
+td, th { border: 1px solid #ccc; padding: 0.2em 0.6em; }
+td.price { text-align: right; }
+.interpolated { font-style: italic; }
+.carried { color: grey; }
+.off-sale { background: #eee; }
+svg { max-width: 100%; height: auto; }`
+
+const archive_script = `document.getElementById("search").addEventListener("input", function (event) {
+  var text = event.target.value.toLowerCase();
+  var sections = document.querySelectorAll("section[data-system]");
+  var shown = 0;
+  for (var i = 0; i < sections.length; i++) {
+    var match = sections[i].getAttribute("data-system").toLowerCase().indexOf(text) >= 0;
+    sections[i].hidden = !match;
+    if (match) { shown++; }
+  }
+  document.getElementById("count").textContent = shown + " of " + sections.length + " systems";
+});`
+
+// Given advert data for a range of systems, outputs that data as a self-contained interactive HTML page
+func outputArchive(w io.Writer, table priceTable) {
+	keys, minDate, maxDate := table.keys, table.minDate, table.maxDate
+	title := fmt.Sprintf("Home computer prices, %s to %s", hcp.FormatQuarter(minDate), hcp.FormatQuarter(maxDate))
+
+	fmt.Fprintf(w, "<!DOCTYPE html>\n<html lang=\"en\">\n<head>\n<meta charset=\"utf-8\">\n")
+	fmt.Fprintf(w, "<title>%s</title>\n<style>\n%s\n</style>\n</head>\n<body>\n", html.EscapeString(title), archive_style)
+	fmt.Fprintf(w, "<h1>%s</h1>\n", html.EscapeString(title))
+	fmt.Fprintf(w, "<p><input id=\"search\" type=\"search\" placeholder=\"Search systems\" autofocus> <span id=\"count\"></span></p>\n")
+	for _, note := range table.legend() {
+		fmt.Fprintf(w, "<p>%s</p>\n", html.EscapeString(note))
+	}
+
+	for _, key := range keys {
+		if !table.hasPrices(key) {
+			continue
+		}
+		fmt.Fprintf(w, "<section data-system=\"%s\">\n<h2>%s</h2>\n", html.EscapeString(key), html.EscapeString(key))
+		writeSystemPrices(w, table, key)
+		fmt.Fprintf(w, "</section>\n")
+	}
+
+	outputHTMLAttribution(w, table)
+
+	data, err := json.Marshal(newPluginDocument(table))
+	if err != nil {
+		log.Fatalln("Cannot encode archive data:", err.Error())
+	}
+	fmt.Fprintf(w, "<script type=\"application/json\" id=\"hcp-data\">%s</script>\n", data)
+	fmt.Fprintf(w, "<script>\n%s\n</script>\n", archive_script)
+	if table.stamp != "" {
+		fmt.Fprintf(w, "<!-- %s -->\n", table.stamp)
+	}
+	fmt.Fprintf(w, "</body>\n</html>\n")
+}
+
+// Return true if a system has a price in any quarter of the table
+func (table priceTable) hasPrices(system string) bool {
+	first, _ := table.priceSpan(system)
+	return first >= 0
+}
+
+// Return the date-indexes of the first and last quarters in which a system has a price, or -1, -1 if it has none
+func (table priceTable) priceSpan(system string) (first int, last int) {
+	first, last = -1, -1
+	for index := table.minDate; index <= table.maxDate; index++ {
+		if table.systems[system][index-table.minDate] > 0 {
+			if first < 0 {
+				first = index
+			}
+			last = index
+		}
+	}
+	return first, last
+}
+
+// Outputs a chart and a table of a system's prices, over only the span of quarters in which it has prices
+func writeSystemPrices(w io.Writer, table priceTable, key string) {
+	prices, minDate := table.systems[key], table.minDate
+	first, last := table.priceSpan(key)
+	values := make([]int, 0, last-first+1)
+	for index := first; index <= last; index++ {
+		if prices[index-minDate] > 0 {
+			values = append(values, prices[index-minDate])
+		} else {
+			values = append(values, -1)
+		}
+	}
+	label := func(pence int) string {
+		return "£" + formatPrice(pence, table.rounding)
+	}
+	var shaded func(index int) bool
+	if _, ok := table.lifespans[key]; ok {
+		shaded = func(index int) bool { return table.offSale(key, index) }
+	}
+	writeLineChart(w, key, first, last, []chartSeries{{key, values}}, label, shaded)
+
+	fmt.Fprintf(w, "<table>\n<tr><th>Quarter</th><th>Price</th></tr>\n")
+	for index := first; index <= last; index++ {
+		if prices[index-minDate] <= 0 {
+			continue
+		}
+		kind := table.kind(key, index)
+		price := table.markedCellText(key, index, label)
+		class := "price " + kind.String()
+		if table.offSale(key, index) {
+			class += " off-sale"
+		}
+		fmt.Fprintf(w, "<tr><td>%s</td><td class=\"%s\">%s</td></tr>\n", hcp.FormatQuarter(index), class, html.EscapeString(price))
+	}
+	fmt.Fprintf(w, "</table>\n")
+}
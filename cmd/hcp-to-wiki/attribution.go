@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+
+	"github.com/AntonioCarlini/home-computer-prices/hcp"
+)
+
+// The attribution section of the configuration is appended to each generated page or file,
+// in whatever form suits the format: visible text in the wiki and HTML output, comments and a field in the Lua module,
+// and schema.org properties in the JSON-LD output. The matrix CSV has nowhere to put it.
+
+// Return the attribution as plain sentences, or "" if there is none.
+// link formats a URL with the text to show for it.
+func attributionSentences(attribution hcp.Attribution, link func(url string, text string) string) string {
+	sentences := make([]string, 0, 3)
+	switch {
+	case (attribution.Licence != "") && (attribution.LicenceURL != ""):
+		sentences = append(sentences, "Data licensed under "+link(attribution.LicenceURL, attribution.Licence)+".")
+	case attribution.Licence != "":
+		sentences = append(sentences, "Data licensed under "+attribution.Licence+".")
+	case attribution.LicenceURL != "":
+		sentences = append(sentences, "Licence: "+link(attribution.LicenceURL, attribution.LicenceURL)+".")
+	}
+	if len(attribution.Contributors) > 0 {
+		sentences = append(sentences, "Contributors: "+strings.Join(attribution.Contributors, ", ")+".")
+	}
+	if attribution.Repository != "" {
+		sentences = append(sentences, "Source: "+link(attribution.Repository, attribution.Repository)+".")
+	}
+	return strings.Join(sentences, " ")
+}
+
+// Return the attribution as plain text
+func attributionText(attribution hcp.Attribution) string {
+	return attributionSentences(attribution, func(url string, text string) string {
+		if url == text {
+			return url
+		}
+		return text + " (" + url + ")"
+	})
+}
+
+// Outputs the attribution, if there is one, as small print at the foot of a wiki page
+func outputWikiAttribution(w io.Writer, table priceTable) {
+	if table.attribution.IsEmpty() {
+		return
+	}
+	text := attributionSentences(table.attribution, func(url string, text string) string {
+		if url == text {
+			return url
+		}
+		return "[" + url + " " + text + "]"
+	})
+	fmt.Fprintf(w, "----\n<small>%s</small>\n\n", text)
+}
+
+// Outputs the attribution, if there is one, as the footer of an HTML page
+func outputHTMLAttribution(w io.Writer, table priceTable) {
+	if table.attribution.IsEmpty() {
+		return
+	}
+	escaped := hcp.Attribution{
+		Licence:    html.EscapeString(table.attribution.Licence),
+		LicenceURL: html.EscapeString(table.attribution.LicenceURL),
+		Repository: html.EscapeString(table.attribution.Repository),
+	}
+	for _, contributor := range table.attribution.Contributors {
+		escaped.Contributors = append(escaped.Contributors, html.EscapeString(contributor))
+	}
+	text := attributionSentences(escaped, func(url string, text string) string {
+		return "<a href=\"" + url + "\">" + text + "</a>"
+	})
+	fmt.Fprintf(w, "<footer><small>%s</small></footer>\n", text)
+}
+
+// Outputs the wiki page footer: the attribution, then the metadata stamp
+func outputWikiFooter(w io.Writer, table priceTable) {
+	outputWikiAttribution(w, table)
+	outputWikiStamp(w, table)
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+
+	"github.com/AntonioCarlini/home-computer-prices/hcp"
+)
+
+// Write the audit trail of the published prices as CSV: a row per advert behind each cell of the tables, so that
+// "where did this number come from?" can be answered by looking up the system and quarter.
+// Chosen is "Y" for each advert whose price is the one published; a median of an even number of adverts may match none.
+// An estimated cell has a single row naming no advert, and a withheld cell lists the adverts that were too few to publish.
+func writeAuditCSV(w io.Writer, table priceTable) {
+	out := csv.NewWriter(w)
+	out.Write([]string{"System", "Quarter", "Price", "Kind", "Chosen", "File", "Row", "Magazine", "Issue", "Page", "Advert Price"})
+	for _, key := range table.keys {
+		for index := table.minDate; index <= table.maxDate; index++ {
+			pence := table.systems[key][index-table.minDate]
+			kind := table.kind(key, index)
+			if (pence <= 0) && (kind != withheldPrice) {
+				continue
+			}
+			price := ""
+			if pence > 0 {
+				price = formatPrice(pence, table.rounding)
+			}
+			cell := []string{key, hcp.FormatQuarter(index), price, kind.String()}
+			if (kind == interpolatedPrice) || (kind == carriedPrice) {
+				out.Write(append(cell, "", "", "", "", "", "", ""))
+				continue
+			}
+			for _, advert := range table.observations[key][index-table.minDate] {
+				chosen := ""
+				if kind == observedPrice {
+					chosen = "N"
+					if advert.Price == pence {
+						chosen = "Y"
+					}
+				}
+				// A page number that could not be read is not worth repeating
+				page := ""
+				if advert.Page >= 0 {
+					page = "p" + strconv.Itoa(advert.Page)
+				}
+				issue := fmt.Sprintf("%04d-%02d", advert.Year, advert.Month)
+				out.Write(append(cell, chosen, advert.File, strconv.Itoa(advert.Row), advert.Magazine, issue, page, formatPrice(advert.Price, "exact")))
+			}
+		}
+	}
+	out.Flush()
+	if err := out.Error(); err != nil {
+		log.Fatalln("Cannot write CSV data:", err.Error())
+	}
+}
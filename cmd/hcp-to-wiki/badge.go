@@ -0,0 +1,110 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/AntonioCarlini/home-computer-prices/hcp"
+)
+
+// Implements "badge [-out-dir dir] [-config rules.json] [-updated 2026-10-14] data.csv ...".
+// Writes SVG badges, in the style of those shown on project pages, for the data repository's landing page:
+// badge-adverts.svg (the adverts accepted), badge-systems.svg (the systems published) and badge-updated.svg
+// (the date the data was last changed: that of the most recently modified input, unless -updated says otherwise).
+func runBadge(args []string) {
+	flags := flag.NewFlagSet("badge", flag.ExitOnError)
+	outputDir := flags.String("out-dir", ".", "write the badges to this directory")
+	configFilename := flags.String("config", "", "JSON file of rules applied to the data (default: the built-in rules)")
+	updated := flags.String("updated", "", "the date shown on the last-updated badge (default: when the newest input was modified)")
+	flags.Parse(args)
+	if flags.NArg() < 1 {
+		log.Fatalf("At least 1 argument required but %d supplied\n", flags.NArg())
+	}
+
+	config, err := hcp.LoadConfiguration(*configFilename)
+	if err != nil {
+		log.Fatalf("Cannot load configuration: %s\n", err.Error())
+	}
+	dataset, err := hcp.LoadFiles(flags.Args(), hcp.Options{Config: &config})
+	if err != nil {
+		log.Fatalf("Cannot load adverts: %s\n", err.Error())
+	}
+	if *updated == "" {
+		var newest time.Time
+		for _, filename := range flags.Args() {
+			info, err := os.Stat(filename)
+			if err != nil {
+				log.Fatalf("Cannot read '%s': %s\n", filename, err.Error())
+			}
+			if info.ModTime().After(newest) {
+				newest = info.ModTime()
+			}
+		}
+		*updated = newest.UTC().Format("2006-01-02")
+	}
+
+	if err := os.MkdirAll(*outputDir, 0755); err != nil {
+		log.Fatalf("Cannot create '%s': %s\n", *outputDir, err.Error())
+	}
+	badges := []struct {
+		name  string
+		label string
+		value string
+	}{
+		{"adverts", "adverts", groupThousands(len(dataset.Adverts))},
+		{"systems", "systems tracked", groupThousands(len(dataset.Systems()))},
+		{"updated", "last updated", *updated},
+	}
+	for _, badge := range badges {
+		writeOutput(filepath.Join(*outputDir, "badge-"+badge.name+".svg"), func(w io.Writer) {
+			writeBadge(w, badge.label, badge.value)
+		})
+	}
+}
+
+// Badge layout, in pixels
+const (
+	badge_height      = 20
+	badge_padding     = 6   // Either side of each part's text
+	badge_char_width  = 6.5 // The average width of a character, at the badge's font size
+	badge_label_color = "#555"
+	badge_value_color = "#007ec6"
+)
+
+// Write a two-part SVG badge: the label on grey, then the value on blue
+func writeBadge(w io.Writer, label string, value string) {
+	width := func(text string) int {
+		return int(float64(len([]rune(text)))*badge_char_width) + 2*badge_padding
+	}
+	labelWidth, valueWidth := width(label), width(value)
+	total := labelWidth + valueWidth
+	label, value = html.EscapeString(label), html.EscapeString(value)
+
+	fmt.Fprintf(w, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" role=\"img\" aria-label=\"%s: %s\">\n", total, badge_height, label, value)
+	fmt.Fprintf(w, "<title>%s: %s</title>\n", label, value)
+	fmt.Fprintf(w, "<clipPath id=\"r\"><rect width=\"%d\" height=\"%d\" rx=\"3\"/></clipPath>\n", total, badge_height)
+	fmt.Fprintf(w, "<g clip-path=\"url(#r)\">\n")
+	fmt.Fprintf(w, "<rect width=\"%d\" height=\"%d\" fill=\"%s\"/>\n", labelWidth, badge_height, badge_label_color)
+	fmt.Fprintf(w, "<rect x=\"%d\" width=\"%d\" height=\"%d\" fill=\"%s\"/>\n", labelWidth, valueWidth, badge_height, badge_value_color)
+	fmt.Fprintf(w, "</g>\n")
+	fmt.Fprintf(w, "<g fill=\"#fff\" text-anchor=\"middle\" font-family=\"Verdana,Geneva,DejaVu Sans,sans-serif\" font-size=\"11\">\n")
+	fmt.Fprintf(w, "<text x=\"%d\" y=\"14\">%s</text>\n", labelWidth/2, label)
+	fmt.Fprintf(w, "<text x=\"%d\" y=\"14\">%s</text>\n", labelWidth+valueWidth/2, value)
+	fmt.Fprintf(w, "</g>\n</svg>\n")
+}
+
+// Return a count written with commas between each group of three digits, such as "12,345"
+func groupThousands(n int) string {
+	digits := strconv.Itoa(n)
+	for i := len(digits) - 3; i > 0; i -= 3 {
+		digits = digits[:i] + "," + digits[i:]
+	}
+	return digits
+}
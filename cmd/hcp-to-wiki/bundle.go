@@ -0,0 +1,72 @@
+package main
+
+import (
+	"archive/zip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// With -archive, every file written by a generation run (the output in each format, subpages, languages, per-magazine tables,
+// the matrix and audit CSV files and the validation report) is also packed into a single zip archive,
+// ready to attach to a release of the dataset. Files in -out-dir keep their place in it; others are stored by name alone.
+
+// Every file written by writeOutput or writePages, in the order they were written
+var writtenFiles []string
+
+// Pack the files into a new zip archive, naming each relative to outputDir if it is inside it
+func writeArchive(filename string, files []string, outputDir string) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	archive := zip.NewWriter(f)
+	seen := make(map[string]bool)
+	for _, file := range files {
+		name := filepath.Base(file)
+		if outputDir != "" {
+			if relative, err := filepath.Rel(outputDir, file); (err == nil) && !strings.HasPrefix(relative, "..") {
+				name = relative
+			}
+		}
+		name = filepath.ToSlash(name)
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		if err := addToArchive(archive, name, file); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Copy a file into the archive under the given name
+func addToArchive(archive *zip.Writer, name string, filename string) error {
+	in, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	header.Name, header.Method = name, zip.Deflate
+	out, err := archive.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, in)
+	return err
+}
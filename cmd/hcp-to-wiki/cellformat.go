@@ -0,0 +1,111 @@
+package main
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/AntonioCarlini/home-computer-prices/hcp"
+)
+
+// What the wiki and archive tables show for each quarter, selected with -cell-format.
+// o price shows the published price, which is what the tables have always done
+// o min-median shows the lowest advert price and the median of all the quarter's advert prices, such as "£175 / £199",
+// whatever -aggregate chose; a quarter whose lowest and median prices are the same, or whose price is an estimate, shows one price
+var cellFormats = []string{"price", "min-median"}
+
+// Return the lowest and the median of the prices of the adverts behind a system's price at a date-index.
+// ok is false if the price was not chosen from adverts, so there is nothing to show beside it.
+func (table priceTable) lowAndMedian(system string, index int) (low int, median int, ok bool) {
+	if (table.kind(system, index) != observedPrice) || (table.observations == nil) {
+		return 0, 0, false
+	}
+	adverts := table.observations[system][index-table.minDate]
+	if len(adverts) == 0 {
+		return 0, 0, false
+	}
+	prices := make([]int, 0, len(adverts))
+	for _, advert := range adverts {
+		prices = append(prices, advert.Price)
+	}
+	return hcp.PriceAggregations["min"](prices), hcp.PriceAggregations["median"](prices), true
+}
+
+// Return the text shown in the table for a system's price at a date-index, each price being written by format
+func (table priceTable) cellText(system string, index int, format func(pence int) string) string {
+	if table.cellFormat == "min-median" {
+		if low, median, ok := table.lowAndMedian(system, index); ok && (low != median) {
+			return format(low) + " / " + format(median)
+		}
+	}
+	return format(table.systems[system][index-table.minDate])
+}
+
+// Return the text shown in the table for a system's price at a date-index, as cellText does,
+// with the markers for a price taken from a single advert or from adverts dated only by year,
+// followed by the currencies the price was converted from
+func (table priceTable) markedCellText(system string, index int, format func(pence int) string) string {
+	text := table.cellText(system, index, format)
+	if table.singleAdvert(system, index) {
+		text += single_advert_marker
+	}
+	if currencies := table.currencies(system, index); currencies != "" {
+		text += " [" + currencies + "]"
+	}
+	if table.approximateDate(system, index) {
+		text = approximate_date_marker + text
+	}
+	return text
+}
+
+// Return the symbols of the currencies that the adverts behind a system's price at a date-index were quoted in,
+// separated by "/", such as "$" or "$/£", if -show-currency is given and any of them was converted into pounds; otherwise ""
+func (table priceTable) currencies(system string, index int) string {
+	if !table.showCurrency || (table.kind(system, index) != observedPrice) || (table.observations == nil) {
+		return ""
+	}
+	symbols := make([]string, 0)
+	converted := false
+	for _, advert := range table.observations[system][index-table.minDate] {
+		symbol := advert.Currency
+		if symbol == "" {
+			symbol = "£"
+		} else {
+			converted = true
+		}
+		if !sliceContainsString(symbols, symbol) {
+			symbols = append(symbols, symbol)
+		}
+	}
+	if !converted {
+		return ""
+	}
+	sort.Strings(symbols)
+	return strings.Join(symbols, "/")
+}
+
+// Return true if any price is followed by the currencies it was converted from
+func (table priceTable) hasCurrencies() bool {
+	for _, system := range table.keys {
+		for index := table.minDate; index <= table.maxDate; index++ {
+			if table.currencies(system, index) != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Return true if any quarter shows both a lowest and a median price, so that the table needs a note explaining them
+func (table priceTable) showsMedians() bool {
+	if table.cellFormat != "min-median" {
+		return false
+	}
+	for _, system := range table.keys {
+		for index := table.minDate; index <= table.maxDate; index++ {
+			if low, median, ok := table.lowAndMedian(system, index); ok && (low != median) {
+				return true
+			}
+		}
+	}
+	return false
+}
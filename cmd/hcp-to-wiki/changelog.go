@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// After publishing, a one-line changelog of what changed in the price tables is printed, such as
+// "3 new systems (A, B, C), 124 new prices, 17 prices changed", for the wiki's talk page or a release note.
+// It is worked out by reading the tables back out of the wiki's current text and the text published (see parseWikiTables).
+
+// What changed in the price tables between two versions of the wiki pages
+type tableChanges struct {
+	newSystems     []string // Systems with prices only in the new version, in alphabetical order
+	removedSystems []string // Systems with prices only in the old version, in alphabetical order
+	newPrices      int      // Prices in quarters that had none
+	changedPrices  int      // Prices that differ
+	removedPrices  int      // Quarters whose price has gone
+}
+
+// Given the old and new text of the pages, return what changed in their price tables
+func compareWikiTables(old string, new string) tableChanges {
+	before, _ := parseWikiTables(old)
+	after, _ := parseWikiTables(new)
+
+	type cell struct {
+		system string
+		index  int
+	}
+	oldPrices := make(map[cell]string, len(before))
+	oldSystems := make(map[string]bool)
+	for _, price := range before {
+		oldPrices[cell{price.system, price.index}] = price.price
+		oldSystems[price.system] = true
+	}
+	newSystems := make(map[string]bool)
+
+	var changes tableChanges
+	for _, price := range after {
+		newSystems[price.system] = true
+		key := cell{price.system, price.index}
+		if text, ok := oldPrices[key]; !ok {
+			changes.newPrices++
+		} else if text != price.price {
+			changes.changedPrices++
+		}
+		delete(oldPrices, key)
+	}
+	changes.removedPrices = len(oldPrices)
+
+	for system := range newSystems {
+		if !oldSystems[system] {
+			changes.newSystems = append(changes.newSystems, system)
+		}
+	}
+	for system := range oldSystems {
+		if !newSystems[system] {
+			changes.removedSystems = append(changes.removedSystems, system)
+		}
+	}
+	sort.Strings(changes.newSystems)
+	sort.Strings(changes.removedSystems)
+	return changes
+}
+
+// Return the changes as a human-readable line, such as "3 new systems (A, B, C), 124 new prices, 17 prices changed"
+func (changes tableChanges) String() string {
+	parts := make([]string, 0, 5)
+	if len(changes.newSystems) > 0 {
+		parts = append(parts, fmt.Sprintf("%s (%s)", plural(len(changes.newSystems), "new system", "new systems"), strings.Join(changes.newSystems, ", ")))
+	}
+	if changes.newPrices > 0 {
+		parts = append(parts, plural(changes.newPrices, "new price", "new prices"))
+	}
+	if changes.changedPrices > 0 {
+		parts = append(parts, plural(changes.changedPrices, "price changed", "prices changed"))
+	}
+	if changes.removedPrices > 0 {
+		parts = append(parts, plural(changes.removedPrices, "price removed", "prices removed"))
+	}
+	if len(changes.removedSystems) > 0 {
+		parts = append(parts, fmt.Sprintf("%s (%s)", plural(len(changes.removedSystems), "system removed", "systems removed"), strings.Join(changes.removedSystems, ", ")))
+	}
+	if len(parts) == 0 {
+		return "no prices changed"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Return a count followed by the singular or plural form of what it counts
+func plural(count int, singular string, plurals string) string {
+	if count == 1 {
+		return "1 " + singular
+	}
+	return fmt.Sprintf("%d %s", count, plurals)
+}
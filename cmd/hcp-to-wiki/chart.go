@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"math"
+
+	"github.com/AntonioCarlini/home-computer-prices/hcp"
+)
+
+// One line on a chart: a value for each date-index from the chart's minDate to its maxDate.
+// Negative values are gaps in the data and break the line.
+type chartSeries struct {
+	name   string
+	values []int
+}
+
+// Chart layout, in SVG user units
+const (
+	chart_width         = 900
+	chart_height        = 400
+	chart_left_margin   = 60
+	chart_right_margin  = 200 // Room for the legend
+	chart_top_margin    = 40
+	chart_bottom_margin = 40
+)
+
+// Colours used for successive series
+var chartColours = []string{"#1f77b4", "#ff7f0e", "#2ca02c", "#d62728", "#9467bd", "#8c564b", "#e377c2", "#7f7f7f", "#bcbd22", "#17becf"}
+
+// Write an SVG line chart with quarters along the x-axis.
+// Each series is drawn as a line, broken wherever the data has gaps; isolated points are drawn as dots.
+// label formats a y-axis value for display. If shaded is not nil, the quarters for which it returns true are shaded.
+func writeLineChart(w io.Writer, title string, minDate int, maxDate int, series []chartSeries, label func(value int) string, shaded func(index int) bool) {
+	highest := 0
+	for _, s := range series {
+		for _, value := range s.values {
+			highest = max(highest, value)
+		}
+	}
+	top := niceCeiling(highest)
+
+	plotWidth := chart_width - chart_left_margin - chart_right_margin
+	plotHeight := chart_height - chart_top_margin - chart_bottom_margin
+	x := func(index int) float64 {
+		if maxDate == minDate {
+			return chart_left_margin + float64(plotWidth)/2
+		}
+		return chart_left_margin + float64(index-minDate)*float64(plotWidth)/float64(maxDate-minDate)
+	}
+	y := func(value int) float64 {
+		return chart_top_margin + float64(plotHeight) - float64(value)*float64(plotHeight)/float64(top)
+	}
+
+	fmt.Fprintf(w, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\" font-family=\"sans-serif\" font-size=\"12\">\n", chart_width, chart_height, chart_width, chart_height)
+	fmt.Fprintf(w, "<title>%s</title>\n", html.EscapeString(title))
+	fmt.Fprintf(w, "<text x=\"%d\" y=\"20\" font-size=\"16\">%s</text>\n", chart_left_margin, html.EscapeString(title))
+
+	// Shaded quarters, each a band centred on its point and reaching halfway to its neighbours
+	if shaded != nil {
+		half := float64(plotWidth) / 2
+		if maxDate > minDate {
+			half = float64(plotWidth) / float64(maxDate-minDate) / 2
+		}
+		fmt.Fprintf(w, "<g fill=\"%s\">\n", off_sale_background)
+		for index := minDate; index <= maxDate; index++ {
+			if shaded(index) {
+				left := math.Max(x(index)-half, chart_left_margin)
+				right := math.Min(x(index)+half, float64(chart_left_margin+plotWidth))
+				fmt.Fprintf(w, "<rect x=\"%.1f\" y=\"%d\" width=\"%.1f\" height=\"%d\"/>\n", left, chart_top_margin, right-left, plotHeight)
+			}
+		}
+		fmt.Fprintf(w, "</g>\n")
+	}
+
+	// Axes, with a horizontal grid line and label for each fifth of the y-axis
+	fmt.Fprintf(w, "<g stroke=\"#ccc\">\n")
+	for step := 0; step <= 5; step++ {
+		value := top * step / 5
+		fmt.Fprintf(w, "<line x1=\"%d\" y1=\"%.1f\" x2=\"%d\" y2=\"%.1f\"/>\n", chart_left_margin, y(value), chart_left_margin+plotWidth, y(value))
+	}
+	fmt.Fprintf(w, "</g>\n<g text-anchor=\"end\">\n")
+	for step := 0; step <= 5; step++ {
+		value := top * step / 5
+		fmt.Fprintf(w, "<text x=\"%d\" y=\"%.1f\">%s</text>\n", chart_left_margin-5, y(value)+4, html.EscapeString(label(value)))
+	}
+	fmt.Fprintf(w, "</g>\n")
+
+	// Label the start of each year, thinning the labels out if there are very many years
+	minYear, _ := hcp.DecodeIndexByQuarter(minDate)
+	maxYear, _ := hcp.DecodeIndexByQuarter(maxDate)
+	yearStep := 1 + (maxYear-minYear)/15
+	fmt.Fprintf(w, "<g text-anchor=\"middle\">\n")
+	for year := minYear; year <= maxYear; year += yearStep {
+		index := max(hcp.BuildIndexFromYearAndQuarter(year, 1), minDate)
+		fmt.Fprintf(w, "<line x1=\"%.1f\" y1=\"%d\" x2=\"%.1f\" y2=\"%d\" stroke=\"#000\"/>\n", x(index), chart_top_margin+plotHeight, x(index), chart_top_margin+plotHeight+5)
+		fmt.Fprintf(w, "<text x=\"%.1f\" y=\"%d\">%d</text>\n", x(index), chart_top_margin+plotHeight+20, year)
+	}
+	fmt.Fprintf(w, "</g>\n")
+	fmt.Fprintf(w, "<line x1=\"%d\" y1=\"%d\" x2=\"%d\" y2=\"%d\" stroke=\"#000\"/>\n", chart_left_margin, chart_top_margin+plotHeight, chart_left_margin+plotWidth, chart_top_margin+plotHeight)
+
+	// The series themselves, each followed by its legend entry
+	for i, s := range series {
+		colour := chartColours[i%len(chartColours)]
+		fmt.Fprintf(w, "<g stroke=\"%s\" fill=\"%s\">\n", colour, colour)
+		run := make([][2]float64, 0)
+		flush := func() {
+			if len(run) == 1 {
+				fmt.Fprintf(w, "<circle cx=\"%.1f\" cy=\"%.1f\" r=\"3\"/>\n", run[0][0], run[0][1])
+			} else if len(run) > 1 {
+				fmt.Fprintf(w, "<polyline fill=\"none\" stroke-width=\"2\" points=\"")
+				for j, point := range run {
+					if j > 0 {
+						fmt.Fprintf(w, " ")
+					}
+					fmt.Fprintf(w, "%.1f,%.1f", point[0], point[1])
+				}
+				fmt.Fprintf(w, "\"/>\n")
+			}
+			run = run[:0]
+		}
+		for index := minDate; index <= maxDate; index++ {
+			value := s.values[index-minDate]
+			if value < 0 {
+				flush()
+				continue
+			}
+			run = append(run, [2]float64{x(index), y(value)})
+		}
+		flush()
+		legendY := chart_top_margin + 10 + i*18
+		fmt.Fprintf(w, "<rect x=\"%d\" y=\"%d\" width=\"12\" height=\"12\"/>\n", chart_width-chart_right_margin+15, legendY-10)
+		fmt.Fprintf(w, "<text x=\"%d\" y=\"%d\" stroke=\"none\" fill=\"#000\">%s</text>\n", chart_width-chart_right_margin+32, legendY, html.EscapeString(s.name))
+		fmt.Fprintf(w, "</g>\n")
+	}
+	fmt.Fprintf(w, "</svg>\n")
+}
+
+// Round a value up to something that makes a tidy top for an axis divided into five: 1, 2 or 5 times a power of ten
+func niceCeiling(value int) int {
+	for magnitude := 1; ; magnitude *= 10 {
+		for _, multiple := range []int{1, 2, 5} {
+			if multiple*magnitude*5 >= value && multiple*magnitude*5 > 0 {
+				return multiple * magnitude * 5
+			}
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/AntonioCarlini/home-computer-prices/hcp"
+)
+
+// Implements "checksum [-o manifest.json] [-verify manifest.json] data.csv ...".
+// Writes a manifest of the dataset's files, giving the SHA-256 of each and its row counts (as in a validation report),
+// for publishing alongside the data so that a mirror of it can show that it is unmodified. With -verify, the files are
+// checked against such a manifest instead: every file it lists must be given, with the same hash and row counts.
+// Files are named in the manifest as they were given on the command line; the rows of a zip archive's CSV files are
+// counted together. Each difference is listed with the diagnostics, and the exit status is 1 if there are any.
+func runChecksum(args []string) {
+	flags := flag.NewFlagSet("checksum", flag.ExitOnError)
+	outputFilename := flags.String("o", "", "write the manifest to this file instead of standard output")
+	verifyFilename := flags.String("verify", "", "check the files against this manifest instead of writing one")
+	flags.Parse(args)
+	if flags.NArg() < 1 {
+		log.Fatalf("At least 1 argument required but %d supplied\n", flags.NArg())
+	}
+
+	manifest, err := buildChecksumManifest(flags.Args())
+	if err != nil {
+		log.Fatalf("Cannot build manifest: %s\n", err.Error())
+	}
+	if *verifyFilename == "" {
+		writeOutput(*outputFilename, func(w io.Writer) {
+			encoder := json.NewEncoder(w)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(manifest); err != nil {
+				log.Fatalln("Cannot write manifest:", err.Error())
+			}
+		})
+		return
+	}
+
+	data, err := os.ReadFile(*verifyFilename)
+	if err != nil {
+		log.Fatalf("Cannot read manifest: %s\n", err.Error())
+	}
+	var expected checksumManifest
+	if err := json.Unmarshal(data, &expected); err != nil {
+		log.Fatalf("Cannot read manifest: bad manifest [%s] (%s)\n", *verifyFilename, err.Error())
+	}
+	differences := compareChecksumManifests(expected, manifest)
+	for _, difference := range differences {
+		logf("%s\n", difference)
+	}
+	logf("%d of %d file(s) in '%s' differ\n", len(differences), len(expected.Files), *verifyFilename)
+	if len(differences) > 0 {
+		os.Exit(1)
+	}
+}
+
+// A checksumManifest is the manifest written by checksum
+type checksumManifest struct {
+	Generator string          `json:"generator"` // The program that wrote it, as in the -stamp of a generation run
+	Files     []checksumEntry `json:"files"`
+}
+
+// A checksumEntry is the hash and row counts of one file of the dataset
+type checksumEntry struct {
+	File     string `json:"file"`
+	SHA256   string `json:"sha256"`
+	Bytes    int64  `json:"bytes"`
+	Rows     int    `json:"rows"`
+	Accepted int    `json:"accepted"`
+	Rejected int    `json:"rejected"`
+}
+
+// Given the files of the dataset, hash each of them and count its rows
+func buildChecksumManifest(filenames []string) (checksumManifest, error) {
+	_, _, _, validations, err := hcp.ReadIssueAdverts(filenames, nil)
+	if err != nil {
+		return checksumManifest{}, err
+	}
+	manifest := checksumManifest{Generator: "hcp-to-wiki " + toolVersion(), Files: make([]checksumEntry, 0, len(filenames))}
+	for _, filename := range filenames {
+		f, err := os.Open(filename)
+		if err != nil {
+			return checksumManifest{}, err
+		}
+		digest := sha256.New()
+		size, err := io.Copy(digest, f)
+		f.Close()
+		if err != nil {
+			return checksumManifest{}, fmt.Errorf("cannot read [%s] (%w)", filename, err)
+		}
+		entry := checksumEntry{File: filename, SHA256: hex.EncodeToString(digest.Sum(nil)), Bytes: size}
+		// A zip archive holds several CSV files, each validated as "archive.zip/entry.csv", which are counted together
+		for _, validation := range validations {
+			if (validation.Filename == filename) || strings.HasPrefix(validation.Filename, filename+"/") {
+				entry.Rows, entry.Accepted, entry.Rejected = entry.Rows+validation.Rows, entry.Accepted+validation.Accepted, entry.Rejected+validation.Rejected
+			}
+		}
+		manifest.Files = append(manifest.Files, entry)
+	}
+	return manifest, nil
+}
+
+// Given a published manifest and one built from the files given, describe each file that differs from the published one
+func compareChecksumManifests(expected checksumManifest, actual checksumManifest) []string {
+	given := make(map[string]checksumEntry, len(actual.Files))
+	for _, entry := range actual.Files {
+		given[entry.File] = entry
+	}
+	differences := make([]string, 0)
+	for _, want := range expected.Files {
+		got, ok := given[want.File]
+		switch {
+		case !ok:
+			differences = append(differences, fmt.Sprintf("%s: listed in the manifest but not given", want.File))
+		case got.SHA256 != want.SHA256:
+			differences = append(differences, fmt.Sprintf("%s: sha256 %s, but the manifest has %s", want.File, got.SHA256, want.SHA256))
+		case (got.Rows != want.Rows) || (got.Accepted != want.Accepted) || (got.Rejected != want.Rejected):
+			// The same bytes read differently, as by a release that validates rows differently
+			differences = append(differences, fmt.Sprintf("%s: %d rows, %d accepted, %d rejected, but the manifest has %d, %d and %d", want.File, got.Rows, got.Accepted, got.Rejected, want.Rows, want.Accepted, want.Rejected))
+		}
+	}
+	return differences
+}
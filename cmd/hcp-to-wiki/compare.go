@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/AntonioCarlini/home-computer-prices/hcp"
+)
+
+// Implements "compare-aggregations [-a min] [-b median] [-threshold 10] [-o report.csv] [-out-dir dir] [-config rules.json] [table options] data.csv ...".
+// Builds the price tables twice, choosing each quarter's price with aggregation -a and then with -b, and outputs as CSV
+// every cell whose two prices differ by more than -threshold percent of the -a price, to help decide which aggregation
+// to publish. The table options (-interpolate, -carry-forward, -min-sources, -sources-by and -price-rounding) are
+// those of a generation run and apply to both tables. With -out-dir, both wiki tables are also written there, as
+// home-computer-prices-min.wiki and so on, to compare them side by side. A summary is given with the diagnostics.
+func runCompareAggregations(args []string) {
+	flags := flag.NewFlagSet("compare-aggregations", flag.ExitOnError)
+	first := flags.String("a", "min", "the first aggregation: min, mode or median")
+	second := flags.String("b", "median", "the second aggregation: min, mode or median")
+	threshold := flags.Float64("threshold", 10, "report cells whose prices differ by more than this percentage of the first price")
+	outputFilename := flags.String("o", "", "write the report to this file instead of standard output")
+	outputDir := flags.String("out-dir", "", "also write the wiki tables built with each aggregation to this directory")
+	configFilename := flags.String("config", "", "JSON file of rules applied to the data (default: the built-in rules)")
+	interpolate := flags.Bool("interpolate", false, "as for a generation run")
+	carry := flags.Bool("carry-forward", false, "as for a generation run")
+	rounding := flags.String("price-rounding", "trunc", "as for a generation run")
+	minSources := flags.Int("min-sources", 0, "as for a generation run")
+	sourcesBy := flags.String("sources-by", "issue", "as for a generation run")
+	flags.Parse(args)
+	if flags.NArg() < 1 {
+		log.Fatalf("At least 1 argument required but %d supplied\n", flags.NArg())
+	}
+	for _, aggregation := range []string{*first, *second} {
+		if _, ok := hcp.PriceAggregations[aggregation]; !ok {
+			log.Fatalf("Unknown aggregation '%s'\n", aggregation)
+		}
+	}
+	if *first == *second {
+		log.Fatalf("-a and -b are both '%s'\n", *first)
+	}
+	if _, ok := priceRoundings[*rounding]; !ok {
+		log.Fatalf("Unknown price rounding '%s'\n", *rounding)
+	}
+	if !sliceContainsString(hcp.SourceCountings, *sourcesBy) {
+		log.Fatalf("Unknown source counting '%s'\n", *sourcesBy)
+	}
+
+	config, err := hcp.LoadConfiguration(*configFilename)
+	if err != nil {
+		log.Fatalf("Cannot load configuration: %s\n", err.Error())
+	}
+	options := tableOptions{*interpolate, *carry, *rounding, *minSources, *sourcesBy, false, "", false}
+	tables := make([]priceTable, 0, 2)
+	for i, aggregation := range []string{*first, *second} {
+		dataset, err := hcp.LoadFiles(flags.Args(), hcp.Options{Config: &config, Aggregation: aggregation})
+		if err != nil {
+			log.Fatalf("Cannot load adverts: %s\n", err.Error())
+		}
+		if i == 0 {
+			printRowProblems(dataset.Validations)
+		}
+		table := newPriceTable(dataset, options)
+		table.attribution, table.lifespans, table.placeholders = config.Attribution, config.Lifespans, config.Placeholders
+		tables = append(tables, table)
+	}
+
+	if *outputDir != "" {
+		if err := os.MkdirAll(*outputDir, 0755); err != nil {
+			log.Fatalf("Cannot create '%s': %s\n", *outputDir, err.Error())
+		}
+		for i, aggregation := range []string{*first, *second} {
+			writeOutput(filepath.Join(*outputDir, out_dir_basename+"-"+aggregation+".wiki"), func(w io.Writer) {
+				outputWikidata(w, tables[i])
+			})
+		}
+	}
+
+	a, b := tables[0], tables[1]
+	cells, differing := 0, 0
+	writeOutput(*outputFilename, func(w io.Writer) {
+		out := csv.NewWriter(w)
+		out.Write([]string{"System", "Quarter", *first, *second, "Difference %"})
+		for _, key := range a.keys {
+			for index := a.minDate; index <= a.maxDate; index++ {
+				priceA, priceB := a.systems[key][index-a.minDate], b.systems[key][index-b.minDate]
+				if (priceA <= 0) || (priceB <= 0) {
+					continue
+				}
+				cells++
+				difference := float64(priceB-priceA) * 100 / float64(priceA)
+				if (difference <= *threshold) && (difference >= -*threshold) {
+					continue
+				}
+				differing++
+				out.Write([]string{key, hcp.FormatQuarter(index), formatPrice(priceA, *rounding), formatPrice(priceB, *rounding), fmt.Sprintf("%.1f", difference)})
+			}
+		}
+		out.Flush()
+		if err := out.Error(); err != nil {
+			log.Fatalln("Cannot write CSV data:", err.Error())
+		}
+	})
+	logf("%d of %d priced cells differ by more than %g%% between %s and %s\n", differing, cells, *threshold, *first, *second)
+}
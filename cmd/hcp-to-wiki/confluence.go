@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"io"
+
+	"github.com/AntonioCarlini/home-computer-prices/hcp"
+)
+
+// The confluence output holds the same tables as the wiki output, in the storage format of Atlassian Confluence,
+// the XHTML that its pages are kept in, to be pasted into the source editor of a page or uploaded through its REST
+// API. The storage format has no stylesheet, so prices are marked inline, as the wiki tables mark them: interpolated
+// prices in italics and carried prices in grey, while the quarters a system was not on sale are highlighted grey, as
+// Confluence highlights a cell. Confluence keeps no comments, so the metadata stamp is shown as small print.
+
+// The colour of carried prices, as the wiki tables show them
+const confluence_carried_style = "color: rgb(128,128,128);"
+
+// Given advert data for a range of systems, outputs that data in Confluence storage format
+func outputConfluence(w io.Writer, table priceTable) {
+	groups := table.groupYears()
+	for i, group := range groups {
+		fmt.Fprintf(w, "<h2>%d - %d</h2>\n", group.first, group.last)
+		outputConfluenceGroup(w, table, group)
+		progress.update("Rendering", i+1, len(groups), "tables")
+	}
+	if !table.attribution.IsEmpty() {
+		escaped := hcp.Attribution{
+			Licence:    html.EscapeString(table.attribution.Licence),
+			LicenceURL: html.EscapeString(table.attribution.LicenceURL),
+			Repository: html.EscapeString(table.attribution.Repository),
+		}
+		for _, contributor := range table.attribution.Contributors {
+			escaped.Contributors = append(escaped.Contributors, html.EscapeString(contributor))
+		}
+		text := attributionSentences(escaped, func(url string, text string) string {
+			return "<a href=\"" + url + "\">" + text + "</a>"
+		})
+		fmt.Fprintf(w, "<hr />\n<p><small>%s</small></p>\n", text)
+	}
+	if table.stamp != "" {
+		fmt.Fprintf(w, "<p><small>%s</small></p>\n", html.EscapeString(table.stamp))
+	}
+}
+
+// Outputs the Confluence table, and the notes explaining it, for a group of years
+func outputConfluenceGroup(w io.Writer, table priceTable, group yearGroup) {
+	first, last := table.groupQuarters(group)
+	fmt.Fprintf(w, "<table><tbody>\n<tr><th></th>")
+	for year := group.first; year <= group.last; year++ {
+		shown := 0
+		for quarter := 1; quarter <= 4; quarter++ {
+			if index := hcp.BuildIndexFromYearAndQuarter(year, quarter); (index >= first) && (index <= last) {
+				shown++
+			}
+		}
+		if shown > 0 {
+			fmt.Fprintf(w, "<th colspan=\"%d\">%d</th>", shown, year)
+		}
+	}
+	fmt.Fprintf(w, "</tr>\n<tr><th>%s</th>", html.EscapeString(table.systemHeading()))
+	for index := first; index <= last; index++ {
+		_, quarter := hcp.DecodeIndexByQuarter(index)
+		fmt.Fprintf(w, "<th>%s</th>", html.EscapeString(table.quarterHeadings()[quarter-1]))
+	}
+	fmt.Fprintf(w, "</tr>\n")
+
+	for _, key := range table.groupKeys(group) {
+		fmt.Fprintf(w, "<tr><th>%s</th>", html.EscapeString(key))
+		for index := first; index <= last; index++ {
+			fmt.Fprintf(w, "%s", table.confluenceCell(key, index))
+		}
+		fmt.Fprintf(w, "</tr>\n")
+	}
+	if table.totals {
+		fmt.Fprintf(w, "<tr><th>%s</th>", html.EscapeString(table.note("systems-priced", "Systems priced")))
+		for index := first; index <= last; index++ {
+			priced, _ := table.quarterSummary(index)
+			fmt.Fprintf(w, "<td>%d</td>", priced)
+		}
+		fmt.Fprintf(w, "</tr>\n<tr><th>%s</th>", html.EscapeString(table.note("median-price", "Median price")))
+		for index := first; index <= last; index++ {
+			if priced, median := table.quarterSummary(index); priced > 0 {
+				fmt.Fprintf(w, "<td>%s</td>", html.EscapeString(table.wikiPrice(median)))
+			} else {
+				fmt.Fprintf(w, "<td>—</td>")
+			}
+		}
+		fmt.Fprintf(w, "</tr>\n")
+	}
+	fmt.Fprintf(w, "</tbody></table>\n")
+	for _, note := range table.legend() {
+		fmt.Fprintf(w, "<p>%s</p>\n", html.EscapeString(note))
+	}
+}
+
+// Return the Confluence cell for a system's price at a date-index
+func (table priceTable) confluenceCell(key string, index int) string {
+	start := "<td>"
+	if table.offSale(key, index) {
+		start = "<td class=\"highlight-grey\" data-highlight-colour=\"grey\">"
+	}
+	switch {
+	case (index >= table.minDate) && (index <= table.maxDate) && (table.kind(key, index) == withheldPrice):
+		return start + withheld_marker + "</td>"
+	case (index < table.minDate) || (index > table.maxDate) || (table.systems[key][index-table.minDate] <= 0):
+		if placeholder, ok := table.placeholder(key, index); ok {
+			return start + html.EscapeString(placeholder) + "</td>"
+		}
+		return start + "—</td>"
+	}
+	price := html.EscapeString(table.markedCellText(key, index, table.wikiPrice))
+	switch table.kind(key, index) {
+	case interpolatedPrice:
+		price = "<em>" + price + "</em>"
+	case carriedPrice:
+		price = "<span style=\"" + confluence_carried_style + "\">" + price + "</span>"
+	}
+	return start + price + "</td>"
+}
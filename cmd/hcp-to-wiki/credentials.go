@@ -0,0 +1,143 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The credentials used to edit the wiki, so that passwords need not appear on the command line.
+// They are read from a JSON file named with -credentials, then any environment variable that is set overrides the file.
+// Either a bot password (Special:BotPasswords) or an owner-only OAuth 1.0a consumer (Special:OAuthConsumerRegistration)
+// may be used; if the OAuth keys are present they take precedence.
+//
+// Example:
+//
+//	{
+//	  "user":     "Example@hcp-to-wiki",
+//	  "password": "..."
+//	}
+//
+// or
+//
+//	{
+//	  "consumer_key":    "...",
+//	  "consumer_secret": "...",
+//	  "access_token":    "...",
+//	  "access_secret":   "..."
+//	}
+type wikiCredentials struct {
+	User           string `json:"user"`            // HCP_WIKI_USER: bot password user name, such as "Example@hcp-to-wiki"
+	Password       string `json:"password"`        // HCP_WIKI_PASSWORD
+	ConsumerKey    string `json:"consumer_key"`    // HCP_WIKI_CONSUMER_KEY
+	ConsumerSecret string `json:"consumer_secret"` // HCP_WIKI_CONSUMER_SECRET
+	AccessToken    string `json:"access_token"`    // HCP_WIKI_ACCESS_TOKEN
+	AccessSecret   string `json:"access_secret"`   // HCP_WIKI_ACCESS_SECRET
+}
+
+// Load the credentials from a file, if one is named, and the environment.
+// A credentials file that other users may read draws a warning.
+func loadCredentials(filename string) (wikiCredentials, error) {
+	var credentials wikiCredentials
+	if filename != "" {
+		info, err := os.Stat(filename)
+		if err != nil {
+			return credentials, err
+		}
+		if info.Mode().Perm()&0077 != 0 {
+			logf("Warning: credentials file '%s' can be read by other users\n", filename)
+		}
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			return credentials, err
+		}
+		if err := json.Unmarshal(data, &credentials); err != nil {
+			return credentials, fmt.Errorf("bad credentials file [%s] (%w)", filename, err)
+		}
+	}
+
+	for variable, field := range map[string]*string{
+		"HCP_WIKI_USER":            &credentials.User,
+		"HCP_WIKI_PASSWORD":        &credentials.Password,
+		"HCP_WIKI_CONSUMER_KEY":    &credentials.ConsumerKey,
+		"HCP_WIKI_CONSUMER_SECRET": &credentials.ConsumerSecret,
+		"HCP_WIKI_ACCESS_TOKEN":    &credentials.AccessToken,
+		"HCP_WIKI_ACCESS_SECRET":   &credentials.AccessSecret,
+	} {
+		if value, ok := os.LookupEnv(variable); ok {
+			*field = value
+		}
+	}
+
+	if credentials.usesOAuth() {
+		if (credentials.ConsumerSecret == "") || (credentials.AccessToken == "") || (credentials.AccessSecret == "") {
+			return credentials, fmt.Errorf("OAuth needs a consumer key, consumer secret, access token and access secret")
+		}
+	} else if (credentials.User != "") && (credentials.Password == "") {
+		return credentials, fmt.Errorf("no password for [%s]", credentials.User)
+	}
+	return credentials, nil
+}
+
+// Return true if the credentials are for OAuth rather than a bot password
+func (credentials wikiCredentials) usesOAuth() bool {
+	return credentials.ConsumerKey != ""
+}
+
+// Return the OAuth 1.0a Authorization header for a request, signed with HMAC-SHA1.
+// params are the request's query or form parameters, which are covered by the signature.
+func (credentials wikiCredentials) oauthHeader(method string, endpoint string, params url.Values) string {
+	nonce := make([]byte, 16)
+	rand.Read(nonce)
+	return credentials.signedOAuthHeader(method, endpoint, params, hex.EncodeToString(nonce), strconv.FormatInt(time.Now().Unix(), 10))
+}
+
+// Return the OAuth Authorization header for a request, as oauthHeader does, with the given nonce and timestamp.
+// The optional oauth_version is left out, as RFC 5849 allows.
+func (credentials wikiCredentials) signedOAuthHeader(method string, endpoint string, params url.Values, nonce string, timestamp string) string {
+	oauth := map[string]string{
+		"oauth_consumer_key":     credentials.ConsumerKey,
+		"oauth_token":            credentials.AccessToken,
+		"oauth_signature_method": "HMAC-SHA1",
+		"oauth_timestamp":        timestamp,
+		"oauth_nonce":            nonce,
+	}
+
+	// The signature base string is built from every parameter, encoded then sorted
+	pairs := make([]string, 0, len(params)+len(oauth))
+	for name, values := range params {
+		for _, value := range values {
+			pairs = append(pairs, oauthEscape(name)+"="+oauthEscape(value))
+		}
+	}
+	for name, value := range oauth {
+		pairs = append(pairs, oauthEscape(name)+"="+oauthEscape(value))
+	}
+	sort.Strings(pairs)
+	base := method + "&" + oauthEscape(endpoint) + "&" + oauthEscape(strings.Join(pairs, "&"))
+	mac := hmac.New(sha1.New, []byte(oauthEscape(credentials.ConsumerSecret)+"&"+oauthEscape(credentials.AccessSecret)))
+	mac.Write([]byte(base))
+	oauth["oauth_signature"] = base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	fields := make([]string, 0, len(oauth))
+	for name, value := range oauth {
+		fields = append(fields, fmt.Sprintf("%s=\"%s\"", name, oauthEscape(value)))
+	}
+	sort.Strings(fields)
+	return "OAuth " + strings.Join(fields, ", ")
+}
+
+// Percent-encode a string as OAuth requires (RFC 3986), which differs from URL query encoding only in how spaces are written
+func oauthEscape(text string) string {
+	return strings.ReplaceAll(url.QueryEscape(text), "+", "%20")
+}
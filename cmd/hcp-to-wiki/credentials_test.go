@@ -0,0 +1,48 @@
+package main
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestSignedOAuthHeader(t *testing.T) {
+	// The example request of RFC 5849, section 1.2
+	credentials := wikiCredentials{ConsumerKey: "dpf43f3p2l4k3l03", ConsumerSecret: "kd94hf93k423kf44", AccessToken: "nnch734d00sl2jdk", AccessSecret: "pfkkdhi9sl3r4s00"}
+	rfc := `OAuth oauth_consumer_key="dpf43f3p2l4k3l03", oauth_nonce="chapoH", oauth_signature="MdpQcU8iPSUjWoN%2FUDMsK2sui9I%3D", ` +
+		`oauth_signature_method="HMAC-SHA1", oauth_timestamp="137131202", oauth_token="nnch734d00sl2jdk"`
+	tests := []struct {
+		name     string
+		method   string
+		endpoint string
+		params   url.Values
+		want     string
+	}{
+		{"RFC 5849 example", "GET", "http://photos.example.net/photos", url.Values{"file": {"vacation.jpg"}, "size": {"original"}}, rfc},
+		{"parameters in any order", "GET", "http://photos.example.net/photos", url.Values{"size": {"original"}, "file": {"vacation.jpg"}}, rfc},
+	}
+	for _, test := range tests {
+		if got := credentials.signedOAuthHeader(test.method, test.endpoint, test.params, "chapoH", "137131202"); got != test.want {
+			t.Errorf("%s: signedOAuthHeader() = %s; want %s", test.name, got, test.want)
+		}
+	}
+	if other := credentials.signedOAuthHeader("POST", "http://photos.example.net/photos", url.Values{"file": {"vacation.jpg"}, "size": {"original"}}, "chapoH", "137131202"); other == rfc {
+		t.Errorf("signedOAuthHeader() signs a POST as it does a GET")
+	}
+}
+
+func TestOAuthEscape(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"abc-._~123", "abc-._~123"},
+		{"a b", "a%20b"},
+		{"a+b/c=d&e", "a%2Bb%2Fc%3Dd%26e"},
+		{"£", "%C2%A3"},
+	}
+	for _, test := range tests {
+		if got := oauthEscape(test.text); got != test.want {
+			t.Errorf("oauthEscape(%q) = %q; want %q", test.text, got, test.want)
+		}
+	}
+}
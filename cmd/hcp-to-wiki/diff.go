@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// The number of unchanged lines shown either side of each change in a diff
+const diff_context = 3
+
+// One line of a diff: kind is ' ' for a line in both texts, '-' for a line only in the old text and '+' for a line only in the new text
+type diffLine struct {
+	kind byte
+	text string
+}
+
+// Write the differences between two texts as a unified diff, labelling them fromName and toName.
+// Nothing is written if the texts are the same.
+func writeDiff(w io.Writer, fromName string, toName string, from string, to string) {
+	lines := diffLines(splitLines(from), splitLines(to))
+
+	// Find the lines worth showing: each change and the context around it
+	show := make([]bool, len(lines))
+	changed := false
+	for i, line := range lines {
+		if line.kind == ' ' {
+			continue
+		}
+		changed = true
+		for j := max(0, i-diff_context); j <= min(len(lines)-1, i+diff_context); j++ {
+			show[j] = true
+		}
+	}
+	if !changed {
+		return
+	}
+
+	fmt.Fprintf(w, "--- %s\n+++ %s\n", fromName, toName)
+	fromLine, toLine := 1, 1
+	for i := 0; i < len(lines); {
+		if !show[i] {
+			if lines[i].kind != '+' {
+				fromLine++
+			}
+			if lines[i].kind != '-' {
+				toLine++
+			}
+			i++
+			continue
+		}
+		// Output a hunk made of this run of lines to be shown
+		end := i
+		fromCount, toCount := 0, 0
+		for ; (end < len(lines)) && show[end]; end++ {
+			if lines[end].kind != '+' {
+				fromCount++
+			}
+			if lines[end].kind != '-' {
+				toCount++
+			}
+		}
+		// As in diff -u, an empty side of a hunk is numbered by the line before it
+		fromStart, toStart := fromLine, toLine
+		if fromCount == 0 {
+			fromStart--
+		}
+		if toCount == 0 {
+			toStart--
+		}
+		fmt.Fprintf(w, "@@ -%d,%d +%d,%d @@\n", fromStart, fromCount, toStart, toCount)
+		for ; i < end; i++ {
+			fmt.Fprintf(w, "%c%s\n", lines[i].kind, lines[i].text)
+		}
+		fromLine += fromCount
+		toLine += toCount
+	}
+}
+
+// Split a text into lines, ignoring any final newline
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// Given the lines of two texts, return the lines of a diff that turns the first into the second.
+// Lines common to the start and end of both are matched directly; the rest is matched by longest common subsequence.
+func diffLines(a []string, b []string) []diffLine {
+	prefix := 0
+	for (prefix < len(a)) && (prefix < len(b)) && (a[prefix] == b[prefix]) {
+		prefix++
+	}
+	suffix := 0
+	for (suffix < len(a)-prefix) && (suffix < len(b)-prefix) && (a[len(a)-1-suffix] == b[len(b)-1-suffix]) {
+		suffix++
+	}
+
+	result := make([]diffLine, 0, len(a)+len(b))
+	for _, text := range a[:prefix] {
+		result = append(result, diffLine{' ', text})
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of middleA[i:] and middleB[j:]
+	middleA, middleB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	lcs := make([][]int, len(middleA)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(middleB)+1)
+	}
+	for i := len(middleA) - 1; i >= 0; i-- {
+		for j := len(middleB) - 1; j >= 0; j-- {
+			if middleA[i] == middleB[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	i, j := 0, 0
+	for (i < len(middleA)) || (j < len(middleB)) {
+		switch {
+		case (i < len(middleA)) && (j < len(middleB)) && (middleA[i] == middleB[j]):
+			result = append(result, diffLine{' ', middleA[i]})
+			i++
+			j++
+		case (j == len(middleB)) || ((i < len(middleA)) && (lcs[i+1][j] >= lcs[i][j+1])):
+			result = append(result, diffLine{'-', middleA[i]})
+			i++
+		default:
+			result = append(result, diffLine{'+', middleB[j]})
+			j++
+		}
+	}
+
+	for _, text := range a[len(a)-suffix:] {
+		result = append(result, diffLine{' ', text})
+	}
+	return result
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/AntonioCarlini/home-computer-prices/hcp"
+)
+
+// The dokuwiki output holds the same tables as the wiki output, in the syntax of DokuWiki, for wikis that run it
+// rather than MediaWiki. Each year spans its quarters in the first header row, as in the wiki tables, and prices are
+// aligned to the right. DokuWiki has no colours, so estimates are marked in the text, as in the markdown output:
+// interpolated prices are in italics and carried prices in parentheses. Quarters outside a system's lifespan are
+// not shaded.
+
+// Given advert data for a range of systems, outputs that data as DokuWiki tables
+func outputDokuWiki(w io.Writer, table priceTable) {
+	groups := table.groupYears()
+	for i, group := range groups {
+		fmt.Fprintf(w, "===== %d - %d =====\n\n", group.first, group.last)
+		outputDokuWikiGroup(w, table, group)
+		progress.update("Rendering", i+1, len(groups), "tables")
+	}
+	outputDokuWikiFooter(w, table)
+}
+
+// Outputs the DokuWiki table, and the notes explaining it, for a group of years
+func outputDokuWikiGroup(w io.Writer, table priceTable, group yearGroup) {
+	first, last := table.groupQuarters(group)
+
+	// The years, each spanning its quarters: an empty header cell joins the one before it
+	years := "^ "
+	for year := group.first; year <= group.last; year++ {
+		shown := 0
+		for quarter := 1; quarter <= 4; quarter++ {
+			if index := hcp.BuildIndexFromYearAndQuarter(year, quarter); (index >= first) && (index <= last) {
+				shown++
+			}
+		}
+		if shown > 0 {
+			years += fmt.Sprintf("^  %d  %s", year, strings.Repeat("^", shown-1))
+		}
+	}
+	fmt.Fprintf(w, "%s^\n", years)
+	headings := []string{dokuwikiText(table.systemHeading())}
+	for index := first; index <= last; index++ {
+		_, quarter := hcp.DecodeIndexByQuarter(index)
+		headings = append(headings, dokuwikiText(table.quarterHeadings()[quarter-1]))
+	}
+	fmt.Fprintf(w, "^ %s ^\n", strings.Join(headings, " ^ "))
+
+	for _, key := range table.groupKeys(group) {
+		cells := []string{dokuwikiText(key)}
+		for index := first; index <= last; index++ {
+			cells = append(cells, table.dokuwikiCell(key, index))
+		}
+		writeDokuWikiRow(w, cells)
+	}
+	if table.totals {
+		counts, medians := []string{"**" + dokuwikiText(table.note("systems-priced", "Systems priced")) + "**"}, []string{"**" + dokuwikiText(table.note("median-price", "Median price")) + "**"}
+		for index := first; index <= last; index++ {
+			priced, median := table.quarterSummary(index)
+			counts = append(counts, fmt.Sprintf(" %d", priced))
+			if priced == 0 {
+				medians = append(medians, " —")
+			} else {
+				medians = append(medians, " "+dokuwikiText(table.wikiPrice(median)))
+			}
+		}
+		writeDokuWikiRow(w, counts)
+		writeDokuWikiRow(w, medians)
+	}
+	fmt.Fprintln(w, "")
+	for _, note := range table.legendWith(parenthesised_carried_notes) {
+		fmt.Fprintf(w, "%s\n\n", dokuwikiText(note))
+	}
+}
+
+// Return the DokuWiki for one cell of a system's row; a price starts with a space, as the extra space before
+// the content aligns the cell to the right
+func (table priceTable) dokuwikiCell(key string, index int) string {
+	switch {
+	case (index >= table.minDate) && (index <= table.maxDate) && (table.kind(key, index) == withheldPrice):
+		return " " + withheld_marker
+	case (index < table.minDate) || (index > table.maxDate) || (table.systems[key][index-table.minDate] <= 0):
+		if placeholder, ok := table.placeholder(key, index); ok {
+			return " " + dokuwikiText(placeholder)
+		}
+		return " —"
+	}
+	price := dokuwikiText(table.markedCellText(key, index, table.wikiPrice))
+	switch table.kind(key, index) {
+	case interpolatedPrice:
+		return " //" + price + "//"
+	case carriedPrice:
+		return " (" + price + ")"
+	}
+	return " " + price
+}
+
+// Outputs one row of a DokuWiki table
+func writeDokuWikiRow(w io.Writer, cells []string) {
+	fmt.Fprintf(w, "| %s |\n", strings.Join(cells, " | "))
+}
+
+// The character sequences that DokuWiki reads as markup, or as the end of a table cell
+var dokuwiki_markup = []string{"|", "^", "**", "//", "__", "''", "[[", "{{", "<", "\\\\", "~~", "%%", "--", "==", "(("}
+
+// Return text that DokuWiki shows as it is: text holding any of its markup is wrapped in %%, which DokuWiki
+// does not format within
+func dokuwikiText(text string) string {
+	for _, markup := range dokuwiki_markup {
+		if strings.Contains(text, markup) {
+			return "%%" + strings.ReplaceAll(text, "%%", "% %") + "%%"
+		}
+	}
+	return text
+}
+
+// Outputs the footer of a DokuWiki page: the attribution, then the metadata stamp, which DokuWiki has no
+// comments to hide, in monospace
+func outputDokuWikiFooter(w io.Writer, table priceTable) {
+	if !table.attribution.IsEmpty() {
+		text := attributionSentences(table.attribution, func(url string, text string) string {
+			return "[[" + url + "|" + text + "]]"
+		})
+		fmt.Fprintf(w, "----\n\n%s\n\n", text)
+	}
+	if table.stamp != "" {
+		fmt.Fprintf(w, "''%s''\n", dokuwikiText(table.stamp))
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/AntonioCarlini/home-computer-prices/hcp"
+)
+
+// serve offers the data being served for download, generated on demand, so that the site doubles as the dataset's
+// distribution point: /download/dataset.csv holds the adverts, while /download/dataset.json and /download/dataset.sqlite
+// hold both the adverts and the prices published from them, the database also holding the validation results of the
+// files. Each advert's price is in pence, VAT included, and its page is empty (or null) where it could not be read.
+
+// The columns of the adverts in the CSV download and the SQLite adverts table
+var download_advert_columns = []string{"File", "Row", "Magazine", "Year", "Month", "Page", "System", "Pence", "Ex VAT", "Region", "Approximate Date", "Size"}
+
+// The indexes over the adverts in the SQLite download, for finding a system's adverts or an issue's
+var download_advert_indexes = []sqliteIndex{{"adverts_by_system", []int{6, 3, 4}}, {"adverts_by_issue", []int{2, 3, 4}}}
+
+// The indexes over the prices in the SQLite download, for finding a system's prices or a quarter's, as serve -sqlite does
+var download_price_indexes = []sqliteIndex{{"prices_by_system", []int{0, 1, 2}}, {"prices_by_quarter", []int{1, 2, 0}}}
+
+// The JSON form of an advert in the JSON download
+type downloadAdvert struct {
+	File            string `json:"file"`
+	Row             int    `json:"row"`
+	Magazine        string `json:"magazine"`
+	Year            int    `json:"year"`
+	Month           int    `json:"month"`
+	Page            *int   `json:"page"`
+	System          string `json:"system"` // As advertised, before the configuration renamed it
+	Pence           int    `json:"pence"`
+	ExVAT           bool   `json:"ex_vat"` // The advert was without VAT, which has been added
+	Region          string `json:"region,omitempty"`
+	ApproximateDate bool   `json:"approximate_date,omitempty"`
+	Size            string `json:"size,omitempty"` // One of hcp.AdvertSizes, if recorded
+}
+
+// The JSON form of a published price in the JSON download
+type downloadPrice struct {
+	System  string `json:"system"` // As published
+	Year    int    `json:"year"`
+	Quarter int    `json:"quarter"`
+	Pence   int    `json:"pence"`
+	Adverts int    `json:"adverts"`
+}
+
+// Return the fields of an advert, in the order of download_advert_columns, with nil for a page that could not be read
+func downloadAdvertFields(advert hcp.Advert) []interface{} {
+	var page interface{}
+	if advert.Page >= 0 {
+		page = advert.Page
+	}
+	return []interface{}{advert.File, advert.Row, advert.Magazine, advert.Year, advert.Month, page, advert.System, advert.Price, advert.ExVAT, advert.Region, advert.ApproximateDate, advert.Size}
+}
+
+// Return every published price of the dataset, by system then quarter
+func downloadPrices(dataset *hcp.Dataset) []downloadPrice {
+	prices := make([]downloadPrice, 0)
+	for _, system := range dataset.Systems() {
+		for _, price := range dataset.PricesFor(system) {
+			prices = append(prices, downloadPrice{system, price.Year, price.Quarter, price.Price, len(price.Adverts)})
+		}
+	}
+	return prices
+}
+
+// Mark a response as a file to be saved under the given name
+func setDownload(w http.ResponseWriter, contentType string, filename string) {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+}
+
+func handleDownloadCSV(w http.ResponseWriter, r *http.Request, snapshot *servedSnapshot) {
+	setDownload(w, "text/csv; charset=utf-8", "dataset.csv")
+	out := csv.NewWriter(w)
+	out.Write(download_advert_columns)
+	for _, advert := range snapshot.dataset.Adverts {
+		record := make([]string, 0, len(download_advert_columns))
+		for _, field := range downloadAdvertFields(advert) {
+			switch value := field.(type) {
+			case nil:
+				record = append(record, "")
+			case bool:
+				record = append(record, map[bool]string{false: "N", true: "Y"}[value])
+			case int:
+				record = append(record, strconv.Itoa(value))
+			default:
+				record = append(record, fmt.Sprint(value))
+			}
+		}
+		out.Write(record)
+	}
+	out.Flush()
+	if err := out.Error(); err != nil {
+		logf("Cannot write response: %s\n", err.Error())
+	}
+}
+
+func handleDownloadJSON(w http.ResponseWriter, r *http.Request, snapshot *servedSnapshot) {
+	dataset := snapshot.dataset
+	adverts := make([]downloadAdvert, 0, len(dataset.Adverts))
+	for _, advert := range dataset.Adverts {
+		var page *int
+		if advert.Page >= 0 {
+			page = &advert.Page
+		}
+		adverts = append(adverts, downloadAdvert{advert.File, advert.Row, advert.Magazine, advert.Year, advert.Month, page, advert.System, advert.Price, advert.ExVAT, advert.Region, advert.ApproximateDate, advert.Size})
+	}
+	setDownload(w, "application/json", "dataset.json")
+	writeJSON(w, map[string]interface{}{
+		"digest":  snapshot.digest,
+		"first":   hcp.FormatQuarter(dataset.MinDate),
+		"last":    hcp.FormatQuarter(dataset.MaxDate),
+		"adverts": adverts,
+		"prices":  downloadPrices(dataset),
+	})
+}
+
+// Write the dataset as a SQLite database, as served for download and written by -sqlite: the adverts, indexed by
+// system and by issue, the prices published from them, indexed by system and by quarter, and the validation
+// results of each file the adverts were read from
+func writeDatasetSQLite(w io.Writer, dataset *hcp.Dataset) error {
+	adverts := sqliteTable{name: "adverts", columns: []string{"file TEXT", "row INTEGER", "magazine TEXT", "year INTEGER", "month INTEGER", "page INTEGER", "system TEXT", "pence INTEGER", "ex_vat INTEGER", "region TEXT", "approximate_date INTEGER", "size TEXT"}, indexes: download_advert_indexes}
+	for _, advert := range dataset.Adverts {
+		adverts.rows = append(adverts.rows, downloadAdvertFields(advert))
+	}
+	prices := sqliteTable{name: "prices", columns: []string{"system TEXT", "year INTEGER", "quarter INTEGER", "pence INTEGER", "adverts INTEGER"}, indexes: download_price_indexes}
+	for _, price := range downloadPrices(dataset) {
+		prices.rows = append(prices.rows, []interface{}{price.System, price.Year, price.Quarter, price.Pence, price.Adverts})
+	}
+	files := sqliteTable{name: "files", columns: []string{"file TEXT", "rows INTEGER", "accepted INTEGER", "rejected INTEGER", "warnings INTEGER"}}
+	for _, validation := range dataset.Validations {
+		files.rows = append(files.rows, []interface{}{validation.Filename, validation.Rows, validation.Accepted, validation.Rejected, validation.Warnings})
+	}
+	return writeSQLite(w, []sqliteTable{adverts, prices, files})
+}
+
+func handleDownloadSQLite(w http.ResponseWriter, r *http.Request, snapshot *servedSnapshot) {
+	var database bytes.Buffer
+	if err := writeDatasetSQLite(&database, snapshot.dataset); err != nil {
+		http.Error(w, fmt.Sprintf("cannot build database: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+	setDownload(w, "application/vnd.sqlite3", "dataset.sqlite")
+	if _, err := database.WriteTo(w); err != nil {
+		logf("Cannot write response: %s\n", err.Error())
+	}
+}
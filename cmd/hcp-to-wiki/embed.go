@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+)
+
+// serve answers GET /embed/system/{name} with a small HTML page holding the chart and table of one system's prices,
+// as in the archive, for other sites to show in an iframe as a live price widget:
+//
+//	<iframe src="https://prices.example.org/embed/system/Acorn%20Atom" width="600" height="500"></iframe>
+//
+// The page loads nothing from elsewhere and may be framed by any site. It links back to the server's data for the system.
+
+// Styles for the embedded page, kept compact to suit a small frame
+const embed_style = `body { font-family: sans-serif; font-size: 0.9em; margin: 0.5em; }
+h1 { font-size: 1.2em; margin: 0 0 0.5em 0; }
+table { border-collapse: collapse; }
+td, th { border: 1px solid #ccc; padding: 0.1em 0.4em; }
+td.price { text-align: right; }
+.interpolated { font-style: italic; }
+.carried { color: grey; }
+.off-sale { background: #eee; }
+svg { max-width: 100%; height: auto; }`
+
+func handleEmbed(w http.ResponseWriter, r *http.Request, snapshot *servedSnapshot) {
+	table, name := snapshot.table, snapshot.dataset.Resolve(r.PathValue("name"))
+	if _, ok := table.systems[name]; !ok || !table.hasPrices(name) {
+		http.Error(w, fmt.Sprintf("no prices for [%s]; /api/search finds systems by part of their name", name), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", "frame-ancestors *")
+	fmt.Fprintf(w, "<!DOCTYPE html>\n<html lang=\"en\">\n<head>\n<meta charset=\"utf-8\">\n")
+	fmt.Fprintf(w, "<title>%s prices</title>\n<style>\n%s\n</style>\n</head>\n<body>\n", html.EscapeString(name), embed_style)
+	fmt.Fprintf(w, "<h1>%s</h1>\n", html.EscapeString(name))
+	writeSystemPrices(w, table, name)
+	fmt.Fprintf(w, "<p><small><a href=\"/api/system?name=%s\" target=\"_blank\">Data</a></small></p>\n", html.EscapeString(url.QueryEscape(name)))
+	outputHTMLAttribution(w, table)
+	fmt.Fprintf(w, "</body>\n</html>\n")
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/AntonioCarlini/home-computer-prices/hcp"
+)
+
+// How a published price was arrived at
+type priceKind int
+
+const (
+	observedPrice     priceKind = iota // Chosen from the adverts for that quarter
+	interpolatedPrice                  // Estimated from the quarters either side; shown in italics
+	carriedPrice                       // Repeated from the previous quarter with a price; shown in grey
+	withheldPrice                      // Seen in too few independent sources to be published; shown as withheld_marker
+)
+
+// What is shown in place of a withheld price
+const withheld_marker = "†"
+
+// What is shown after a price taken from a single advert, with -mark-single-source
+const single_advert_marker = "*"
+
+// What is shown before a price taken only from adverts whose issues are dated by year alone
+const approximate_date_marker = "~"
+
+// Return the name of a kind of price, as used in the data-only output formats
+func (kind priceKind) String() string {
+	switch kind {
+	case interpolatedPrice:
+		return "interpolated"
+	case carriedPrice:
+		return "carried"
+	case withheldPrice:
+		return "withheld"
+	default:
+		return "observed"
+	}
+}
+
+// Create a price-kind array for each system, marking every price as observed
+func newPriceKinds(systems map[string][]int) map[string][]priceKind {
+	kinds := make(map[string][]priceKind, len(systems))
+	for name, prices := range systems {
+		kinds[name] = make([]priceKind, len(prices))
+	}
+	return kinds
+}
+
+// Remove each price whose adverts come from fewer than minSources independent sources, marking it as withheld.
+// A withheld price is not estimated from its neighbours either, as that would publish it by the back door.
+func withholdThinPrices(systems map[string][]int, kinds map[string][]priceKind, observations map[string][][]hcp.Advert, minSources int, by string) {
+	for name, prices := range systems {
+		for i := range prices {
+			if prices[i] <= 0 {
+				continue
+			}
+			if sources, _ := hcp.CountSources(observations[name][i], by); sources < minSources {
+				prices[i] = 0
+				kinds[name][i] = withheldPrice
+			}
+		}
+	}
+}
+
+// Return true if the price for a system at a date-index is to be marked as taken from a single advert
+func (table priceTable) singleAdvert(system string, index int) bool {
+	if !table.markSingle || (table.kind(system, index) != observedPrice) {
+		return false
+	}
+	return len(table.observations[system][index-table.minDate]) == 1
+}
+
+// Return true if any price is marked as taken from a single advert
+func (table priceTable) hasSingleAdverts() bool {
+	for _, system := range table.keys {
+		for index := table.minDate; index <= table.maxDate; index++ {
+			if table.singleAdvert(system, index) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Return true if the price for a system at a date-index was taken only from adverts with approximate dates,
+// so that the quarter it is shown in was chosen by the configuration rather than known
+func (table priceTable) approximateDate(system string, index int) bool {
+	if (table.kind(system, index) != observedPrice) || (table.observations == nil) {
+		return false
+	}
+	adverts := table.observations[system][index-table.minDate]
+	for _, advert := range adverts {
+		if !advert.ApproximateDate {
+			return false
+		}
+	}
+	return len(adverts) > 0
+}
+
+// Return true if any price was taken only from adverts with approximate dates
+func (table priceTable) hasApproximateDates() bool {
+	for _, system := range table.keys {
+		for index := table.minDate; index <= table.maxDate; index++ {
+			if table.approximateDate(system, index) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Return true if any of a system's prices from startYear to endYear were withheld, so that its row is still shown
+func (table priceTable) hasWithheld(system string, startYear int, endYear int) bool {
+	first := max(hcp.BuildIndexFromYearAndQuarter(startYear, 1), table.minDate)
+	last := min(hcp.BuildIndexFromYearAndQuarter(endYear, 4), table.maxDate)
+	for index := first; index <= last; index++ {
+		if table.kind(system, index) == withheldPrice {
+			return true
+		}
+	}
+	return false
+}
+
+// Fill each gap of a single quarter between two observed prices with the mean of those prices, marked as interpolated.
+// Longer gaps are left alone: the further an estimate is from real adverts the less it can be trusted.
+func interpolateGaps(systems map[string][]int, kinds map[string][]priceKind) {
+	for name, prices := range systems {
+		for i := 1; i < len(prices)-1; i++ {
+			if (prices[i] <= 0) && (kinds[name][i] != withheldPrice) && (prices[i-1] > 0) && (prices[i+1] > 0) && (kinds[name][i-1] == observedPrice) && (kinds[name][i+1] == observedPrice) {
+				prices[i] = (prices[i-1] + prices[i+1]) / 2
+				kinds[name][i] = interpolatedPrice
+			}
+		}
+	}
+}
+
+// Fill each gap between a system's first and last observed prices with the price of the quarter before it, marked as carried.
+// Advertisers often skipped an issue, so while a system was still on sale its last known price is a fair guide.
+// Nothing is carried beyond the last observed price, as the system may no longer have been on sale.
+func carryForward(systems map[string][]int, kinds map[string][]priceKind) {
+	for name, prices := range systems {
+		last := -1
+		for i := range prices {
+			if (prices[i] > 0) && (kinds[name][i] == observedPrice) {
+				last = i
+			}
+		}
+		for i := 1; i < last; i++ {
+			if (prices[i] <= 0) && (kinds[name][i] != withheldPrice) && (prices[i-1] > 0) {
+				prices[i] = prices[i-1]
+				kinds[name][i] = carriedPrice
+			}
+		}
+	}
+}
+
+// Return how the price for a system at a date-index was arrived at
+func (table priceTable) kind(system string, index int) priceKind {
+	if table.kinds == nil {
+		return observedPrice
+	}
+	return table.kinds[system][index-table.minDate]
+}
+
+// Return the notes that explain the marked prices in the table.
+// Only notes for kinds of price that actually appear are returned.
+func (table priceTable) legend() []string {
+	return table.legendWith(nil)
+}
+
+// The notes that differ from the wiki tables', for the formats that have no colours, so mark carried prices in
+// parentheses, and do not shade the quarters outside a system's lifespan: markdown, latex and dokuwiki
+var parenthesised_carried_notes = map[string]string{
+	carriedPrice.String(): "Prices in parentheses are carried forward from the previous quarter, as no advert was found.",
+	"off-sale":            "",
+}
+
+// Return the notes that explain the marked prices in the table, as legend does, for a format that marks some
+// prices differently: overrides replaces the English text of the notes it names (see note), and an override
+// of "" leaves the note out, for marks that the format does not show.
+func (table priceTable) legendWith(overrides map[string]string) []string {
+	if overrides != nil {
+		table.noteOverrides = overrides
+	}
+	present := make(map[priceKind]bool)
+	for _, kinds := range table.kinds {
+		for _, kind := range kinds {
+			present[kind] = true
+		}
+	}
+	notes := make([]string, 0)
+	if present[interpolatedPrice] {
+		notes = append(notes, table.note(interpolatedPrice.String(), "Prices in italics are estimates, interpolated from the quarters either side."))
+	}
+	if present[carriedPrice] {
+		notes = append(notes, table.note(carriedPrice.String(), "Prices in grey are carried forward from the previous quarter, as no advert was found."))
+	}
+	if table.hasSingleAdverts() {
+		notes = append(notes, table.note("single", fmt.Sprintf("%s marks a price taken from a single advert.", single_advert_marker)))
+	}
+	if table.hasApproximateDates() {
+		notes = append(notes, table.note("approximate", fmt.Sprintf("%s marks a price from adverts in issues known only by their year, so the quarter is not certain.", approximate_date_marker)))
+	}
+	if table.hasCurrencies() {
+		notes = append(notes, table.note("currency", "A price followed by currency symbols, such as [$], was converted into pounds from adverts quoted in those currencies."))
+	}
+	if table.showsMedians() {
+		notes = append(notes, table.note("min-median", "Where a quarter shows two prices, the first is the lowest advertised price and the second the median of all its adverts."))
+	}
+	if present[withheldPrice] {
+		notes = append(notes, table.note(withheldPrice.String(), fmt.Sprintf("%s marks a price seen in fewer than %d independent sources, which is not shown.", withheld_marker, table.minSources)))
+	}
+	if table.hasOffSale() {
+		notes = append(notes, table.note("off-sale", "Shaded quarters are before the system was launched or after it was discontinued."))
+	}
+	notes = append(notes, table.placeholderNotes()...)
+	kept := make([]string, 0, len(notes))
+	for _, note := range notes {
+		if note != "" {
+			kept = append(kept, note)
+		}
+	}
+	return kept
+}
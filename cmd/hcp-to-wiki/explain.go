@@ -0,0 +1,221 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/AntonioCarlini/home-computer-prices/hcp"
+)
+
+// Implements "explain -system NAME -quarter 1982Q2 [-config rules.json] [-aggregate min] [-issue "PCW 1982-06"] [table options] data.csv ...".
+// Prints every candidate advert for one cell of the tables, which of them was chosen and why,
+// for when a published value looks wrong. The table options (-interpolate, -carry-forward, -min-sources,
+// -sources-by, -price-rounding and -no-preprocess) are those of a generation run, so that the cell is explained as it was published.
+// Rows for the system and quarter that were rejected by validation are listed too, as they are often the missing price.
+// -issue considers only the adverts from that issue, to check what a newly transcribed issue contributes to the cell.
+// The flags may also follow the CSV files, as in "explain data.csv -system ZX81 -quarter 1982Q2".
+func runExplain(args []string) {
+	flags := flag.NewFlagSet("explain", flag.ExitOnError)
+	system := flags.String("system", "", "the system, by the name it is published under or one of its aliases")
+	quarterText := flags.String("quarter", "", "the quarter, such as 1982Q2")
+	configFilename := flags.String("config", "", "JSON file of rules applied to the data (default: the built-in rules)")
+	aggregation := flags.String("aggregate", "min", "how each quarter's price is chosen from its adverts: min, mode or median")
+	interpolate := flags.Bool("interpolate", false, "as for a generation run")
+	carry := flags.Bool("carry-forward", false, "as for a generation run")
+	rounding := flags.String("price-rounding", "trunc", "as for a generation run")
+	minSources := flags.Int("min-sources", 0, "as for a generation run")
+	sourcesBy := flags.String("sources-by", "issue", "as for a generation run")
+	noPreprocess := flags.Bool("no-preprocess", false, "as for a generation run")
+	newIssue := addIssueFlag(flags, "consider only the rows for this issue, such as \"PCW 1982-06\"")
+	inputs := parseInterspersed(flags, args)
+	issueFilter = newIssue()
+
+	if len(inputs) < 1 {
+		log.Fatalf("At least 1 argument required but %d supplied\n", len(inputs))
+	}
+	if *system == "" {
+		log.Fatalf("-system is needed\n")
+	}
+	index, err := hcp.ParseQuarter(*quarterText)
+	if err != nil {
+		log.Fatalf("Cannot explain: %s\n", err.Error())
+	}
+	if _, ok := hcp.PriceAggregations[*aggregation]; !ok {
+		log.Fatalf("Unknown aggregation '%s'\n", *aggregation)
+	}
+	if _, ok := priceRoundings[*rounding]; !ok {
+		log.Fatalf("Unknown price rounding '%s'\n", *rounding)
+	}
+	if !sliceContainsString(hcp.SourceCountings, *sourcesBy) {
+		log.Fatalf("Unknown source counting '%s'\n", *sourcesBy)
+	}
+
+	config, err := hcp.LoadConfiguration(*configFilename)
+	if err != nil {
+		log.Fatalf("Cannot load configuration: %s\n", err.Error())
+	}
+	if *noPreprocess {
+		config = config.WithoutPreprocessing()
+	}
+	dataset, err := hcp.LoadFiles(inputs, hcp.Options{Config: &config, Aggregation: *aggregation, Issue: issueFilter})
+	if err != nil {
+		log.Fatalf("Cannot load adverts: %s\n", err.Error())
+	}
+	checkIssueRows(dataset.Validations)
+	table := newPriceTable(dataset, tableOptions{*interpolate, *carry, *rounding, *minSources, *sourcesBy, false, "", false})
+
+	fmt.Printf("%s, %s\n", *system, hcp.FormatQuarter(index))
+	name := *system
+	if published := config.ResolveName(name); (published != name) && !sliceContainsString(dataset.Dropped, name) {
+		// An alias is explained as the system it is published as
+		fmt.Printf("  Published as %s: the configuration renames it\n", published)
+		name = published
+	}
+	if sliceContainsString(dataset.Dropped, name) {
+		fmt.Printf("  Not published: the configuration suppresses this system\n")
+	} else if _, ok := table.systems[name]; !ok {
+		fmt.Printf("  Not published: there are no adverts for this system\n")
+	} else if (index < table.minDate) || (index > table.maxDate) {
+		fmt.Printf("  Not published: the data runs from %s to %s\n", hcp.FormatQuarter(table.minDate), hcp.FormatQuarter(table.maxDate))
+	} else {
+		explainCell(table, name, index, *aggregation, *sourcesBy, config)
+	}
+
+	rejected := rejectedRows(dataset.Validations, config, name, index)
+	if len(rejected) > 0 {
+		fmt.Printf("  Rows rejected by validation:\n")
+		for _, problem := range rejected {
+			fmt.Printf("    %s\n", problem)
+		}
+	}
+}
+
+// Print how the price in one cell of the table was arrived at: the candidate adverts, the one chosen and the rule that chose it
+func explainCell(table priceTable, system string, index int, aggregation string, sourcesBy string, config hcp.Configuration) {
+	pence := table.systems[system][index-table.minDate]
+	kind := table.kind(system, index)
+	adverts := append([]hcp.Advert(nil), table.observations[system][index-table.minDate]...)
+	sort.SliceStable(adverts, func(i, j int) bool { return adverts[i].Price < adverts[j].Price })
+
+	switch {
+	case kind == withheldPrice:
+		sources, _ := hcp.CountSources(adverts, sourcesBy)
+		fmt.Printf("  Withheld: seen in %d independent source(s) (counted by %s), fewer than -min-sources %d\n", sources, sourcesBy, table.minSources)
+	case kind != observedPrice:
+		fmt.Printf("  Published: £%s (%s)\n", formatPrice(pence, table.rounding), kind)
+	case pence > 0:
+		fmt.Printf("  Published: £%s (£%s before -price-rounding %s)\n", formatPrice(pence, table.rounding), formatPrice(pence, "exact"), table.rounding)
+	default:
+		fmt.Printf("  Not published: no adverts in this quarter\n")
+	}
+	switch kind {
+	case interpolatedPrice:
+		fmt.Printf("  No adverts in this quarter; estimated as the mean of the prices in %s and %s\n", hcp.FormatQuarter(index-1), hcp.FormatQuarter(index+1))
+	case carriedPrice:
+		fmt.Printf("  No adverts in this quarter; carried forward from %s\n", hcp.FormatQuarter(index-1))
+	}
+	if len(adverts) == 0 {
+		return
+	}
+
+	prices := hcp.WeightedPrices(adverts, config)
+	chosen := hcp.PriceAggregations[aggregation](prices)
+	fmt.Printf("  Rule: -aggregate %s took %s\n", aggregation, aggregationReason(aggregation, prices, chosen))
+	fmt.Printf("  Candidates:\n")
+	for _, advert := range adverts {
+		marker := " "
+		if advert.Price == chosen {
+			marker = "*"
+		}
+		details := ""
+		if advert.System != system {
+			details += fmt.Sprintf(", as %s", advert.System)
+		}
+		if advert.ExVAT {
+			details += ", VAT added"
+		}
+		if advert.ApproximateDate {
+			details += ", dated by year only"
+		}
+		if advert.Region != "" {
+			details += ", region " + advert.Region
+		}
+		if advert.Size != "" {
+			details += ", " + advert.Size
+		}
+		if weight := hcp.AdvertWeight(advert, config); weight != 1 {
+			details += fmt.Sprintf(", counted %d times", weight)
+		}
+		fmt.Printf("  %s £%s  %s %04d-%02d p%d (%s line %d%s)\n", marker, formatPrice(advert.Price, "exact"), advert.Magazine, advert.Year, advert.Month, advert.Page, advert.File, advert.Row, details)
+	}
+}
+
+// Given an aggregation, the prices it chose from and the price it chose, describe why that price was chosen
+func aggregationReason(aggregation string, prices []int, chosen int) string {
+	price := "£" + formatPrice(chosen, "exact")
+	switch aggregation {
+	case "mode":
+		counts := make(map[int]int)
+		for _, p := range prices {
+			counts[p]++
+		}
+		tied := 0
+		for _, count := range counts {
+			if count == counts[chosen] {
+				tied++
+			}
+		}
+		if tied > 1 {
+			return fmt.Sprintf("%s, carried by %d of the %d adverts and the lowest of the %d prices that were equally common", price, counts[chosen], len(prices), tied)
+		}
+		return fmt.Sprintf("%s, carried by %d of the %d adverts, more than any other price", price, counts[chosen], len(prices))
+	case "median":
+		if len(prices)%2 == 0 {
+			return fmt.Sprintf("%s, the mean of the middle two of the %d prices, rounded down", price, len(prices))
+		}
+		return fmt.Sprintf("%s, the middle one of the %d prices", price, len(prices))
+	default:
+		return fmt.Sprintf("%s, the lowest of the %d prices", price, len(prices))
+	}
+}
+
+// Return the problems with the rows rejected by validation that are for the system (as published) in the quarter.
+// A row whose date could not be read cannot be placed in a quarter, so is not returned.
+func rejectedRows(validations []hcp.FileValidation, config hcp.Configuration, system string, index int) []string {
+	year, quarter := hcp.DecodeIndexByQuarter(index)
+	months := make([]string, 0, 3)
+	for month := quarter*3 - 2; month <= quarter*3; month++ {
+		months = append(months, fmt.Sprintf("%04d-%02d", year, month))
+	}
+	rejected := make([]string, 0)
+	for _, validation := range validations {
+		for _, problem := range validation.Problems {
+			// The System and YYYY-MM columns of the advert CSV format
+			if !problem.Rejected || (len(problem.Record) < 4) {
+				continue
+			}
+			name, ok := config.PublishedName(strings.TrimSpace(problem.Record[3]))
+			if ok && (name == system) && sliceContainsString(months, strings.TrimSpace(problem.Record[1])) {
+				rejected = append(rejected, validation.Filename+": "+problem.String())
+			}
+		}
+	}
+	return rejected
+}
+
+// Parse the flags of a subcommand wherever they appear among its other arguments, which are returned in order
+func parseInterspersed(flags *flag.FlagSet, args []string) []string {
+	others := make([]string, 0)
+	for {
+		flags.Parse(args)
+		args = flags.Args()
+		if len(args) == 0 {
+			return others
+		}
+		others = append(others, args[0])
+		args = args[1:]
+	}
+}
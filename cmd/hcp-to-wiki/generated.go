@@ -0,0 +1,45 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// When a page is published its generated text is placed between two comments, the first of which holds a hash of that text:
+//
+//	<!-- BEGIN hcp-to-wiki generated content 0123456789abcdef: edits here will be overwritten -->
+//	...
+//	<!-- END hcp-to-wiki generated content -->
+//
+// Anything outside the comments belongs to the wiki's editors and is kept when the page is next published.
+// If the text between the comments no longer matches the hash, someone has edited the generated content by hand
+// and publishing it again would lose their changes.
+var generatedPattern = regexp.MustCompile(`(?s)<!-- BEGIN hcp-to-wiki generated content ([0-9a-f]+): edits here will be overwritten -->\n(.*?)\n?<!-- END hcp-to-wiki generated content -->`)
+
+// Return the hash recorded for a piece of generated text.
+// Trailing whitespace is ignored, as the wiki may strip it.
+func generatedHash(text string) string {
+	sum := sha256.Sum256([]byte(strings.TrimRight(text, " \n")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Wrap generated text in the comments that mark it as generated
+func wrapGenerated(text string) string {
+	body := strings.TrimRight(text, " \n")
+	return fmt.Sprintf("<!-- BEGIN hcp-to-wiki generated content %s: edits here will be overwritten -->\n%s\n<!-- END hcp-to-wiki generated content -->", generatedHash(body), body)
+}
+
+// Given the text of a page, find its generated content.
+// Return the text before and after the generated content, and whether the generated content still matches its recorded hash.
+// found is false if the page has no generated content.
+func findGenerated(page string) (before string, after string, unmodified bool, found bool) {
+	match := generatedPattern.FindStringSubmatchIndex(page)
+	if match == nil {
+		return "", "", false, false
+	}
+	hash, body := page[match[2]:match[3]], page[match[4]:match[5]]
+	return page[:match[0]], page[match[1]:], generatedHash(body) == hash, true
+}
@@ -0,0 +1,43 @@
+package main
+
+import "testing"
+
+func TestWrapGenerated(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"table", "<!-- BEGIN hcp-to-wiki generated content " + generatedHash("table") + ": edits here will be overwritten -->\ntable\n<!-- END hcp-to-wiki generated content -->"},
+		{"table \n\n", "<!-- BEGIN hcp-to-wiki generated content " + generatedHash("table") + ": edits here will be overwritten -->\ntable\n<!-- END hcp-to-wiki generated content -->"},
+	}
+	for _, test := range tests {
+		if got := wrapGenerated(test.text); got != test.want {
+			t.Errorf("wrapGenerated(%q) = %q; want %q", test.text, got, test.want)
+		}
+	}
+}
+
+func TestFindGenerated(t *testing.T) {
+	wrapped := wrapGenerated("{|\n| £69 |\n|}")
+	tests := []struct {
+		name       string
+		page       string
+		before     string
+		after      string
+		unmodified bool
+		found      bool
+	}{
+		{"alone", wrapped, "", "", true, true},
+		{"with the editors' text", "Intro\n" + wrapped + "\n[[Category:Prices]]", "Intro\n", "\n[[Category:Prices]]", true, true},
+		{"trailing whitespace stripped by the wiki", wrapped[:len(wrapped)-len("\n<!-- END hcp-to-wiki generated content -->")] + "  \n<!-- END hcp-to-wiki generated content -->", "", "", true, true},
+		{"edited by hand", "Intro\n" + wrapped[:len(wrapped)-len("|}\n<!-- END hcp-to-wiki generated content -->")] + "| £70 |\n|}\n<!-- END hcp-to-wiki generated content -->", "Intro\n", "", false, true},
+		{"no generated content", "Intro\n{|\n|}", "", "", false, false},
+		{"no end comment", wrapped[:len(wrapped)-len("<!-- END hcp-to-wiki generated content -->")], "", "", false, false},
+	}
+	for _, test := range tests {
+		before, after, unmodified, found := findGenerated(test.page)
+		if (before != test.before) || (after != test.after) || (unmodified != test.unmodified) || (found != test.found) {
+			t.Errorf("%s: findGenerated() = %q, %q, %t, %t; want %q, %q, %t, %t", test.name, before, after, unmodified, found, test.before, test.after, test.unmodified, test.found)
+		}
+	}
+}
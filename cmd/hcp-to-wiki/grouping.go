@@ -0,0 +1,44 @@
+package main
+
+import "github.com/AntonioCarlini/home-computer-prices/hcp"
+
+// With -group-columns, the years are grouped into tables by how dense the data is, rather than five at a time:
+// each table covers as few years as hold about the target number of quarters with prices, so that the sparse
+// early years share a wide table and the busiest years are split into narrow ones. A table covers at least
+// min_group_years and at most max_group_years, and takes in any years after it too few to make a table of their own
+// if it can do so without covering more than max_group_years.
+
+// The fewest and most years that an adaptive group covers
+const min_group_years = 2
+const max_group_years = 10
+
+// Given the target number of quarters with prices in each table, return the adaptive groups of years, oldest first
+func (table priceTable) adaptiveGroups(target int) []yearGroup {
+	minYear, _ := hcp.DecodeIndexByQuarter(table.minDate)
+	maxYear, _ := hcp.DecodeIndexByQuarter(table.maxDate)
+	groups := make([]yearGroup, 0)
+	for first := minYear; first <= maxYear; {
+		last, priced := first, table.pricedQuarters(first)
+		for (last < maxYear) && (last-first+1 < max_group_years) && ((last-first+1 < min_group_years) || (priced < target)) {
+			last++
+			priced += table.pricedQuarters(last)
+		}
+		if (maxYear-last < min_group_years) && (maxYear-first+1 <= max_group_years) {
+			last = maxYear
+		}
+		groups = append(groups, yearGroup{first, last})
+		first = last + 1
+	}
+	return groups
+}
+
+// Return how many of the quarters of a year have a price for at least one system
+func (table priceTable) pricedQuarters(year int) int {
+	priced := 0
+	for quarter := 1; quarter <= 4; quarter++ {
+		if count, _ := table.quarterSummary(hcp.BuildIndexFromYearAndQuarter(year, quarter)); count > 0 {
+			priced++
+		}
+	}
+	return priced
+}
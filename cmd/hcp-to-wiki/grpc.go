@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/AntonioCarlini/home-computer-prices/hcp"
+	"github.com/AntonioCarlini/home-computer-prices/hcp/hcppb"
+)
+
+// Implements "grpc-serve [-listen host:port] [-config rules.json] [-aggregate min|mode|median] data.csv ...".
+// Loads the data once, then answers queries about it over gRPC (see hcp/hcppb/hcp.proto) until stopped,
+// so that other services, such as a chat bot answering "how much was an Amiga in 1987?", can use the same data.
+func runGRPCServer(args []string) {
+	flags := flag.NewFlagSet("grpc-serve", flag.ExitOnError)
+	listen := flags.String("listen", "localhost:50051", "address to listen on")
+	configFilename := flags.String("config", "", "JSON file of rules applied to the data (default: the built-in rules)")
+	aggregation := flags.String("aggregate", "min", "how each quarter's price is chosen from its adverts: min, mode or median")
+	logFilename, logFormat := addLoggingFlags(flags)
+	flags.Parse(args)
+	setupLogging(*logFilename, *logFormat)
+
+	config, err := hcp.LoadConfiguration(*configFilename)
+	if err != nil {
+		log.Fatalf("Cannot load configuration: %s\n", err.Error())
+	}
+	dataset, err := hcp.LoadFiles(flags.Args(), hcp.Options{Config: &config, Aggregation: *aggregation})
+	if err != nil {
+		log.Fatalf("Cannot load adverts: %s\n", err.Error())
+	}
+	printValidationSummary(dataset.Validations)
+
+	listener, err := net.Listen("tcp", *listen)
+	if err != nil {
+		log.Fatalf("Cannot listen on '%s': %s\n", *listen, err.Error())
+	}
+	server := grpc.NewServer()
+	hcppb.RegisterPricesServer(server, &pricesServer{dataset: dataset})
+	logf("Serving gRPC on %s\n", listener.Addr())
+	if err := server.Serve(listener); err != nil {
+		log.Fatalf("gRPC server failed: %s\n", err.Error())
+	}
+}
+
+// The header line added to candidate rows sent for validation without one
+const candidate_rows_header = "Source,YYYY-MM,Page,System,Price,,Kit,Board"
+
+// Answers the gRPC Prices service from a dataset
+type pricesServer struct {
+	hcppb.UnimplementedPricesServer
+	dataset *hcp.Dataset
+}
+
+func (server *pricesServer) ListSystems(ctx context.Context, request *hcppb.ListSystemsRequest) (*hcppb.ListSystemsResponse, error) {
+	return &hcppb.ListSystemsResponse{Systems: server.dataset.Systems()}, nil
+}
+
+func (server *pricesServer) PricesForSystem(ctx context.Context, request *hcppb.PricesForSystemRequest) (*hcppb.PricesForSystemResponse, error) {
+	prices := server.dataset.PricesFor(request.GetSystem())
+	if len(prices) == 0 {
+		return nil, status.Errorf(codes.NotFound, "no prices for [%s]", request.GetSystem())
+	}
+	response := &hcppb.PricesForSystemResponse{}
+	for _, price := range prices {
+		response.Prices = append(response.Prices, &hcppb.QuarterPrice{
+			Year:        int32(price.Year),
+			Quarter:     int32(price.Quarter),
+			Pence:       int64(price.Price),
+			AdvertCount: int32(len(price.Adverts)),
+		})
+	}
+	return response, nil
+}
+
+func (server *pricesServer) PricesForQuarter(ctx context.Context, request *hcppb.PricesForQuarterRequest) (*hcppb.PricesForQuarterResponse, error) {
+	if (request.GetQuarter() < 1) || (request.GetQuarter() > 4) {
+		return nil, status.Errorf(codes.InvalidArgument, "bad quarter [%d]", request.GetQuarter())
+	}
+	response := &hcppb.PricesForQuarterResponse{}
+	for _, price := range server.dataset.Quarter(int(request.GetYear()), int(request.GetQuarter())) {
+		response.Prices = append(response.Prices, &hcppb.SystemPrice{
+			System:      price.System,
+			Pence:       int64(price.Price),
+			AdvertCount: int32(len(price.Adverts)),
+		})
+	}
+	return response, nil
+}
+
+func (server *pricesServer) ValidateRows(ctx context.Context, request *hcppb.ValidateRowsRequest) (*hcppb.ValidateRowsResponse, error) {
+	// Row numbers are reported as the rows were sent, so allow for any header line that is added
+	text, offset := request.GetCsv(), 0
+	if !hasHeaderLine(text) {
+		text, offset = candidate_rows_header+"\n"+text, 1
+	}
+	_, _, _, validation, err := hcp.ParseAdverts("request", strings.NewReader(text))
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%s", err.Error())
+	}
+	response := &hcppb.ValidateRowsResponse{
+		Rows:     int32(validation.Rows),
+		Accepted: int32(validation.Accepted),
+		Rejected: int32(validation.Rejected),
+		Warnings: int32(validation.Warnings),
+	}
+	for _, problem := range validation.Problems {
+		response.Problems = append(response.Problems, &hcppb.RowProblem{
+			Row:      int32(problem.Row - offset),
+			Field:    problem.Field,
+			Text:     problem.Text,
+			Message:  problem.Err.Error(),
+			Rejected: problem.Rejected,
+		})
+	}
+	return response, nil
+}
+
+// Return true if CSV text contains the header line (with "Source" in the first column) that the data rows follow
+func hasHeaderLine(text string) bool {
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "Source,") {
+			return true
+		}
+	}
+	return false
+}
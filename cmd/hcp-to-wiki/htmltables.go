@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"io"
+
+	"github.com/AntonioCarlini/home-computer-prices/hcp"
+)
+
+// The html output is a standalone HTML page holding the same tables as the wiki output, a table per group of years,
+// for previewing the tables in a browser before they are pasted into a wiki or for publishing them on a plain web host.
+// Nothing is loaded from elsewhere: the stylesheet is embedded in the page. Unlike the archive output it has no
+// charts or script, only the tables.
+
+// Styles for the html output, marking prices as the wiki tables do
+const html_tables_style = `body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+td, th { border: 1px solid #ccc; padding: 0.2em 0.6em; }
+th { background: #f4f4f4; }
+td.price { text-align: right; }
+td.none { text-align: center; }
+tr.totals td, tr.totals th { border-top: 2px solid #999; }
+.interpolated { font-style: italic; }
+.carried { color: grey; }
+.off-sale { background: ` + off_sale_background + `; }`
+
+// Return the title of an HTML page of the tables, naming the quarters they span; with no adverts, there are none to name
+func (table priceTable) pageTitle() string {
+	if table.maxDate < table.minDate {
+		return "Home computer prices"
+	}
+	return fmt.Sprintf("Home computer prices, %s to %s", hcp.FormatQuarter(table.minDate), hcp.FormatQuarter(table.maxDate))
+}
+
+// Given advert data for a range of systems, outputs that data as a standalone HTML page of tables
+func outputHTMLTables(w io.Writer, table priceTable) {
+	title := table.pageTitle()
+	fmt.Fprintf(w, "<!DOCTYPE html>\n<html lang=\"en\">\n<head>\n<meta charset=\"utf-8\">\n")
+	fmt.Fprintf(w, "<title>%s</title>\n<style>\n%s\n</style>\n</head>\n<body>\n", html.EscapeString(title), html_tables_style)
+	fmt.Fprintf(w, "<h1>%s</h1>\n", html.EscapeString(title))
+	groups := table.groupYears()
+	for i, group := range groups {
+		fmt.Fprintf(w, "<h2>%d - %d</h2>\n", group.first, group.last)
+		outputHTMLGroup(w, table, group)
+		progress.update("Rendering", i+1, len(groups), "tables")
+	}
+	outputHTMLAttribution(w, table)
+	if table.stamp != "" {
+		fmt.Fprintf(w, "<!-- %s -->\n", table.stamp)
+	}
+	fmt.Fprintf(w, "</body>\n</html>\n")
+}
+
+// Outputs the HTML table, and the notes explaining it, for a group of years
+func outputHTMLGroup(w io.Writer, table priceTable, group yearGroup) {
+	first, last := table.groupQuarters(group)
+	fmt.Fprintf(w, "<table>\n<tr><th></th>")
+	for year := group.first; year <= group.last; year++ {
+		shown := 0
+		for quarter := 1; quarter <= 4; quarter++ {
+			if index := hcp.BuildIndexFromYearAndQuarter(year, quarter); (index >= first) && (index <= last) {
+				shown++
+			}
+		}
+		if shown > 0 {
+			fmt.Fprintf(w, "<th colspan=\"%d\">%d</th>", shown, year)
+		}
+	}
+	fmt.Fprintf(w, "</tr>\n<tr><th>%s</th>", html.EscapeString(table.systemHeading()))
+	for index := first; index <= last; index++ {
+		_, quarter := hcp.DecodeIndexByQuarter(index)
+		fmt.Fprintf(w, "<th>%s</th>", html.EscapeString(table.quarterHeadings()[quarter-1]))
+	}
+	fmt.Fprintf(w, "</tr>\n")
+
+	for _, key := range table.groupKeys(group) {
+		fmt.Fprintf(w, "<tr><th>%s</th>", html.EscapeString(key))
+		for index := first; index <= last; index++ {
+			class, text := table.htmlCell(key, index)
+			fmt.Fprintf(w, "<td class=\"%s\">%s</td>", class, html.EscapeString(text))
+		}
+		fmt.Fprintf(w, "</tr>\n")
+	}
+	if table.totals {
+		fmt.Fprintf(w, "<tr class=\"totals\"><th>%s</th>", html.EscapeString(table.note("systems-priced", "Systems priced")))
+		for index := first; index <= last; index++ {
+			priced, _ := table.quarterSummary(index)
+			fmt.Fprintf(w, "<td class=\"price\">%d</td>", priced)
+		}
+		fmt.Fprintf(w, "</tr>\n<tr><th>%s</th>", html.EscapeString(table.note("median-price", "Median price")))
+		for index := first; index <= last; index++ {
+			if priced, median := table.quarterSummary(index); priced > 0 {
+				fmt.Fprintf(w, "<td class=\"price\">%s</td>", html.EscapeString(table.wikiPrice(median)))
+			} else {
+				fmt.Fprintf(w, "<td class=\"none\">&mdash;</td>")
+			}
+		}
+		fmt.Fprintf(w, "</tr>\n")
+	}
+	fmt.Fprintf(w, "</table>\n")
+	for _, note := range table.legend() {
+		fmt.Fprintf(w, "<p>%s</p>\n", html.EscapeString(note))
+	}
+}
+
+// Return the class and the text of the HTML cell for a system's price at a date-index
+func (table priceTable) htmlCell(key string, index int) (class string, text string) {
+	offSale := ""
+	if table.offSale(key, index) {
+		offSale = " off-sale"
+	}
+	switch {
+	case (index >= table.minDate) && (index <= table.maxDate) && (table.kind(key, index) == withheldPrice):
+		return "none" + offSale, withheld_marker
+	case (index < table.minDate) || (index > table.maxDate) || (table.systems[key][index-table.minDate] <= 0):
+		if placeholder, ok := table.placeholder(key, index); ok {
+			return "none" + offSale, placeholder
+		}
+		return "none" + offSale, "—"
+	}
+	return "price " + table.kind(key, index).String() + offSale, table.markedCellText(key, index, table.wikiPrice)
+}
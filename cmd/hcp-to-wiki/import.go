@@ -0,0 +1,242 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/AntonioCarlini/home-computer-prices/hcp"
+)
+
+// Implements "import -format price-list-csv|price-list-json -region US [-rate 0.5] [-rates rates.json] [-currency $] [-source name] [-o out.csv] file".
+// Converts a price dataset published elsewhere, such as a US magazine price list, into rows of the advert CSV
+// format tagged with a region in the optional Region column, ready to be checked and merged into the data.
+// Such datasets are expected to be a list of records with these fields (named case-insensitively):
+//
+//	date         "1983-04" or "1983-04-15"
+//	system       the machine (or "machine")
+//	price        e.g. "599.95" or "$599.95"
+//	publication  where the price was published (or "source"); -source supplies it if the dataset lacks it
+//	page         optional page number
+//
+// price-list-csv takes them as the columns of a CSV file with a header row, price-list-json as an array of JSON objects.
+// Prices not in pounds are converted with -rates, a JSON object of year => pounds per unit ({"1983": 0.66}),
+// falling back on the single rate given with -rate; a record whose year has no rate is reported and skipped.
+// The currency each converted price was quoted in is recorded in the optional Currency column, so that the tables can
+// show it (see -show-currency): the symbol marking the price, or -currency for prices given without one.
+func runImport(args []string) {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	format := flags.String("format", "price-list-csv", "format of the dataset: price-list-csv or price-list-json")
+	region := flags.String("region", "", "region tag written to each row, such as US")
+	rate := flags.Float64("rate", 0, "pounds per unit of the dataset's currency, for years not in -rates (0 means the prices are already in pounds)")
+	ratesFilename := flags.String("rates", "", "JSON file of pounds per unit of the dataset's currency by year")
+	currency := flags.String("currency", "", "symbol of the dataset's currency, such as $, recorded for the converted prices that are not marked with one")
+	source := flags.String("source", "", "publication recorded for records that do not name one")
+	outputFilename := flags.String("o", "", "write the rows to this file instead of standard output")
+	flags.Parse(args)
+
+	reader, ok := importFormats[*format]
+	if !ok {
+		log.Fatalf("Unknown import format '%s'\n", *format)
+	}
+	if *region == "" {
+		log.Fatalf("-region is needed\n")
+	}
+	if *rate < 0 {
+		log.Fatalf("-rate cannot be negative\n")
+	}
+	if flags.NArg() != 1 {
+		log.Fatalf("Exactly 1 dataset required but %d supplied\n", flags.NArg())
+	}
+	rates := make(map[string]float64)
+	if *ratesFilename != "" {
+		data, err := os.ReadFile(*ratesFilename)
+		if err != nil {
+			log.Fatalf("Cannot read exchange rates: %s\n", err.Error())
+		}
+		if err := json.Unmarshal(data, &rates); err != nil {
+			log.Fatalf("Bad exchange rates file '%s': %s\n", *ratesFilename, err.Error())
+		}
+		for year, yearRate := range rates {
+			if yearRate <= 0 {
+				log.Fatalf("Bad exchange rate %g for %s in '%s'\n", yearRate, year, *ratesFilename)
+			}
+		}
+	}
+
+	f, err := os.Open(flags.Arg(0))
+	if err != nil {
+		log.Fatalf("Cannot open dataset: %s\n", err.Error())
+	}
+	records, err := reader(f)
+	f.Close()
+	if err != nil {
+		log.Fatalf("Cannot read dataset '%s': %s\n", flags.Arg(0), err.Error())
+	}
+
+	rows := make([][]string, 0, len(records))
+	for i, record := range records {
+		row, err := record.advertRow(*region, *source, *currency, rates, *rate)
+		if err != nil {
+			logf("Record %d: %s\n", i+1, err.Error())
+			continue
+		}
+		rows = append(rows, row)
+	}
+	logf("Imported %d of %d records\n", len(rows), len(records))
+	writeOutput(*outputFilename, func(w io.Writer) {
+		writeAdvertRows(w, rows)
+	})
+}
+
+// The optional column of the advert CSV format holding the currency a price was quoted in before it was converted into pounds
+const imported_currency_header = "Currency"
+
+// Write rows in the advert CSV format, after the row declaring its schema version and its header line, which names
+// the schema's columns and then the Currency column.
+// The rows hold the fixed columns, the Region column and the Currency column; the others are left empty.
+func writeAdvertRows(w io.Writer, rows [][]string) {
+	header := append(hcp.SchemaHeader(), imported_currency_header)
+	out := csv.NewWriter(w)
+	out.Write(hcp.SchemaMarker())
+	out.Write(header)
+	for _, row := range rows {
+		for len(row) < len(header) {
+			row = append(row, "")
+		}
+		out.Write(row)
+	}
+	out.Flush()
+	if err := out.Error(); err != nil {
+		log.Fatalf("Cannot write rows: %s\n", err.Error())
+	}
+}
+
+// One price from an external dataset, with fields as named in the dataset (lower-cased)
+type importedRecord map[string]string
+
+// The readers for each external dataset format, which return its records
+var importFormats = map[string]func(r io.Reader) ([]importedRecord, error){
+	"price-list-csv":  readPriceListCSV,
+	"price-list-json": readPriceListJSON,
+}
+
+// Read a CSV price list, whose header row names the fields
+func readPriceListCSV(r io.Reader) ([]importedRecord, error) {
+	data, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	records := make([]importedRecord, 0)
+	if len(data) == 0 {
+		return records, nil
+	}
+	header := data[0]
+	for _, row := range data[1:] {
+		record := make(importedRecord, len(header))
+		for i, name := range header {
+			if i < len(row) {
+				record[strings.ToLower(strings.TrimSpace(name))] = strings.TrimSpace(row[i])
+			}
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// Read a JSON price list, an array of objects whose values may be strings or numbers
+func readPriceListJSON(r io.Reader) ([]importedRecord, error) {
+	var objects []map[string]interface{}
+	if err := json.NewDecoder(r).Decode(&objects); err != nil {
+		return nil, err
+	}
+	records := make([]importedRecord, 0, len(objects))
+	for _, object := range objects {
+		record := make(importedRecord, len(object))
+		for name, value := range object {
+			if value != nil {
+				record[strings.ToLower(name)] = strings.TrimSpace(fmt.Sprint(value))
+			}
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// Return the first of the named fields that the record has a value for
+func (record importedRecord) field(names ...string) string {
+	for _, name := range names {
+		if value := record[name]; value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// Convert a record into a row of the advert CSV format, as written by writeAdvertRows.
+// The price is converted into pounds at the rate for its year (or the fallback rate; 0 leaves it alone, so a price
+// marked in dollars or euros with no rate is an error).
+// The currency of a converted price is the symbol it is marked with, or else the given one.
+func (record importedRecord) advertRow(region string, source string, currency string, rates map[string]float64, rate float64) ([]string, error) {
+	system := record.field("system", "machine")
+	if system == "" {
+		return nil, fmt.Errorf("no system")
+	}
+	publication := record.field("publication", "source")
+	if publication == "" {
+		publication = source
+	}
+	if publication == "" {
+		return nil, fmt.Errorf("no publication for [%s]", system)
+	}
+
+	date := record.field("date")
+	if len(date) > len("YYYY-MM") {
+		date = date[:len("YYYY-MM")]
+	}
+	if len(date) < len("YYYY-MM") {
+		return nil, fmt.Errorf("bad date [%s] for [%s]", record.field("date"), system)
+	}
+
+	page := "p0"
+	if number := record.field("page"); number != "" {
+		page = "p" + strings.TrimPrefix(number, "p")
+	}
+
+	text := strings.ReplaceAll(strings.TrimLeft(record.field("price"), "$£€ "), ",", "")
+	amount, err := strconv.ParseFloat(text, 64)
+	if (err != nil) || math.IsNaN(amount) || math.IsInf(amount, 0) || (amount <= 0) {
+		return nil, fmt.Errorf("bad price [%s] for [%s]", record.field("price"), system)
+	}
+	if yearRate, ok := rates[date[:len("YYYY")]]; ok {
+		rate = yearRate
+	} else if len(rates) > 0 && rate == 0 {
+		return nil, fmt.Errorf("no exchange rate for [%s]", date[:len("YYYY")])
+	} else if rate == 0 && strings.ContainsAny(record.field("price"), "$€") {
+		return nil, fmt.Errorf("no exchange rate for the price [%s] of [%s], which is not in pounds", record.field("price"), system)
+	}
+	quoted := "" // The currency the price was quoted in, if it is converted
+	if rate > 0 {
+		amount = amount * rate
+		quoted = currency
+		for _, symbol := range []string{"$", "€", "£"} {
+			if strings.Contains(record.field("price"), symbol) {
+				quoted = symbol
+			}
+		}
+	}
+	price := "£" + formatPrice(int(amount*100+0.5), "exact")
+
+	row := []string{publication, date, page, system, price, "", "", "", region}
+	for len(row) < len(hcp.SchemaHeader()) {
+		row = append(row, "")
+	}
+	return append(row, quoted), nil
+}
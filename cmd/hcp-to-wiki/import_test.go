@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/AntonioCarlini/home-computer-prices/hcp"
+)
+
+func TestAdvertRow(t *testing.T) {
+	tests := []struct {
+		name     string
+		record   importedRecord
+		rates    map[string]float64
+		rate     float64
+		want     []string
+		currency string
+		err      bool
+	}{
+		{"pounds", importedRecord{"system": "ZX81", "date": "1982-03-15", "price": "£69.95", "publication": "Byte", "page": "12"}, nil, 0,
+			[]string{"Byte", "1982-03", "p12", "ZX81", "£69.95", "", "", "", "US"}, "", false},
+		{"machine and source", importedRecord{"machine": "ZX81", "date": "1982-03", "price": "69.95"}, nil, 0,
+			[]string{"catalogue", "1982-03", "p0", "ZX81", "£69.95", "", "", "", "US"}, "", false},
+		{"page already prefixed", importedRecord{"system": "ZX81", "date": "1982-03", "price": "£69.95", "page": "p7"}, nil, 0,
+			[]string{"catalogue", "1982-03", "p7", "ZX81", "£69.95", "", "", "", "US"}, "", false},
+		{"fallback rate", importedRecord{"system": "ZX81", "date": "1982-03", "price": "$1,000"}, nil, 0.5,
+			[]string{"catalogue", "1982-03", "p0", "ZX81", "£500", "", "", "", "US"}, "$", false},
+		{"rate for the year", importedRecord{"system": "ZX81", "date": "1982-03", "price": "$100"}, map[string]float64{"1982": 0.6}, 0.5,
+			[]string{"catalogue", "1982-03", "p0", "ZX81", "£60", "", "", "", "US"}, "$", false},
+		{"euros", importedRecord{"system": "ZX81", "date": "1982-03", "price": "€100"}, nil, 0.5,
+			[]string{"catalogue", "1982-03", "p0", "ZX81", "£50", "", "", "", "US"}, "€", false},
+		{"no symbol", importedRecord{"system": "ZX81", "date": "1982-03", "price": "100"}, nil, 0.5,
+			[]string{"catalogue", "1982-03", "p0", "ZX81", "£50", "", "", "", "US"}, "A$", false},
+		{"no rate for the year", importedRecord{"system": "ZX81", "date": "1983-03", "price": "$100"}, map[string]float64{"1982": 0.6}, 0, nil, "", true},
+		{"no system", importedRecord{"date": "1982-03", "price": "£69.95"}, nil, 0, nil, "", true},
+		{"bad date", importedRecord{"system": "ZX81", "date": "1982", "price": "£69.95"}, nil, 0, nil, "", true},
+		{"bad price", importedRecord{"system": "ZX81", "date": "1982-03", "price": "£sixty"}, nil, 0, nil, "", true},
+		{"not a number", importedRecord{"system": "ZX81", "date": "1982-03", "price": "NaN"}, nil, 0, nil, "", true},
+		{"infinite", importedRecord{"system": "ZX81", "date": "1982-03", "price": "£Inf"}, nil, 0, nil, "", true},
+		{"negative", importedRecord{"system": "ZX81", "date": "1982-03", "price": "£-69.95"}, nil, 0, nil, "", true},
+		{"zero", importedRecord{"system": "ZX81", "date": "1982-03", "price": "£0"}, nil, 0, nil, "", true},
+		{"dollars without a rate", importedRecord{"system": "ZX81", "date": "1982-03", "price": "$100"}, nil, 0, nil, "", true},
+		{"euros without a rate", importedRecord{"system": "ZX81", "date": "1982-03", "price": "€100"}, nil, 0, nil, "", true},
+	}
+	for _, test := range tests {
+		row, err := test.record.advertRow("US", "catalogue", "A$", test.rates, test.rate)
+		want := append(test.want, make([]string, len(hcp.SchemaHeader())-len(test.want))...)
+		want = append(want, test.currency)
+		if (err != nil) != test.err {
+			t.Errorf("%s: advertRow() error = %v; want error %t", test.name, err, test.err)
+		} else if !test.err && !reflect.DeepEqual(row, want) {
+			t.Errorf("%s: advertRow() = %q; want %q", test.name, row, want)
+		}
+	}
+}
+
+func TestImportedCurrency(t *testing.T) {
+	rows := make([][]string, 0)
+	for _, imported := range []struct {
+		record importedRecord
+		rate   float64
+	}{
+		{importedRecord{"system": "VIC-20", "date": "1982-03", "price": "$1,000"}, 0.6},
+		{importedRecord{"system": "ZX81", "date": "1982-03", "price": "$100"}, 0.6},
+		{importedRecord{"system": "ZX81", "date": "1982-02", "price": "£69.95"}, 0},
+		{importedRecord{"system": "Dragon 32", "date": "1982-03", "price": "£175"}, 0},
+	} {
+		row, err := imported.record.advertRow("US", "catalogue", "", nil, imported.rate)
+		if err != nil {
+			t.Fatalf("advertRow(%v) error = %v", imported.record, err)
+		}
+		rows = append(rows, row)
+	}
+	var csv bytes.Buffer
+	writeAdvertRows(&csv, rows)
+	dataset, err := hcp.LoadCSV("imported", &csv, hcp.Options{})
+	if err != nil {
+		t.Fatalf("LoadCSV() error = %v", err)
+	}
+	index := hcp.BuildIndexFromYearAndQuarter(1982, 1)
+	for _, showCurrency := range []bool{false, true} {
+		table := newPriceTable(dataset, tableOptions{rounding: "trunc", showCurrency: showCurrency})
+		want := map[string]string{"VIC-20": "£600 [$]", "ZX81": "£60 [$/£]", "Dragon 32": "£175"}
+		if !showCurrency {
+			want = map[string]string{"VIC-20": "£600", "ZX81": "£60", "Dragon 32": "£175"}
+		}
+		for system, text := range want {
+			if got := table.markedCellText(system, index, table.wikiPrice); got != text {
+				t.Errorf("-show-currency=%t: markedCellText(%q) = %q; want %q", showCurrency, system, got, text)
+			}
+		}
+		if notes := table.legend(); (len(notes) > 0) != showCurrency {
+			t.Errorf("-show-currency=%t: legend() = %q", showCurrency, notes)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/AntonioCarlini/home-computer-prices/hcp"
+)
+
+// Implements "import-wiki [-source name] [-o out.csv] page.wikitext".
+// Reads wiki price tables laid out like those this program generates, but maintained by hand before it existed,
+// and writes the prices they hold as rows of the advert CSV format so that they can be merged into the data.
+// Each table must have a header row giving the years, each spanning four quarter columns (colspan="4"),
+// followed by rows of a system name and a price per quarter. Since the tables do not say which advert a price
+// came from, each row is given the synthetic source -source (by default "Wiki: PAGE", after the file name),
+// the first month of its quarter and page 0. Prices shown as estimates (in italics or grey) are not imported.
+func runImportWiki(args []string) {
+	flags := flag.NewFlagSet("import-wiki", flag.ExitOnError)
+	source := flags.String("source", "", "source recorded for every row (default: \"Wiki: \" and the file name)")
+	outputFilename := flags.String("o", "", "write the rows to this file instead of standard output")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		log.Fatalf("Exactly 1 wikitext file required but %d supplied\n", flags.NArg())
+	}
+	filename := flags.Arg(0)
+	text, err := os.ReadFile(filename)
+	if err != nil {
+		log.Fatalf("Cannot read wikitext: %s\n", err.Error())
+	}
+	if *source == "" {
+		*source = "Wiki: " + strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+	}
+
+	prices, problems := parseWikiTables(string(text))
+	for _, problem := range problems {
+		logf("%s: %s\n", filename, problem)
+	}
+	rows := make([][]string, 0, len(prices))
+	for _, price := range prices {
+		year, quarter := hcp.DecodeIndexByQuarter(price.index)
+		rows = append(rows, []string{*source, fmt.Sprintf("%04d-%02d", year, quarter*3-2), "p0", price.system, price.price, "", "", "", ""})
+	}
+	logf("Imported %d prices\n", len(rows))
+	writeOutput(*outputFilename, func(w io.Writer) {
+		writeAdvertRows(w, rows)
+	})
+}
+
+// A price found in a wiki table
+type wikiTablePrice struct {
+	system string
+	index  int    // Date-index of the quarter
+	price  string // As written, such as "£399"
+}
+
+// Matches a year heading cell, such as `colspan="4" | 1983`
+var wikiYearHeading = regexp.MustCompile(`^(?:.*colspan="?4"?\s*\|)?\s*(\d{4})\s*$`)
+
+// Matches a wiki link, keeping the text shown: [[Page]] or [[Page|text]]
+var wikiLink = regexp.MustCompile(`\[\[(?:[^\]|]*\|)?([^\]]*)\]\]`)
+
+// Given wikitext, return the price in each quarter cell of each table, and a description of anything that could not be understood.
+func parseWikiTables(text string) ([]wikiTablePrice, []string) {
+	prices := make([]wikiTablePrice, 0)
+	problems := make([]string, 0)
+
+	var years []int    // The year of each group of four quarter columns in the current table
+	var cells []string // The cells of the current row
+	lineNumber := 0
+	endRow := func() {
+		if len(cells) > 0 {
+			prices, problems = wikiRowPrices(cells, years, lineNumber, prices, problems)
+		}
+		cells = nil
+	}
+
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "{|"):
+			years = nil
+		case strings.HasPrefix(line, "|}"), strings.HasPrefix(line, "|-"):
+			endRow()
+		case strings.HasPrefix(line, "!"):
+			for _, cell := range strings.Split(strings.TrimPrefix(line, "!"), "!!") {
+				for _, heading := range strings.Split(cell, "||") {
+					if match := wikiYearHeading.FindStringSubmatch(strings.TrimSpace(heading)); match != nil {
+						year, _ := strconv.Atoi(match[1])
+						years = append(years, year)
+					}
+				}
+			}
+		case strings.HasPrefix(line, "|") && !strings.HasPrefix(line, "|+"):
+			if len(cells) == 0 {
+				lineNumber = i + 1
+			}
+			cells = append(cells, strings.Split(strings.TrimPrefix(line, "|"), "||")...)
+		}
+	}
+	endRow()
+	return prices, problems
+}
+
+// Given the cells of one table row (the system, then a cell per quarter) and the years the table covers,
+// add the row's prices to prices, or describe why it cannot be used.
+func wikiRowPrices(cells []string, years []int, lineNumber int, prices []wikiTablePrice, problems []string) ([]wikiTablePrice, []string) {
+	if len(years) == 0 {
+		return prices, append(problems, fmt.Sprintf("line %d: row is not under a heading of years", lineNumber))
+	}
+	system := wikiLink.ReplaceAllString(wikiCellContent(cells[0]), "$1")
+	if len(cells)-1 != len(years)*4 {
+		return prices, append(problems, fmt.Sprintf("line %d: [%s] has %d quarters but the heading has %d", lineNumber, system, len(cells)-1, len(years)*4))
+	}
+	for i, cell := range cells[1:] {
+		content := wikiCellContent(cell)
+		if (content == "") || (content == "&mdash;") || (content == "—") || (content == "-") || (content == "?") || (content == withheld_marker) {
+			continue
+		}
+		// Estimates are shown in italics or grey; only prices taken from adverts belong in the data
+		if strings.HasPrefix(content, "''") || strings.Contains(cell, "color: grey") {
+			continue
+		}
+		index := hcp.BuildIndexFromYearAndQuarter(years[i/4], i%4+1)
+		prices = append(prices, wikiTablePrice{system, index, content})
+	}
+	return prices, problems
+}
+
+// Return the content of a table cell, without any attributes (`style="..." | content`)
+func wikiCellContent(cell string) string {
+	// A "|" inside a link is part of the link, not the end of the attributes
+	if bar := strings.Index(cell, "|"); (bar >= 0) && !strings.Contains(cell[:bar], "[[") {
+		cell = cell[bar+1:]
+	}
+	return strings.TrimSpace(cell)
+}
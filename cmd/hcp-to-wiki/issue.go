@@ -0,0 +1,44 @@
+package main
+
+import (
+	"flag"
+	"log"
+
+	"github.com/AntonioCarlini/home-computer-prices/hcp"
+)
+
+// With -issue, such as -issue "Your Computer 1983-11", a generation run or a subcommand reads only the rows for that
+// issue, as if the rest of the data were not there, so that a newly transcribed issue can be validated, checked and
+// explained on its own. Rows from other issues are neither validated nor counted in the validation results.
+
+// The issue chosen with -issue, if any; loadAdverts reads only its rows
+var issueFilter *hcp.Issue
+
+// Add the -issue option to a set of flags, returning a function that reads the chosen issue once the flags are parsed.
+// The function returns nil if no issue was chosen; an issue that cannot be read is fatal.
+func addIssueFlag(flags *flag.FlagSet, usage string) func() *hcp.Issue {
+	text := flags.String("issue", "", usage)
+	return func() *hcp.Issue {
+		if *text == "" {
+			return nil
+		}
+		issue, err := hcp.ParseIssue(*text)
+		if err != nil {
+			log.Fatalf("Cannot select issue: %s\n", err.Error())
+		}
+		return &issue
+	}
+}
+
+// Warn if an issue was chosen with -issue but none of the files has any rows for it, which usually means a mistyped title or date
+func checkIssueRows(validations []hcp.FileValidation) {
+	if issueFilter == nil {
+		return
+	}
+	for _, validation := range validations {
+		if validation.Rows > 0 {
+			return
+		}
+	}
+	logf("No rows are for issue %s\n", issueFilter)
+}
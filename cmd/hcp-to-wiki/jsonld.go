@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/AntonioCarlini/home-computer-prices/hcp"
+)
+
+// The JSON-LD output describes each system as a schema.org Product.
+// Every quarter with a valid price becomes an Offer whose validFrom/validThrough dates span that quarter.
+// The result is intended to be embedded in an HTML page inside a <script type="application/ld+json"> element.
+
+type jsonldDocument struct {
+	Context string        `json:"@context"`
+	Graph   []interface{} `json:"@graph"`
+}
+
+// With -stamp, the document also holds a Dataset describing how it was generated; it also carries any attribution
+type jsonldDataset struct {
+	Type         string         `json:"@type"`
+	Name         string         `json:"name"`
+	Description  string         `json:"description,omitempty"`
+	DateModified string         `json:"dateModified"`
+	License      string         `json:"license,omitempty"`
+	Creator      []jsonldPerson `json:"creator,omitempty"`
+	URL          string         `json:"url,omitempty"`
+}
+
+type jsonldPerson struct {
+	Type string `json:"@type"`
+	Name string `json:"name"`
+}
+
+type jsonldProduct struct {
+	Type     string        `json:"@type"`
+	Name     string        `json:"name"`
+	Category string        `json:"category"`
+	Offers   []jsonldOffer `json:"offers"`
+}
+
+type jsonldOffer struct {
+	Type          string `json:"@type"`
+	Price         string `json:"price"`
+	PriceCurrency string `json:"priceCurrency"`
+	ValidFrom     string `json:"validFrom"`
+	ValidThrough  string `json:"validThrough"`
+}
+
+// Given advert data for a range of systems, outputs that data as a schema.org JSON-LD document
+func outputJSONLD(w io.Writer, table priceTable) {
+	systems, keys, minDate, maxDate := table.systems, table.keys, table.minDate, table.maxDate
+	document := jsonldDocument{Context: "https://schema.org", Graph: make([]interface{}, 0, len(keys)+1)}
+	if (table.stamp != "") || !table.attribution.IsEmpty() {
+		attribution := table.attribution
+		dataset := jsonldDataset{Type: "Dataset", Name: "Home computer prices", Description: table.stamp, DateModified: time.Now().UTC().Format("2006-01-02"), URL: attribution.Repository}
+		// schema.org expects the licence as a URL, but its name will do if that is all there is
+		dataset.License = attribution.LicenceURL
+		if dataset.License == "" {
+			dataset.License = attribution.Licence
+		}
+		for _, contributor := range attribution.Contributors {
+			dataset.Creator = append(dataset.Creator, jsonldPerson{"Person", contributor})
+		}
+		document.Graph = append(document.Graph, dataset)
+	}
+	for _, key := range keys {
+		prices := systems[key]
+		product := jsonldProduct{Type: "Product", Name: key, Category: "Home computer", Offers: make([]jsonldOffer, 0)}
+		for index := minDate; index <= maxDate; index++ {
+			// Estimated prices were never actually offered, so only observed prices are described
+			if (prices[index-minDate] <= 0) || (table.kind(key, index) != observedPrice) {
+				continue
+			}
+			validFrom, validThrough := quarterDateRange(index)
+			offer := jsonldOffer{
+				Type:          "Offer",
+				Price:         formatPrice(prices[index-minDate], table.rounding),
+				PriceCurrency: "GBP",
+				ValidFrom:     validFrom,
+				ValidThrough:  validThrough,
+			}
+			product.Offers = append(product.Offers, offer)
+		}
+		// Systems with no valid price at all have nothing worth describing
+		if len(product.Offers) > 0 {
+			document.Graph = append(document.Graph, product)
+		}
+	}
+
+	encoded, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		log.Fatalln("Cannot encode JSON-LD data:", err.Error())
+	}
+	fmt.Fprintf(w, "%s\n", encoded)
+}
+
+// Given a date-index, return the first and last days of that quarter as ISO 8601 dates
+func quarterDateRange(index int) (first string, last string) {
+	year, quarter := hcp.DecodeIndexByQuarter(index)
+	firstMonth := time.Month((quarter-1)*3 + 1)
+	start := time.Date(year, firstMonth, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 3, -1)
+	return start.Format("2006-01-02"), end.Format("2006-01-02")
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/AntonioCarlini/home-computer-prices/hcp"
+)
+
+// With -languages, the wiki tables are also written in each of the named languages of the configuration,
+// each to a subdirectory of -out-dir named after the language, such as "build/de/". Their headings, prices,
+// notes and links follow the language (see hcp.Language); "publish -languages" sends them to the sister wikis.
+
+// The headings of the quarter columns in the English tables
+var english_quarters = []string{"JAN-MAR", "APR-JUN", "JUL-SEP", "OCT-DEC"}
+
+// Return the heading of the system column
+func (table priceTable) systemHeading() string {
+	if (table.language != nil) && (table.language.System != "") {
+		return table.language.System
+	}
+	return "System"
+}
+
+// Return the headings of the four quarter columns
+func (table priceTable) quarterHeadings() []string {
+	if (table.language != nil) && (len(table.language.Quarters) == 4) {
+		return table.language.Quarters
+	}
+	return english_quarters
+}
+
+// Return a price, in pence, as it is shown in the wiki tables: "£" then the number of pounds, written as the language writes numbers
+func (table priceTable) wikiPrice(pence int) string {
+	price := formatPrice(pence, table.rounding)
+	if table.language == nil {
+		return "£" + price
+	}
+	pounds, pennies, hasPennies := strings.Cut(price, ".")
+	if separator := table.language.ThousandsSeparator; separator != "" {
+		for i := len(pounds) - 3; i > 0; i -= 3 {
+			pounds = pounds[:i] + separator + pounds[i:]
+		}
+	}
+	if hasPennies {
+		decimal := table.language.DecimalSeparator
+		if decimal == "" {
+			decimal = "."
+		}
+		pounds = pounds + decimal + pennies
+	}
+	return "£" + pounds
+}
+
+// Return a system's name as it is shown in the wiki tables, linked to the language's page about it if there is one
+func (table priceTable) wikiSystem(system string) string {
+	if table.language == nil {
+		return system
+	}
+	page, ok := table.language.Links[system]
+	switch {
+	case !ok || (page == ""):
+		return system
+	case page == system:
+		return "[[" + system + "]]"
+	default:
+		return "[[" + page + "|" + system + "]]"
+	}
+}
+
+// Return the note with the given name (a kind of price, or "single" for -mark-single-source), in the language if it has a translation.
+// The English text is replaced by the output format's override, if it has one (see legendWith).
+func (table priceTable) note(name string, english string) string {
+	if table.language != nil {
+		if note, ok := table.language.Notes[name]; ok && (note != "") {
+			return note
+		}
+	}
+	if override, ok := table.noteOverrides[name]; ok {
+		return override
+	}
+	return english
+}
+
+// Given the price table and the languages chosen with -languages, return a copy of the table for each language
+func languageTables(table priceTable, config hcp.Configuration, names []string) (map[string]priceTable, error) {
+	tables := make(map[string]priceTable, len(names))
+	for _, name := range names {
+		language, ok := config.Languages[name]
+		if !ok {
+			return nil, fmt.Errorf("no language [%s] in the configuration", name)
+		}
+		variant := table
+		variant.language = &language
+		tables[name] = variant
+	}
+	return tables, nil
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/AntonioCarlini/home-computer-prices/hcp"
+)
+
+// The latex output holds the same tables as the wiki output, as LaTeX for print publication, such as an article about
+// the systems' prices. Each group of years is a table in the style of the booktabs package, which the document must
+// load, with each year spanning its quarters above a rule, as in the wiki tables. A quarter without a price shows an
+// em dash, or the configured placeholder. LaTeX needs a package for colour, so only interpolated prices are marked
+// as the wiki marks them, in italics, while carried prices are in parentheses, as in the markdown output, and
+// quarters outside a system's lifespan are not shaded. The notes follow each table; the output is a fragment to be
+// \input into a document, rather than a document of its own.
+
+// Given advert data for a range of systems, outputs that data as LaTeX tables
+func outputLaTeX(w io.Writer, table priceTable) {
+	fmt.Fprintf(w, "%% Generated price tables: load the booktabs package in the preamble, then \\input this file\n\n")
+	groups := table.groupYears()
+	for i, group := range groups {
+		outputLaTeXGroup(w, table, group)
+		progress.update("Rendering", i+1, len(groups), "tables")
+	}
+	outputLaTeXFooter(w, table)
+}
+
+// Outputs the LaTeX table, and the notes explaining it, for a group of years
+func outputLaTeXGroup(w io.Writer, table priceTable, group yearGroup) {
+	first, last := table.groupQuarters(group)
+	fmt.Fprintf(w, "\\begin{table}[htbp]\n\\centering\n\\small\n\\caption{%d--%d}\n", group.first, group.last)
+	fmt.Fprintf(w, "\\begin{tabular}{l%s}\n\\toprule\n", strings.Repeat("r", last-first+1))
+
+	// The years, each spanning its quarters with a rule beneath, then the quarters
+	years, rules := []string{""}, make([]string, 0)
+	column := 2
+	for year := group.first; year <= group.last; year++ {
+		shown := 0
+		for quarter := 1; quarter <= 4; quarter++ {
+			if index := hcp.BuildIndexFromYearAndQuarter(year, quarter); (index >= first) && (index <= last) {
+				shown++
+			}
+		}
+		if shown == 0 {
+			continue
+		}
+		years = append(years, fmt.Sprintf("\\multicolumn{%d}{c}{%d}", shown, year))
+		rules = append(rules, fmt.Sprintf("\\cmidrule(lr){%d-%d}", column, column+shown-1))
+		column += shown
+	}
+	writeLaTeXRow(w, years)
+	fmt.Fprintf(w, "%s\n", strings.Join(rules, " "))
+	headings := []string{latexText(table.systemHeading())}
+	for index := first; index <= last; index++ {
+		_, quarter := hcp.DecodeIndexByQuarter(index)
+		headings = append(headings, latexText(table.quarterHeadings()[quarter-1]))
+	}
+	writeLaTeXRow(w, headings)
+	fmt.Fprintf(w, "\\midrule\n")
+
+	for _, key := range table.groupKeys(group) {
+		cells := []string{latexText(key)}
+		for index := first; index <= last; index++ {
+			cells = append(cells, table.latexCell(key, index))
+		}
+		writeLaTeXRow(w, cells)
+	}
+	if table.totals {
+		fmt.Fprintf(w, "\\midrule\n")
+		counts, medians := []string{"\\textbf{" + latexText(table.note("systems-priced", "Systems priced")) + "}"}, []string{"\\textbf{" + latexText(table.note("median-price", "Median price")) + "}"}
+		for index := first; index <= last; index++ {
+			priced, median := table.quarterSummary(index)
+			counts = append(counts, fmt.Sprintf("%d", priced))
+			if priced == 0 {
+				medians = append(medians, "---")
+			} else {
+				medians = append(medians, latexText(table.wikiPrice(median)))
+			}
+		}
+		writeLaTeXRow(w, counts)
+		writeLaTeXRow(w, medians)
+	}
+	fmt.Fprintf(w, "\\bottomrule\n\\end{tabular}\n")
+	for _, note := range table.legendWith(parenthesised_carried_notes) {
+		fmt.Fprintf(w, "\n\\smallskip\\footnotesize %s\n", latexText(note))
+	}
+	fmt.Fprintf(w, "\\end{table}\n\n")
+}
+
+// Return the LaTeX for one cell of a system's row
+func (table priceTable) latexCell(key string, index int) string {
+	switch {
+	case (index >= table.minDate) && (index <= table.maxDate) && (table.kind(key, index) == withheldPrice):
+		return latexText(withheld_marker)
+	case (index < table.minDate) || (index > table.maxDate) || (table.systems[key][index-table.minDate] <= 0):
+		if placeholder, ok := table.placeholder(key, index); ok {
+			return latexText(placeholder)
+		}
+		return "---"
+	}
+	price := latexText(table.markedCellText(key, index, table.wikiPrice))
+	switch table.kind(key, index) {
+	case interpolatedPrice:
+		return "\\textit{" + price + "}"
+	case carriedPrice:
+		return "(" + price + ")"
+	}
+	return price
+}
+
+// Outputs one row of a LaTeX table
+func writeLaTeXRow(w io.Writer, cells []string) {
+	fmt.Fprintf(w, "%s \\\\\n", strings.Join(cells, " & "))
+}
+
+// Return text escaped so that LaTeX typesets it as it is, rather than as commands or the end of a cell,
+// with the pound sign, the dagger and the em dash written as commands that need no particular input encoding
+func latexText(text string) string {
+	return strings.NewReplacer(
+		"\\", "\\textbackslash{}", "&", "\\&", "%", "\\%", "$", "\\$", "#", "\\#", "_", "\\_", "{", "\\{", "}", "\\}",
+		"~", "\\textasciitilde{}", "^", "\\textasciicircum{}", "|", "\\textbar{}", "<", "\\textless{}", ">", "\\textgreater{}",
+		"£", "\\pounds{}", "†", "\\dag{}", "—", "---",
+	).Replace(text)
+}
+
+// Outputs the footer of a LaTeX fragment: the attribution, then the metadata stamp as a comment
+func outputLaTeXFooter(w io.Writer, table priceTable) {
+	if !table.attribution.IsEmpty() {
+		escaped := hcp.Attribution{
+			Licence:    latexText(table.attribution.Licence),
+			LicenceURL: latexText(table.attribution.LicenceURL),
+			Repository: latexText(table.attribution.Repository),
+		}
+		for _, contributor := range table.attribution.Contributors {
+			escaped.Contributors = append(escaped.Contributors, latexText(contributor))
+		}
+		text := attributionSentences(escaped, func(url string, text string) string {
+			if url == text {
+				return "\\texttt{" + url + "}"
+			}
+			return text + " (\\texttt{" + url + "})"
+		})
+		fmt.Fprintf(w, "{\\footnotesize %s\\par}\n", text)
+	}
+	if table.stamp != "" {
+		fmt.Fprintf(w, "%% %s\n", table.stamp)
+	}
+}
@@ -0,0 +1,27 @@
+package main
+
+import "testing"
+
+func TestLaTeXText(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"ZX81", "ZX81"},
+		{"£69.95", "\\pounds{}69.95"},
+		{"Acorn & BBC", "Acorn \\& BBC"},
+		{"50% off", "50\\% off"},
+		{"$100 #1", "\\$100 \\#1"},
+		{"TRS_80 {kit}", "TRS\\_80 \\{kit\\}"},
+		{"~5^2", "\\textasciitilde{}5\\textasciicircum{}2"},
+		{"C:\\DOS", "C:\\textbackslash{}DOS"},
+		{"†—", "\\dag{}---"},
+		{"Games | Utilities", "Games \\textbar{} Utilities"},
+		{"<£100 >£50", "\\textless{}\\pounds{}100 \\textgreater{}\\pounds{}50"},
+	}
+	for _, test := range tests {
+		if got := latexText(test.text); got != test.want {
+			t.Errorf("latexText(%q) = %q; want %q", test.text, got, test.want)
+		}
+	}
+}
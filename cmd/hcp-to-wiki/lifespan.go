@@ -0,0 +1,87 @@
+package main
+
+import "fmt"
+
+// The configuration may give when each system was launched and discontinued (see hcp.Lifespan).
+// Quarters in which a system was not on sale are shaded in the wiki and archive tables and in the archive charts,
+// so that a gap before a launch is not mistaken for missing data; adverts dated outside the lifespan are warned about.
+// The configuration's placeholders (see hcp.Placeholders) may also tell these quarters apart in the text of their cells.
+
+// The background of the wiki table cells for quarters in which a system was not on sale
+const off_sale_background = "#eee"
+
+// Return true if the configuration says the system was not on sale at any time in the quarter with the given date-index
+func (table priceTable) offSale(system string, index int) bool {
+	lifespan, ok := table.lifespans[system]
+	return ok && !lifespan.IncludesQuarter(index)
+}
+
+// Return true if any quarter of the tables is shaded as being outside a system's lifespan, so that the tables need a note explaining it
+func (table priceTable) hasOffSale() bool {
+	for _, system := range table.keys {
+		for index := table.minDate; index <= table.maxDate; index++ {
+			if table.offSale(system, index) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Return why a system has no price in a quarter, as the placeholders tell it: "pre-launch", "discontinued" or "no-data"
+func (table priceTable) placeholderReason(system string, index int) string {
+	lifespan, ok := table.lifespans[system]
+	switch {
+	case ok && lifespan.BeforeLaunch(index):
+		return "pre-launch"
+	case ok && lifespan.AfterDiscontinuation(index):
+		return "discontinued"
+	}
+	return "no-data"
+}
+
+// Return the configuration's placeholder for a system's quarter without a price, and true;
+// or false if the configuration gives none for it, in which case the cell shows a dash
+func (table priceTable) placeholder(system string, index int) (string, bool) {
+	text := map[string]string{
+		"pre-launch":   table.placeholders.PreLaunch,
+		"discontinued": table.placeholders.Discontinued,
+		"no-data":      table.placeholders.NoData,
+	}[table.placeholderReason(system, index)]
+	return text, text != ""
+}
+
+// Return the notes explaining the placeholders shown in the tables, for the legend
+func (table priceTable) placeholderNotes() []string {
+	shown := make(map[string]bool)
+	for _, group := range table.groupYears() {
+		first, last := table.groupQuarters(group)
+		for _, system := range table.groupKeys(group) {
+			for index := first; index <= last; index++ {
+				inRange := (index >= table.minDate) && (index <= table.maxDate)
+				if !inRange || ((table.systems[system][index-table.minDate] <= 0) && (table.kind(system, index) != withheldPrice)) {
+					shown[table.placeholderReason(system, index)] = true
+				}
+			}
+		}
+	}
+	notes := make([]string, 0)
+	for _, reason := range []struct{ name, text, english string }{
+		{"pre-launch", table.placeholders.PreLaunch, "%s marks a quarter before the system was launched."},
+		{"discontinued", table.placeholders.Discontinued, "%s marks a quarter after the system was discontinued."},
+		{"no-data", table.placeholders.NoData, "%s marks a quarter in which no advert for the system was found."},
+	} {
+		if shown[reason.name] && (reason.text != "") {
+			notes = append(notes, table.note(reason.name, fmt.Sprintf(reason.english, reason.text)))
+		}
+	}
+	return notes
+}
+
+// Return the style to add to a wiki table cell for a system's quarter: a shaded background if it was not on sale, otherwise nothing
+func (table priceTable) wikiLifespanStyle(system string, index int) string {
+	if table.offSale(system, index) {
+		return " background: " + off_sale_background + ";"
+	}
+	return ""
+}
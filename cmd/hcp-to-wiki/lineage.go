@@ -0,0 +1,108 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/AntonioCarlini/home-computer-prices/hcp"
+)
+
+// Implements "lineage-chart [-o chart.svg] [-config rules.json] [-aggregate min] [-lineages Sinclair,Acorn] data.csv ...".
+// Draws an SVG chart of the price of each manufacturer's current entry-level machine, following the lineages of the
+// configuration (such as ZX80, then ZX81, then ZX Spectrum 16K) as one continuous series across the model changes.
+// In each quarter a lineage's price is that of its newest system with a price then; once a successor has been
+// priced, its predecessors are no longer followed, so the series never steps back to a model that has been replaced.
+// By default every lineage is charted; -lineages names them explicitly. The model changes are listed with the diagnostics.
+func runLineageChart(args []string) {
+	flags := flag.NewFlagSet("lineage-chart", flag.ExitOnError)
+	outputFilename := flags.String("o", "", "write the chart to this file instead of standard output")
+	configFilename := flags.String("config", "", "JSON file of rules applied to the data (default: the built-in rules)")
+	aggregation := flags.String("aggregate", "min", "how each quarter's price is chosen from its adverts: min, mode or median")
+	lineageList := flags.String("lineages", "", "comma-separated list of the lineages to chart instead of all of them")
+	flags.Parse(args)
+	if flags.NArg() < 1 {
+		log.Fatalf("At least 1 argument required but %d supplied\n", flags.NArg())
+	}
+	if _, ok := hcp.PriceAggregations[*aggregation]; !ok {
+		log.Fatalf("Unknown aggregation '%s'\n", *aggregation)
+	}
+
+	config, err := hcp.LoadConfiguration(*configFilename)
+	if err != nil {
+		log.Fatalf("Cannot load configuration: %s\n", err.Error())
+	}
+	var names []string
+	if *lineageList != "" {
+		names = strings.Split(*lineageList, ",")
+		for i := range names {
+			names[i] = strings.TrimSpace(names[i])
+			if _, ok := config.Lineages[names[i]]; !ok {
+				log.Fatalf("No lineage '%s' in the configuration\n", names[i])
+			}
+		}
+	} else {
+		for name := range config.Lineages {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+	if len(names) == 0 {
+		log.Fatalf("The configuration has no lineages\n")
+	}
+
+	dataset, err := hcp.LoadFiles(flags.Args(), hcp.Options{Config: &config, Aggregation: *aggregation})
+	if err != nil {
+		log.Fatalf("Cannot load adverts: %s\n", err.Error())
+	}
+	printRowProblems(dataset.Validations)
+	if len(dataset.Adverts) == 0 {
+		log.Fatalf("No adverts to chart\n")
+	}
+
+	prices := dataset.Prices()
+	series := make([]chartSeries, 0, len(names))
+	for _, name := range names {
+		values, changes := lineagePrices(prices, config.Lineages[name], dataset.MinDate, dataset.MaxDate)
+		series = append(series, chartSeries{name, values})
+		for _, change := range changes {
+			logf("%s: %s from %s\n", name, change.system, hcp.FormatQuarter(change.index))
+		}
+	}
+	label := func(pence int) string {
+		return "£" + formatPrice(pence, "trunc")
+	}
+	writeOutput(*outputFilename, func(w io.Writer) {
+		writeLineChart(w, "Entry-level price", dataset.MinDate, dataset.MaxDate, series, label, nil)
+	})
+}
+
+// The quarter from which a lineage's price is that of one of its systems
+type lineageChange struct {
+	system string
+	index  int
+}
+
+// Given the published prices, the systems of a lineage (oldest first) and the quarters to cover, return the lineage's
+// price in each quarter (-1 where it has none, as writeLineChart expects) and the quarters in which each system took over
+func lineagePrices(prices map[string][]int, systems []string, minDate int, maxDate int) ([]int, []lineageChange) {
+	values := make([]int, 0, maxDate-minDate+1)
+	changes := make([]lineageChange, 0)
+	current := 0
+	for index := minDate; index <= maxDate; index++ {
+		value := -1
+		for position := len(systems) - 1; position >= current; position-- {
+			if system := prices[systems[position]]; (system != nil) && (system[index-minDate] > 0) {
+				if (position != current) || (len(changes) == 0) {
+					changes = append(changes, lineageChange{systems[position], index})
+				}
+				current, value = position, system[index-minDate]
+				break
+			}
+		}
+		values = append(values, value)
+	}
+	return values, changes
+}
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/AntonioCarlini/home-computer-prices/hcp"
+)
+
+// A problem found when checking the configuration.
+// Errors would produce wrong or unpredictable output; warnings are merely suspicious.
+type lintProblem struct {
+	isError bool
+	message string
+}
+
+func (problem lintProblem) String() string {
+	if problem.isError {
+		return "error: " + problem.message
+	}
+	return "warning: " + problem.message
+}
+
+// Implements "lint-config [-config rules.json] [data.csv ...]".
+// Checks the configuration for conflicting rules and cycles before a generation run.
+// If any CSV files are supplied, rules that mention systems that never appear in that data are also reported.
+// The exit status is 1 if any errors were found.
+func runLintConfig(args []string) {
+	flags := flag.NewFlagSet("lint-config", flag.ExitOnError)
+	configFilename := flags.String("config", "", "configuration file to check (default: the built-in rules)")
+	flags.Parse(args)
+
+	config, err := hcp.LoadConfiguration(*configFilename)
+	if err != nil {
+		fmt.Printf("error: %s\n", err)
+		os.Exit(1)
+	}
+
+	var knownSystems []string
+	if flags.NArg() > 0 {
+		knownSystems = make([]string, 0)
+		adverts, _, _, _ := loadAdverts(flags.Args())
+		for _, advert := range adverts {
+			if !sliceContainsString(knownSystems, advert.System) {
+				knownSystems = append(knownSystems, advert.System)
+			}
+		}
+	}
+
+	problems := lintConfiguration(config, knownSystems)
+	errors := 0
+	for _, problem := range problems {
+		fmt.Println(problem)
+		if problem.isError {
+			errors++
+		}
+	}
+	fmt.Printf("%d error(s), %d warning(s)\n", errors, len(problems)-errors)
+	if errors > 0 {
+		os.Exit(1)
+	}
+}
+
+// Check the configuration, returning every problem found.
+// If knownSystems is not nil, any rule that mentions a system not in that list is reported as a warning.
+func lintConfiguration(config hcp.Configuration, knownSystems []string) []lintProblem {
+	problems := make([]lintProblem, 0)
+	addError := func(format string, args ...interface{}) {
+		problems = append(problems, lintProblem{true, fmt.Sprintf(format, args...)})
+	}
+	addWarning := func(format string, args ...interface{}) {
+		problems = append(problems, lintProblem{false, fmt.Sprintf(format, args...)})
+	}
+
+	// Individual rename rules must be complete and must actually change the name.
+	// A system may only be renamed to one thing.
+	renamedTo := make(map[string]string)
+	for _, rule := range config.Renames {
+		if rule.From == "" || rule.To == "" {
+			addError("incomplete rename rule [%s] => [%s]", rule.From, rule.To)
+			continue
+		}
+		if rule.From == rule.To {
+			addError("rename rule [%s] => [%s] renames a system to itself", rule.From, rule.To)
+			continue
+		}
+		if previous, ok := renamedTo[rule.From]; ok {
+			if previous == rule.To {
+				addWarning("duplicate rename rule [%s] => [%s]", rule.From, rule.To)
+			} else {
+				addError("conflicting rename rules [%s] => [%s] and [%s] => [%s]", rule.From, previous, rule.From, rule.To)
+			}
+			continue
+		}
+		renamedTo[rule.From] = rule.To
+	}
+
+	// Follow each chain of renames looking for cycles.
+	// Each cycle is reported once, starting from its alphabetically lowest member.
+	sources := make([]string, 0, len(renamedTo))
+	for from := range renamedTo {
+		sources = append(sources, from)
+	}
+	sort.Strings(sources)
+	reportedCycles := make(map[string]bool)
+	for _, start := range sources {
+		chain := []string{start}
+		for name := renamedTo[start]; name != ""; name = renamedTo[name] {
+			if position := indexOfString(chain, name); position >= 0 {
+				cycle := append(chain[position:], name)
+				lowest := cycle[0]
+				for _, member := range cycle {
+					if member < lowest {
+						lowest = member
+					}
+				}
+				if !reportedCycles[lowest] {
+					reportedCycles[lowest] = true
+					addError("rename cycle %s", strings.Join(cycle, " => "))
+				}
+				break
+			}
+			chain = append(chain, name)
+		}
+	}
+
+	// A system must not be both suppressed and renamed, nor renamed into a suppressed system
+	seenSuppressed := make(map[string]bool)
+	for _, name := range config.Suppress {
+		if seenSuppressed[name] {
+			addWarning("system [%s] is suppressed more than once", name)
+			continue
+		}
+		seenSuppressed[name] = true
+		if to, ok := renamedTo[name]; ok {
+			addError("system [%s] is both suppressed and renamed to [%s]", name, to)
+		}
+	}
+	for _, from := range sources {
+		if final := config.ResolveName(from); final != from && seenSuppressed[final] {
+			addError("system [%s] is renamed to [%s], which is suppressed", from, final)
+		}
+	}
+
+	// A language must give a heading for every quarter, or none at all
+	languages := make([]string, 0, len(config.Languages))
+	for name := range config.Languages {
+		languages = append(languages, name)
+	}
+	sort.Strings(languages)
+	for _, name := range languages {
+		if quarters := config.Languages[name].Quarters; (len(quarters) != 0) && (len(quarters) != 4) {
+			addError("language [%s] has %d quarter headings rather than 4", name, len(quarters))
+		}
+	}
+
+	// Price limits are looked up by year, so must be in order
+	for i, limit := range config.PriceLimits {
+		if limit.Max < 0 {
+			addError("price limit from %d is negative", limit.From)
+		}
+		if (i > 0) && (limit.From <= config.PriceLimits[i-1].From) {
+			addError("price limit from %d is not in order after the limit from %d", limit.From, config.PriceLimits[i-1].From)
+		}
+	}
+
+	if (config.YearOnlyQuarter < 0) || (config.YearOnlyQuarter > 4) {
+		addError("year_only_quarter is %d rather than 1 to 4 (or 0 to spread across the year)", config.YearOnlyQuarter)
+	}
+
+	issues := make([]string, 0, len(config.IssueMonths))
+	for issue := range config.IssueMonths {
+		issues = append(issues, issue)
+	}
+	sort.Strings(issues)
+	for _, issue := range issues {
+		if month := config.IssueMonths[issue]; (month < 0) || (month > 12) {
+			addError("issue_months gives [%s] the month %d rather than 1 to 12 (or 0 for a year alone)", issue, month)
+		}
+	}
+
+	magazines := make([]string, 0, len(config.CoverDateLeads))
+	for magazine := range config.CoverDateLeads {
+		magazines = append(magazines, magazine)
+	}
+	sort.Strings(magazines)
+	for _, magazine := range magazines {
+		if lead := config.CoverDateLeads[magazine]; (lead < 0) || (lead > 12) {
+			addError("cover_date_leads gives [%s] a lead of %d months rather than 0 to 12", magazine, lead)
+		}
+	}
+
+	// Lifespans must be readable and must not end before they begin
+	lifespans := make([]string, 0, len(config.Lifespans))
+	for system := range config.Lifespans {
+		lifespans = append(lifespans, system)
+	}
+	sort.Strings(lifespans)
+	for _, system := range lifespans {
+		first, last, err := config.Lifespans[system].Months()
+		if err != nil {
+			addError("lifespan of [%s] has a %s", system, err)
+		} else if first > last {
+			addError("lifespan of [%s] ends before it begins (%s)", system, config.Lifespans[system])
+		}
+		if _, ok := config.PublishedName(system); !ok {
+			addWarning("lifespan of [%s] is for a suppressed system", system)
+		}
+	}
+
+	// A lineage is a succession of systems, so needs at least two, each appearing once
+	lineages := make([]string, 0, len(config.Lineages))
+	for name := range config.Lineages {
+		lineages = append(lineages, name)
+	}
+	sort.Strings(lineages)
+	for _, name := range lineages {
+		systems := config.Lineages[name]
+		if len(systems) < 2 {
+			addWarning("lineage [%s] has %d system(s), so charts a single system", name, len(systems))
+		}
+		for i, system := range systems {
+			if indexOfString(systems[:i], system) >= 0 {
+				addError("lineage [%s] has [%s] more than once", name, system)
+			}
+		}
+	}
+
+	if config.TrailingMonths < 0 {
+		addError("trailing_months is %d rather than 0 or more", config.TrailingMonths)
+	}
+	if config.ExcludeTrailing && (config.TrailingMonths == 0) {
+		addWarning("exclude_trailing is set, but without trailing_months no price is trailing")
+	}
+
+	// Advert sizes must be ones the data can record, and their weights are counts of times
+	sizes := make([]string, 0, len(config.AdvertSizeWeights))
+	for size := range config.AdvertSizeWeights {
+		sizes = append(sizes, size)
+	}
+	sort.Strings(sizes)
+	for _, size := range sizes {
+		if !sliceContainsString(hcp.AdvertSizes, size) {
+			addError("advert_size_weights gives a weight to [%s], which is not one of %s", size, strings.Join(hcp.AdvertSizes, ", "))
+		}
+		if weight := config.AdvertSizeWeights[size]; weight < 0 {
+			addError("advert_size_weights gives [%s] the weight %d rather than 0 or more", size, weight)
+		}
+	}
+
+	// Rules about systems that never appear in the data are probably misspelt
+	if knownSystems != nil {
+		for _, from := range sources {
+			if !sliceContainsString(knownSystems, from) {
+				addWarning("rename rule mentions unknown system [%s]", from)
+			}
+		}
+		for _, name := range config.Suppress {
+			if !sliceContainsString(knownSystems, name) {
+				addWarning("suppress rule mentions unknown system [%s]", name)
+			}
+		}
+		// Lifespans are given by the name a system is published under
+		published := make([]string, 0, len(knownSystems))
+		for _, name := range knownSystems {
+			published = append(published, config.ResolveName(name))
+		}
+		for _, system := range lifespans {
+			if !sliceContainsString(published, system) {
+				addWarning("lifespan of unknown system [%s]", system)
+			}
+		}
+		for _, name := range lineages {
+			for _, system := range config.Lineages[name] {
+				if !sliceContainsString(published, system) {
+					addWarning("lineage [%s] mentions unknown system [%s]", name, system)
+				}
+			}
+		}
+	}
+
+	return problems
+}
+
+// Return the position of candidate in slice, or -1 if it is not present
+func indexOfString(slice []string, candidate string) int {
+	for i, member := range slice {
+		if member == candidate {
+			return i
+		}
+	}
+	return -1
+}
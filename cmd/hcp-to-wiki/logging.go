@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+// Diagnostics, such as validation problems and publishing progress, go through logf rather than straight to standard output.
+// By default they are printed as they always have been; -log-file sends them to a file instead, and
+// -log-format json writes each one as a JSON object on its own line, e.g.
+//
+//	{"time":"2026-10-14T08:55:59Z","level":"info","msg":"Dropping Apple II"}
+//
+// Fatal errors reported through the log package follow the same settings, with a level of "error".
+var (
+	logOutput io.Writer = os.Stdout
+	logJSON             = false
+)
+
+// Add the -log-file and -log-format options to a set of flags
+func addLoggingFlags(flags *flag.FlagSet) (filename *string, format *string) {
+	filename = flags.String("log-file", "", "append diagnostics to this file instead of printing them")
+	format = flags.String("log-format", "text", "how diagnostics are written: text or json")
+	return filename, format
+}
+
+// Direct the diagnostics as the -log-file and -log-format options ask
+func setupLogging(filename string, format string) {
+	switch format {
+	case "text":
+	case "json":
+		logJSON = true
+	default:
+		log.Fatalf("Unknown log format '%s'\n", format)
+	}
+	if filename != "" {
+		f, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			log.Fatalf("Cannot open log file '%s': %s\n", filename, err.Error())
+		}
+		logOutput = f
+		log.SetOutput(f)
+	}
+	if logJSON {
+		log.SetFlags(0)
+		log.SetOutput(jsonLogWriter{"error"})
+	}
+}
+
+// Write a diagnostic message, formatted as for fmt.Printf
+func logf(format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	progress.finish()
+	if !logJSON {
+		fmt.Fprint(logOutput, message)
+		return
+	}
+	jsonLogWriter{"info"}.Write([]byte(message))
+}
+
+// A jsonLogWriter turns each message written to it into a JSON log record with the given level
+type jsonLogWriter struct {
+	level string
+}
+
+func (writer jsonLogWriter) Write(message []byte) (int, error) {
+	record, err := json.Marshal(struct {
+		Time    string `json:"time"`
+		Level   string `json:"level"`
+		Message string `json:"msg"`
+	}{time.Now().UTC().Format(time.RFC3339), writer.level, strings.TrimRight(string(message), "\n")})
+	if err != nil {
+		return 0, err
+	}
+	if _, err := fmt.Fprintf(logOutput, "%s\n", record); err != nil {
+		return 0, err
+	}
+	return len(message), nil
+}
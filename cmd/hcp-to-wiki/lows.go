@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/AntonioCarlini/home-computer-prices/hcp"
+)
+
+// Implements "lows -new new.csv[,...] [-o report.csv] [-config rules.json] [-webhook url] [-webhook-format slack] data.csv ...".
+// Outputs, as CSV, each system for which the adverts of the latest import, the files given to -new, set a new
+// all-time low: an advert cheaper than every advert for it in the rest of the data. A system is named as it is
+// published, and only its cheapest new advert is listed; a system seen for the first time has no low to beat.
+// With -webhook the lows are also posted to a chat webhook, as for the project's social feeds; nothing is posted
+// if there are none.
+func runRecordLows(args []string) {
+	flags := flag.NewFlagSet("lows", flag.ExitOnError)
+	newFiles := flags.String("new", "", "the CSV files of the latest import, separated by commas")
+	outputFilename := flags.String("o", "", "write the report to this file instead of standard output")
+	configFilename := flags.String("config", "", "JSON file of rules applied to the data (default: the built-in rules)")
+	webhookURL := flags.String("webhook", "", "also post the new lows to this chat webhook URL")
+	webhookFormat := flags.String("webhook-format", "slack", "the kind of chat webhook: slack, discord or matrix")
+	flags.Parse(args)
+	if *newFiles == "" {
+		log.Fatalf("-new is required\n")
+	}
+	if flags.NArg() < 1 {
+		log.Fatalf("At least 1 argument required but %d supplied\n", flags.NArg())
+	}
+	if _, ok := webhookStyles[*webhookFormat]; !ok {
+		log.Fatalf("Unknown webhook format '%s'\n", *webhookFormat)
+	}
+	config, err := hcp.LoadConfiguration(*configFilename)
+	if err != nil {
+		log.Fatalf("Cannot load configuration: %s\n", err.Error())
+	}
+
+	earlier, _, _, _ := loadAdverts(flags.Args())
+	latest, _, _, _ := loadAdverts(strings.Split(*newFiles, ","))
+	lows := findRecordLows(publishedAdverts(earlier, config), publishedAdverts(latest, config))
+
+	writeOutput(*outputFilename, func(w io.Writer) {
+		out := csv.NewWriter(w)
+		out.Write([]string{"System", "Price", "Magazine", "Issue", "Page", "File", "Row", "Previous Low", "Previous Magazine", "Previous Issue"})
+		for _, low := range lows {
+			advert, previous := low.advert, low.previous
+			page := ""
+			if advert.Page >= 0 {
+				page = "p" + strconv.Itoa(advert.Page)
+			}
+			out.Write([]string{advert.System, formatPrice(advert.Price, "exact"), advert.Magazine, advertIssue(advert), page, advert.File, strconv.Itoa(advert.Row), formatPrice(previous.Price, "exact"), previous.Magazine, advertIssue(previous)})
+		}
+		out.Flush()
+		if err := out.Error(); err != nil {
+			log.Fatalln("Cannot write CSV data:", err.Error())
+		}
+	})
+
+	if (*webhookURL != "") && (len(lows) > 0) {
+		if err := postWebhook(*webhookURL, *webhookFormat, recordLowsMessage(lows)); err != nil {
+			logf("Cannot post to webhook: %s\n", err.Error())
+		}
+	}
+}
+
+// A new all-time low: the advert that set it and the cheapest advert before it
+type recordLow struct {
+	advert   hcp.Advert
+	previous hcp.Advert
+}
+
+// Return the adverts whose data is published, each under the name it is published under
+func publishedAdverts(adverts []hcp.Advert, config hcp.Configuration) []hcp.Advert {
+	published := make([]hcp.Advert, 0, len(adverts))
+	for _, advert := range adverts {
+		if name, ok := config.PublishedName(advert.System); ok {
+			advert.System = name
+			published = append(published, advert)
+		}
+	}
+	return published
+}
+
+// Given the earlier adverts and those of the latest import, return the new all-time lows, in alphabetical order of system.
+// Of two equally cheap adverts, the first in the data is the one that set the low.
+func findRecordLows(earlier []hcp.Advert, latest []hcp.Advert) []recordLow {
+	cheapest := func(adverts []hcp.Advert) map[string]hcp.Advert {
+		result := make(map[string]hcp.Advert)
+		for _, advert := range adverts {
+			if existing, ok := result[advert.System]; !ok || (advert.Price < existing.Price) {
+				result[advert.System] = advert
+			}
+		}
+		return result
+	}
+	before, after := cheapest(earlier), cheapest(latest)
+	lows := make([]recordLow, 0)
+	for system, advert := range after {
+		if previous, ok := before[system]; ok && (advert.Price < previous.Price) {
+			lows = append(lows, recordLow{advert, previous})
+		}
+	}
+	sort.Slice(lows, func(i int, j int) bool { return lows[i].advert.System < lows[j].advert.System })
+	return lows
+}
+
+// Return the issue an advert appeared in, as "YYYY-MM"
+func advertIssue(advert hcp.Advert) string {
+	return fmt.Sprintf("%04d-%02d", advert.Year, advert.Month)
+}
+
+// Given the new all-time lows, build the message posted to the webhook
+func recordLowsMessage(lows []recordLow) string {
+	lines := make([]string, 0, len(lows))
+	for _, low := range lows {
+		advert, previous := low.advert, low.previous
+		lines = append(lines, fmt.Sprintf("%s: £%s in %s %s, down from £%s in %s %s", advert.System, formatPrice(advert.Price, "exact"), advert.Magazine, advertIssue(advert), formatPrice(previous.Price, "exact"), previous.Magazine, advertIssue(previous)))
+	}
+	return fmt.Sprintf("New all-time low prices in the latest import:\n%s", strings.Join(lines, "\n"))
+}
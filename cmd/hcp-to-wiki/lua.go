@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/AntonioCarlini/home-computer-prices/hcp"
+)
+
+// The Lua output is a Scribunto data module: saved as a MediaWiki page such as "Module:Home computer prices/data",
+// it can be loaded with mw.loadData() by a Lua module that builds the tables, so the wiki can change how the
+// prices are presented without the markup having to be regenerated.
+//
+// Example:
+//
+//	return {
+//		first = "1980Q1",
+//		last = "1984Q4",
+//		systems = {
+//			{
+//				name = "Sinclair ZX81",
+//				prices = { ["1981Q1"] = 69, ["1981Q3"] = 49 },
+//				kinds = { ["1981Q2"] = "interpolated" },
+//			},
+//		},
+//	}
+//
+// Prices are in pounds, rounded as for the other output. Each system's kinds table names only the prices that were estimated.
+
+// Given advert data for a range of systems, outputs that data as a Lua table for a Scribunto data module
+func outputLua(w io.Writer, table priceTable) {
+	systems, keys, minDate, maxDate := table.systems, table.keys, table.minDate, table.maxDate
+	fmt.Fprintf(w, "-- Generated by hcp-to-wiki: changes made here will be lost when the data is next published\n")
+	if table.stamp != "" {
+		fmt.Fprintf(w, "-- %s\n", table.stamp)
+	}
+	attribution := attributionText(table.attribution)
+	if attribution != "" {
+		fmt.Fprintf(w, "-- %s\n", attribution)
+	}
+	fmt.Fprintf(w, "return {\n")
+	if attribution != "" {
+		fmt.Fprintf(w, "\tattribution = %s,\n", luaString(attribution))
+	}
+	fmt.Fprintf(w, "\tfirst = %s,\n", luaString(hcp.FormatQuarter(minDate)))
+	fmt.Fprintf(w, "\tlast = %s,\n", luaString(hcp.FormatQuarter(maxDate)))
+	fmt.Fprintf(w, "\tsystems = {\n")
+	for _, key := range keys {
+		prices := systems[key]
+		entries := make([]string, 0)
+		kinds := make([]string, 0)
+		for index := minDate; index <= maxDate; index++ {
+			if prices[index-minDate] <= 0 {
+				continue
+			}
+			quarter := luaString(hcp.FormatQuarter(index))
+			entries = append(entries, fmt.Sprintf("[%s] = %s", quarter, formatPrice(prices[index-minDate], table.rounding)))
+			if kind := table.kind(key, index); kind != observedPrice {
+				kinds = append(kinds, fmt.Sprintf("[%s] = %s", quarter, luaString(kind.String())))
+			}
+		}
+		// Systems with no valid price at all have nothing worth describing
+		if len(entries) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\t\t{\n")
+		fmt.Fprintf(w, "\t\t\tname = %s,\n", luaString(key))
+		fmt.Fprintf(w, "\t\t\tprices = { %s },\n", strings.Join(entries, ", "))
+		fmt.Fprintf(w, "\t\t\tkinds = { %s },\n", strings.Join(kinds, ", "))
+		fmt.Fprintf(w, "\t\t},\n")
+	}
+	fmt.Fprintf(w, "\t},\n")
+	fmt.Fprintf(w, "}\n")
+}
+
+// Given a string, return it as a double-quoted Lua string literal.
+// Scribunto uses Lua 5.1, so control characters are written as decimal escapes; other bytes, including UTF-8, are left as they are.
+func luaString(text string) string {
+	var quoted strings.Builder
+	quoted.WriteByte('"')
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case (c == '"') || (c == '\\'):
+			quoted.WriteByte('\\')
+			quoted.WriteByte(c)
+		case c == '\n':
+			quoted.WriteString("\\n")
+		case (c < ' ') || (c == 0x7f):
+			fmt.Fprintf(&quoted, "\\%03d", c)
+		default:
+			quoted.WriteByte(c)
+		}
+	}
+	quoted.WriteByte('"')
+	return quoted.String()
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/AntonioCarlini/home-computer-prices/hcp"
+)
+
+// With -by-magazine, the tables are also built for each magazine from only that magazine's adverts,
+// so that the prices in titles aimed at hobbyists can be compared with those in titles aimed at businesses.
+// Each magazine's output, in every selected format, is written to its own subdirectory of -out-dir/magazines,
+// named after the magazine, such as "build/magazines/Your Computer/". Every magazine's tables cover the same quarters.
+
+// The subdirectory of -out-dir holding the per-magazine output
+const by_magazine_dir = "magazines"
+
+// Return the magazines that the dataset's adverts come from, in alphabetical order
+func datasetMagazines(dataset *hcp.Dataset) []string {
+	magazines := make([]string, 0)
+	for _, advert := range dataset.Adverts {
+		if !sliceContainsString(magazines, advert.Magazine) {
+			magazines = append(magazines, advert.Magazine)
+		}
+	}
+	sort.Strings(magazines)
+	return magazines
+}
+
+// Given the dataset and the table built from all of it, write the output in each format for each magazine to outputDir
+func writeMagazineTables(dataset *hcp.Dataset, options tableOptions, table priceTable, formats []string, renderers map[string]outputFormat, outputDir string) {
+	for _, magazine := range datasetMagazines(dataset) {
+		subset := dataset.Subset(func(advert hcp.Advert) bool {
+			return advert.Magazine == magazine
+		})
+		variant := newPriceTable(subset, options)
+		variant.attribution, variant.stamp, variant.language, variant.lifespans, variant.placeholders = table.attribution, table.stamp, table.language, table.lifespans, table.placeholders
+
+		dir := filepath.Join(outputDir, by_magazine_dir, strings.ReplaceAll(magazine, "/", "%2F"))
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			log.Fatalf("Cannot create '%s': %s\n", dir, err.Error())
+		}
+		for _, name := range formats {
+			writeOutput(filepath.Join(dir, out_dir_basename+renderers[name].extension), func(w io.Writer) {
+				renderers[name].render(w, variant)
+			})
+		}
+	}
+}
@@ -1,43 +1,104 @@
 package main
 
 import (
-	"encoding/csv"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"path/filepath"
 	"sort"
-	"strconv"
 	"strings"
-)
 
-// These constants represent the offset of the items in each advert read from the CSV file
-const ( // iota is reset to 0
-	adv_magazine = 0 //
-	adv_yyyy_mm  = 1 //
-	adv_page_num = 2 //
-	adv_system   = 3 //
-	adv_price    = 4 //
-	adv_blank_1  = 5 //
-	adv_kit      = 6 //
-	adv_board    = 7 //
+	"github.com/AntonioCarlini/home-computer-prices/hcp"
 )
 
-const max_price = 100_000 // Maximum price allowed: anything higher than this is likely to be an error in the data
-const max_page_num = 500  // Maximum magazine page number: anything higher than this is likely to be an error in the data
-const min_year = 1945     // Earliest acceptable year
-const max_year = 2099     // Latest acceptable year
-
-type advertInfo struct {
-	row      int
-	magazine string // Magazine Title
-	year     int    // Year (1945..current)
-	month    int    // Month (1..12)
-	page     int    // page number
-	system   string // Computer system name
-	price    int    // Price in pounds, including VAT
-	kit      string // TODO: True if the system had to be assembled
-	board    string // TODO: True if the system was a system board
+// The per-system price data handed to an output renderer
+type priceTable struct {
+	systems       map[string][]int          // Price in pence for each system, indexed by (date-index - minDate)
+	kinds         map[string][]priceKind    // How each price was arrived at, indexed as for systems
+	observations  map[string][][]hcp.Advert // The adverts behind each observed price, indexed as for systems
+	keys          []string                  // System names in the order they are to be output
+	minDate       int                       // Date-index of the first quarter
+	maxDate       int                       // Date-index of the last quarter
+	rounding      string                    // How prices are published; one of the priceRoundings
+	stamp         string                    // If not empty, metadata describing how the output was generated
+	attribution   hcp.Attribution           // Credit and licence appended to the output
+	language      *hcp.Language             // If set, the language the wiki tables are written in; otherwise English
+	minSources    int                       // If more than 1, prices seen in fewer independent sources than this were withheld
+	markSingle    bool                      // If set, prices taken from a single advert are marked with single_advert_marker
+	showCurrency  bool                      // If set, prices converted from other currencies are followed by the currencies' symbols
+	cellFormat    string                    // One of the cellFormats
+	lifespans     map[string]hcp.Lifespan   // When each system was on sale, from the configuration; other quarters are shaded
+	placeholders  hcp.Placeholders          // What the tables show in a quarter without a price, from the configuration
+	totals        bool                      // If set, each wiki table ends with rows of the systems priced and their median price (see totals.go)
+	noteOverrides map[string]string         // The output format's replacements for the English text of the notes (see legendWith)
+	trimQuarters  bool                      // If set, each table leaves out the empty quarters at either end (see trim.go)
+	groupColumns  int                       // If more than 0, the years are grouped into tables adaptively, aiming for this many quarters with prices in each (see grouping.go)
+}
+
+// An outputRenderer writes the per-system price data in one particular output format
+type outputRenderer func(w io.Writer, table priceTable)
+
+// An outputFormat is a renderer along with the file extension used when its output is written to -out-dir
+type outputFormat struct {
+	render    outputRenderer
+	extension string
+}
+
+// The output formats that may be selected with -format
+var outputFormats = map[string]outputFormat{
+	"wiki":       {outputWikidata, ".wiki"},
+	"jsonld":     {outputJSONLD, ".jsonld"},
+	"lua":        {outputLua, ".lua"},
+	"template":   {outputTemplates, ".txt"},
+	"archive":    {outputArchive, ".html"},
+	"markdown":   {outputMarkdown, ".md"},
+	"html":       {outputHTMLTables, ".tables.html"},
+	"json":       {outputPricesJSON, ".json"},
+	"csv":        {outputPricesCSV, ".csv"},
+	"xlsx":       {outputXLSX, ".xlsx"},
+	"latex":      {outputLaTeX, ".tex"},
+	"dokuwiki":   {outputDokuWiki, ".dokuwiki"},
+	"confluence": {outputConfluence, ".confluence.xhtml"},
+}
+
+// The output formats that are not text, which are never written to standard output, where the diagnostics go too
+var binary_formats = []string{"xlsx"}
+
+// The name, without extension, of each file written to -out-dir
+const out_dir_basename = "home-computer-prices"
+
+// Set when built for WebAssembly, in which case it runs instead of the command line (see wasm.go)
+var wasmMain func()
+
+// Subcommands are selected by the first argument; anything else is treated as a table generation run
+var subcommands = map[string]func(args []string){
+	"lint-config":          runLintConfig,
+	"volume":               runVolumeReport,
+	"advert-chart":         runAdvertChart,
+	"lineage-chart":        runLineageChart,
+	"seasonal":             runSeasonalReport,
+	"affordability":        runAffordabilityReport,
+	"publish":              runPublish,
+	"preview-diff":         runPreviewDiff,
+	"grpc-serve":           runGRPCServer,
+	"serve":                runServer,
+	"import":               runImport,
+	"import-wiki":          runImportWiki,
+	"explain":              runExplain,
+	"compare-aggregations": runCompareAggregations,
+	"quarter":              runQuarterReport,
+	"freshness":            runFreshnessReport,
+	"normalize":            runNormalize,
+	"migrate":              runMigrate,
+	"reconcile":            runReconcile,
+	"sample":               runSample,
+	"contributors":         runContributorReport,
+	"badge":                runBadge,
+	"rollback":             runRollback,
+	"checksum":             runChecksum,
+	"lows":                 runRecordLows,
 }
 
 // Takes a CSV file representing home computer prices taken from adverts and
@@ -45,390 +106,536 @@ type advertInfo struct {
 //
 // The data is grouped by quarter in half decades in each table.
 // Systems are listd alphabetically; only systems with at least one valid data point in that table are included.
+// The CSV dialect (delimiter, byte order mark and quoting) is detected from each input; -csv-delimiter overrides the delimiter.
+// Each input may also be gzip-compressed (".csv.gz") or a zip archive of CSV files (".zip"), as contributors exchange them.
+//
+// The -format option selects the output format: "wiki" (the default) produces MediaWiki tables,
+// "jsonld" produces schema.org JSON-LD for embedding in HTML pages, "lua" produces a Scribunto data module and
+// "template" produces calls to wiki templates that take care of the presentation.
+// "markdown" produces the same tables as GitHub-flavoured Markdown, for documentation and wikis without MediaWiki markup (see markdown.go).
+// "html" produces the same tables as a standalone HTML page, for previewing them in a browser or publishing them on a web host (see htmltables.go).
+// "json" produces the aggregated prices as JSON, with the advert behind each, for other tooling to consume (see pricesjson.go).
+// "csv" produces the same prices as CSV, a row per system and quarter, for loading into a spreadsheet (see pricescsv.go).
+// "xlsx" produces the same tables as an Excel workbook, a worksheet per group of years with its headings frozen (see xlsx.go).
+// "latex" produces the same tables as LaTeX in the style of the booktabs package, for print publication (see latex.go).
+// "dokuwiki" produces the same tables in DokuWiki syntax, for wikis that run it rather than MediaWiki (see dokuwiki.go).
+// "confluence" produces the same tables in the storage format of Atlassian Confluence, for pasting or uploading into its pages (see confluence.go).
+// "archive" produces a single self-contained HTML page, with charts and a search box, for browsing the prices offline;
+// -system-pages adds a page per system, with its chart, prices and adverts (see systempages.go).
+// Progress is shown on standard error when it is a terminal, unless -no-progress is given.
+// Diagnostics go to standard output unless -log-file names a file for them; -log-format json makes them machine-readable.
+// The -stamp option adds a note of how and when the output was generated, as a comment where the format allows.
+// The -o option writes the output to a file rather than mixing it with the diagnostics on standard output.
+// Any other format is produced by a plugin, an executable called hcp-to-wiki-FORMAT on the PATH (see pluginDocument).
+// Several formats may be produced from one run, e.g. "-format wiki,jsonld -out-dir build/", each to its own file in the directory.
+// The -subpages option splits the wiki output into a subpage per five-year group plus an index page, written to the -o (or -out-dir) directory;
+// "publish" uploads such pages to a wiki.
+// The -archive option packs every file written into one zip archive, for attaching to a release of the dataset.
+// The -audit-csv option writes the adverts behind every published price, for checking where a number came from.
+// The -sqlite option writes the adverts and the published prices to a SQLite database with indexes, for SQL queries
+// that need not parse the CSV data again, in the form that serve offers for download and can answer from (see download.go).
+// The -quarantine-csv option writes the rows rejected for a bad date to a CSV file, as a work queue of rows to fix.
+// The -config option names a JSON file of rename/suppress rules (see configuration); "lint-config" checks such a file.
+// The -mark-single-source option marks each price that was taken from a single advert.
+// The -cell-format min-median option shows both the lowest and the median advert price for each quarter, a fairer picture than the lowest alone.
+// The -min-sources option withholds prices seen in fewer than that many magazines or issues, marking their cells instead.
+// The -by-magazine option also writes the tables built from each magazine's adverts alone, to compare how titles priced systems.
+// The -languages option also writes the wiki tables in other languages described by the configuration, for sister wikis.
+// The -issue option reads only the rows for one issue of a magazine, to validate a newly transcribed issue on its own (see issue.go).
+// The -group-columns option groups the years into tables by the density of the data rather than five at a time (see grouping.go).
+// The -trim-quarters option leaves out of each table the empty quarters before its first price and after its last.
+// The -totals option ends each wiki table with the number of systems priced and their median price in each quarter.
+// The -no-preprocess option ignores the configuration's rename and suppress rules, to show the data as it was transcribed.
+// The -webhook option posts a summary of validation problems to a Slack, Discord or Matrix webhook (see webhook.go).
 
 func main() {
 
-	flag.Parse()
-
-	inputs := flag.Args()
-	if len(inputs) != 1 {
-		log.Fatalf("Exactly 1 arguments required but %d supplied\n", len(inputs))
+	if wasmMain != nil {
+		wasmMain()
+		return
 	}
-
-	entryFilename := flag.Arg(0)
-
-	data := readCSV(entryFilename)
-
-	// Massage the original CSV data into an array of advertInfo data
-	adverts, minDate, maxDate := parseData(data)
-
-	// Build a collection of prices for each system
-	systems := buildBySystem(adverts, minDate, maxDate)
-
-	systems = preprocessSystemData(systems)
-
-	// Build array of keys (system names) in alphabetical order
-	keys := make([]string, 0, len(systems))
-	for key, _ := range systems {
-		keys = append(keys, key)
+	if len(os.Args) > 1 {
+		if subcommand, ok := subcommands[os.Args[1]]; ok {
+			subcommand(os.Args[2:])
+			return
+		}
 	}
-	sort.Strings(keys)
 
-	for _, key := range keys {
-		fmt.Printf("%-40.40s: %v\n", key, systems[key])
+	format := flag.String("format", "wiki", "output format: wiki, jsonld, lua, template, archive, markdown, html, json, csv, xlsx, latex, dokuwiki or confluence; several may be given, separated by commas, with -out-dir")
+	aggregation := flag.String("aggregate", "min", "how each quarter's price is chosen from its adverts: min, mode or median")
+	interpolate := flag.Bool("interpolate", false, "fill single-quarter gaps with estimates interpolated from the quarters either side")
+	carry := flag.Bool("carry-forward", false, "repeat the previous quarter's price into empty quarters while a system was still being advertised")
+	rounding := flag.String("price-rounding", "trunc", "how prices are published: trunc, round, ceil or exact (show pennies)")
+	minSources := flag.Int("min-sources", 0, "withhold, and mark, prices that were seen in fewer than this many independent sources")
+	sourcesBy := flag.String("sources-by", "issue", "how independent sources are counted for -min-sources: magazine or issue")
+	markSingle := flag.Bool("mark-single-source", false, "mark prices taken from a single advert, with a note explaining the mark")
+	showCurrency := flag.Bool("show-currency", false, "follow each price converted into pounds with the symbols of the currencies its adverts were quoted in, such as [$], for tables mixing UK and US adverts")
+	cellFormat := flag.String("cell-format", "price", "what the wiki and archive tables show for each quarter: price, or min-median for the lowest and the median advert price")
+	outputFilename := flag.String("o", "", "write the generated output to this file instead of standard output")
+	outputDir := flag.String("out-dir", "", "write the output for each format to a file in this directory, named after the format")
+	subpageBase := flag.String("subpages", "", "write each five-year group to its own subpage of this page title, plus an index page that transcludes them, as files in the -o or -out-dir directory")
+	stamp := flag.Bool("stamp", false, "add the tool version, a hash of the input data, the row counts and the date to the output")
+	matrixFilename := flag.String("matrix-csv", "", "also write the published prices to this file as a CSV matrix of systems by quarters")
+	archiveFilename := flag.String("archive", "", "also pack every file written into this zip archive, for attaching to a release")
+	auditFilename := flag.String("audit-csv", "", "also write the adverts behind every published price to this CSV file")
+	sqliteFilename := flag.String("sqlite", "", "also write the adverts and the prices published from them to this SQLite database")
+	configFilename := flag.String("config", "", "JSON file of rules applied to the data (default: the built-in rules)")
+	delimiterName := flag.String("csv-delimiter", "auto", "the delimiter between CSV fields: auto to detect it, tab, or a single character such as ;")
+	quarantineFilename := flag.String("quarantine-csv", "", "write the rows rejected for a bad date to this CSV file, with what was wrong with each")
+	reportFilename := flag.String("validation-report", "", "write a per-file validation summary to this JSON file")
+	baselineFilename := flag.String("validation-baseline", "", "fail if any file has more validation problems than in this earlier validation report")
+	systemPages := flag.Bool("system-pages", false, "with the archive format, also write a page per system, with its chart, prices and adverts, to the systems subdirectory of -out-dir")
+	byMagazine := flag.Bool("by-magazine", false, "also write the tables built from each magazine's adverts alone, each to its own subdirectory of -out-dir")
+	languages := flag.String("languages", "", "also write the wiki tables in these languages of the configuration, separated by commas, each to its own subdirectory of -out-dir")
+	webhookURL := flag.String("webhook", "", "after the run, post a summary of validation regressions or large data changes to this chat webhook URL")
+	webhookFormat := flag.String("webhook-format", "slack", "the kind of chat webhook: slack, discord or matrix")
+	webhookChange := flag.Int("webhook-change", 10, "with -webhook and -validation-baseline, report files whose accepted rows changed by more than this percentage")
+	logFilename, logFormat := addLoggingFlags(flag.CommandLine)
+	newProgress := addProgressFlag(flag.CommandLine)
+	newIssue := addIssueFlag(flag.CommandLine, "read only the rows for this issue, such as \"Your Computer 1983-11\", to check a newly transcribed issue")
+	totals := flag.Bool("totals", false, "end each wiki table with rows giving the number of systems priced and their median price in each quarter")
+	groupColumns := flag.Int("group-columns", 0, "group the years into tables by the density of the data, aiming for this many quarters with prices in each, instead of five years to a table")
+	trimQuarters := flag.Bool("trim-quarters", false, "leave out of each table the quarters before the first price in it and after the last")
+	noPreprocess := flag.Bool("no-preprocess", false, "ignore the configuration's rename and suppress rules, publishing every system under its name in the data")
+	maxPriceJump := flag.Int("max-price-jump", 0, "warn about prices more than this percentage away from the nearest price in adjacent quarters (0 disables the check)")
+	flag.Parse()
+	setupLogging(*logFilename, *logFormat)
+	progress = newProgress()
+	issueFilter = newIssue()
+
+	formats := strings.Split(*format, ",")
+	renderers := make(map[string]outputFormat, len(formats))
+	for _, name := range formats {
+		renderer, ok := lookupFormat(name)
+		if !ok {
+			log.Fatalf("Unknown output format '%s'\n", name)
+		}
+		renderers[name] = renderer
 	}
-
-	// Output the final wiki format data
-	outputWikidata(systems, keys, minDate, maxDate)
-}
-
-// Read data from a CSV file
-// Each row of data is represented as an array
-func readCSV(filename string) [][]string {
-	f, err := os.Open(filename)
+	if (len(formats) > 1) && (*outputDir == "") {
+		log.Fatalf("Several output formats need -out-dir\n")
+	}
+	for _, name := range formats {
+		if sliceContainsString(binary_formats, name) && (*outputFilename == "") && (*outputDir == "") {
+			log.Fatalf("The %s format is binary, so needs -o or -out-dir\n", name)
+		}
+	}
+	if (*outputDir != "") && (*outputFilename != "") {
+		log.Fatalf("-o and -out-dir cannot be used together\n")
+	}
+	if (*subpageBase != "") && !sliceContainsString(formats, "wiki") {
+		log.Fatalf("-subpages needs the wiki format, not '%s'\n", *format)
+	}
+	if (*languages != "") && ((*outputDir == "") || !sliceContainsString(formats, "wiki")) {
+		log.Fatalf("-languages needs -out-dir and the wiki format\n")
+	}
+	if (*archiveFilename != "") && (*outputDir == "") && (*outputFilename == "") {
+		log.Fatalf("-archive needs -o or -out-dir\n")
+	}
+	if *systemPages && ((*outputDir == "") || !sliceContainsString(formats, "archive")) {
+		log.Fatalf("-system-pages needs -out-dir and the archive format\n")
+	}
+	if *byMagazine && (*outputDir == "") {
+		log.Fatalf("-by-magazine needs -out-dir\n")
+	}
+	if !sliceContainsString(hcp.SourceCountings, *sourcesBy) {
+		log.Fatalf("Unknown source counting '%s'\n", *sourcesBy)
+	}
+	if _, ok := hcp.PriceAggregations[*aggregation]; !ok {
+		log.Fatalf("Unknown aggregation '%s'\n", *aggregation)
+	}
+	if _, ok := priceRoundings[*rounding]; !ok {
+		log.Fatalf("Unknown price rounding '%s'\n", *rounding)
+	}
+	if !sliceContainsString(cellFormats, *cellFormat) {
+		log.Fatalf("Unknown cell format '%s'\n", *cellFormat)
+	}
+	delimiter, err := hcp.ParseDelimiter(*delimiterName)
 	if err != nil {
-		log.Fatalf("Cannot open '%s': %s\n", filename, err.Error())
+		log.Fatalf("Cannot read CSV: %s\n", err.Error())
+	}
+	if _, ok := webhookStyles[*webhookFormat]; !ok {
+		log.Fatalf("Unknown webhook format '%s'\n", *webhookFormat)
 	}
-	defer f.Close()
 
-	r := csv.NewReader(f)
+	inputs := flag.Args()
+	if len(inputs) < 1 {
+		log.Fatalf("At least 1 argument required but %d supplied\n", len(inputs))
+	}
 
-	transactions, err := r.ReadAll()
+	config, err := hcp.LoadConfiguration(*configFilename)
 	if err != nil {
-		log.Fatalln("Cannot read CSV data:", err.Error())
+		log.Fatalf("Cannot load configuration: %s\n", err.Error())
 	}
-
-	return transactions
-}
-
-// Parse the CSV data.
-// Skip everything until the header line (with "Source" in the first column) is seen.
-// Ignore empty lines.
-// Perform some integrity checks on the data.
-// Build up an array of advertInfo containing the data that passes validation.
-//
-// Return the data and also the minimum and maximum date-indices seen when processing the data.
-func parseData(data [][]string) (adverts []advertInfo, minDate int, maxDate int) {
-	minDate = (max_year + 1) * 4
-	maxDate = -1
-	adverts = make([]advertInfo, 0)
-
-	searching_for_header := true
-	for i, row := range data {
-		csvRowIndex := i + 1
-		valid := true
-
-		// Skip all data until a row with a suitable header line is seen
-		if searching_for_header {
-			if row[adv_magazine] == "Source" {
-				searching_for_header = false
-			}
-			continue
+	for _, problem := range lintConfiguration(config, nil) {
+		if problem.isError {
+			log.Fatalf("Configuration %s\n", problem)
 		}
+	}
+	if *noPreprocess {
+		config = config.WithoutPreprocessing()
+	}
 
-		// Make sure the system name has no leading or trailing spaces
-		system := strings.TrimSpace(row[adv_system])
-
-		// Entirely empty lines must be ignored. As an approximation, ignore any line without a system title, as that cannot contain meaningful data.
-		if len(system) == 0 {
-			continue
+	// Massage the original CSV data into an array of adverts, then pick the price to publish for each system and quarter
+	showParsing := func(filesDone int, files int, rows int) {
+		progress.update("Parsing", filesDone, files, fmt.Sprintf("files, %d rows", rows))
+	}
+	dataset, err := hcp.LoadFiles(inputs, hcp.Options{Config: &config, Aggregation: *aggregation, Progress: showParsing, Delimiter: delimiter, Issue: issueFilter})
+	if err != nil {
+		log.Fatalf("Cannot load adverts: %s\n", err.Error())
+	}
+	validations := dataset.Validations
+	printRowProblems(validations)
+	checkIssueRows(validations)
+	if *maxPriceJump > 0 {
+		for _, jump := range hcp.CheckPriceJumps(dataset.Adverts, *maxPriceJump, validations) {
+			logf("%s line %d: Implausible price for %s: £%s is a %d%% jump from the nearest price of £%s\n", jump.Advert.File, jump.Advert.Row, jump.Advert.System, formatPrice(jump.Advert.Price, "exact"), jump.Percent, formatPrice(jump.Nearest, "exact"))
 		}
+	}
 
-		// The YYYY-DD field must be of the correct format DD must be 01..12 and YYYY must be greater than 1945 but less than 2099
-		year, month, err := handle_yyyy_mm(row[adv_yyyy_mm])
-		if err != nil {
-			valid = false
-			fmt.Printf("Line %d: Bad YYYY-DD [%s] (%s) in [%v]\n", csvRowIndex, row[adv_yyyy_mm], err, row)
+	printValidationSummary(validations)
+	if *quarantineFilename != "" {
+		writeOutput(*quarantineFilename, func(w io.Writer) {
+			writeQuarantineCSV(w, validations)
+		})
+	}
+	if *reportFilename != "" {
+		if err := writeValidationReport(*reportFilename, validations); err != nil {
+			log.Fatalf("Cannot write validation report: %s\n", err.Error())
 		}
-
-		// The page format must be pN{1,5}}, so at least one N but no more than 5.
-		// Note that the page number does not influence the final output, so "valid" is not adjusted and the data may be used
-		page, err := handle_page_number(row[adv_page_num])
+		writtenFiles = append(writtenFiles, *reportFilename)
+	}
+	regressed := make([]string, 0)
+	notices := make([]string, 0)
+	if *baselineFilename != "" {
+		baseline, err := readValidationReport(*baselineFilename)
 		if err != nil {
-			fmt.Printf("Line %d: Bad page number [%s] (%s) in [%v]\n", csvRowIndex, row[adv_page_num], err, row)
+			log.Fatalf("Cannot read validation baseline: %s\n", err.Error())
 		}
-
-		// The price must be in pounds, must be an integer and must be less than £100,000
-		// The CSV will be encoded as UTF-8 and the "£" symbol will have to be checked as UTF-8
-		price, err := handle_price(row[adv_price])
-		if err != nil {
-			valid = false
-			fmt.Printf("Line %d: Bad price [%s] (%s) in [%v]\n", csvRowIndex, row[adv_price], err, row)
+		for _, regression := range findRegressions(validations, baseline) {
+			regressed = append(regressed, regression.after.Filename)
+			notices = append(notices, regression.String())
 		}
+		notices = append(notices, findDataChanges(validations, baseline, *webhookChange)...)
+	} else {
+		for _, validation := range validations {
+			if validation.Rejected > 0 {
+				notices = append(notices, fmt.Sprintf("%s: %d of %d rows rejected", validation.Filename, validation.Rejected, validation.Rows))
+			}
+		}
+	}
+	if (*webhookURL != "") && (len(notices) > 0) {
+		if err := postWebhook(*webhookURL, *webhookFormat, webhookMessage(inputs, notices)); err != nil {
+			logf("Cannot post to webhook: %s\n", err.Error())
+		}
+	}
+	if len(regressed) > 0 {
+		log.Fatalf("Validation regressed in: %s\n", strings.Join(regressed, ", "))
+	}
 
-		// TODO
-		//  The kit field must be Y, N, ? or blank
+	logPreprocessing(dataset.Preprocessing())
+	options := tableOptions{*interpolate, *carry, *rounding, *minSources, *sourcesBy, *markSingle, *cellFormat, *showCurrency}
+	table := newPriceTable(dataset, options)
+	table.attribution = config.Attribution
+	table.lifespans = config.Lifespans
+	table.placeholders = config.Placeholders
+	table.totals = *totals
+	table.groupColumns = *groupColumns
+	table.trimQuarters = *trimQuarters
+	for _, key := range table.keys {
+		logf("%-40.40s: %v\n", key, table.systems[key])
+	}
 
-		if !valid {
-			continue
+	// Output the final data in each requested format
+	if *stamp {
+		table.stamp = generationStamp(inputs, validations)
+	}
+	if *outputDir != "" {
+		if err := os.MkdirAll(*outputDir, 0755); err != nil {
+			log.Fatalf("Cannot create '%s': %s\n", *outputDir, err.Error())
 		}
-
-		advert := advertInfo{csvRowIndex, row[adv_magazine], year, month, page, system, price, row[adv_kit], row[adv_board]}
-		adverts = append(adverts, advert)
-		dateIndex := buildIndexFromAdvertInfo(advert)
-		if dateIndex < minDate {
-			minDate = dateIndex
+	}
+	for _, name := range formats {
+		filename := *outputFilename
+		if *outputDir != "" {
+			filename = filepath.Join(*outputDir, out_dir_basename+renderers[name].extension)
 		}
-		if dateIndex > maxDate {
-			maxDate = dateIndex
+		if (name == "wiki") && (*subpageBase != "") {
+			// The subpages are written to the directory, in place of the single wiki page
+			pagesDir := *outputFilename
+			if *outputDir != "" {
+				pagesDir = *outputDir
+			}
+			writePages(pagesDir, wikiSubpages(table, *subpageBase))
+			continue
 		}
+		writeOutput(filename, func(w io.Writer) {
+			renderers[name].render(w, table)
+		})
 	}
-
-	return adverts, minDate, maxDate
-}
-
-// Given advert data for a range of systems, outputs that data in a form suitable for including in a wiki page
-func outputWikidata(systems map[string][]int, keys []string, minDate int, maxDate int) {
-	// Loop through quarters in groups of five years.
-	// Take the lowest year and make the starting point either YYY0 or YYY5
-	// Process data for that group
-	// Move on five years and repeat until the start point exceeds the maxDate
-	minYear, _ := decodeIndexByQuarter(minDate)
-	maxYear, _ := decodeIndexByQuarter(maxDate)
-	startYear := (minYear / 5) * 5
-	const groupYearsBy = 5
-	for groupYear := startYear; groupYear <= maxYear; groupYear = groupYear + groupYearsBy {
-		fmt.Printf("== %d - %d ==\n\n", groupYear, groupYear+groupYearsBy-1)
-		fmt.Printf("{| class=\"wikitable\"\n")
-		fmt.Printf("|-\n")
-		fmt.Printf("!  || colspan=\"4\" | %d || colspan=\"4\" | %d || colspan=\"4\" | %d || colspan=\"4\" | %d || colspan=\"4\" | %d\n", groupYear, groupYear+1, groupYear+2, groupYear+3, groupYear+4)
-		fmt.Printf("|-\n")
-		fmt.Println(" ! style=\"width: 10%;\" | System ")
-		fmt.Printf(" ! JAN-MAR || APR-JUN || JUL-SEP || OCT-DEC || JAN-MAR || APR-JUN || JUL-SEP || OCT-DEC || JAN-MAR || APR-JUN || JUL-SEP || OCT-DEC || JAN-MAR || APR-JUN || JUL-SEP || OCT-DEC || JAN-MAR || APR-JUN || JUL-SEP || OCT-DEC\n")
-		for _, key := range keys {
-			// Pick up the prices for this system:
-			prices := systems[key]
-			// Ignore this system if it has no price data in the relevant time period
-			if !systemHasPriceData(groupYear, groupYear+groupYearsBy-1, minDate, maxDate, prices) {
+	if *languages != "" {
+		tables, err := languageTables(table, config, strings.Split(*languages, ","))
+		if err != nil {
+			log.Fatalf("Cannot write languages: %s\n", err.Error())
+		}
+		for name, variant := range tables {
+			dir := filepath.Join(*outputDir, name)
+			if *subpageBase != "" {
+				writePages(dir, wikiSubpages(variant, *subpageBase))
 				continue
 			}
-
-			fmt.Printf("|-\n| %s", key)
-			for currentYear := groupYear; currentYear < groupYear+groupYearsBy; currentYear++ {
-				for currentQuarter := 1; currentQuarter <= 4; currentQuarter++ {
-					currentIndex := buildIndexFromYearAndQuarter(currentYear, currentQuarter)
-					// fmt.Printf("Processing date %dQ%d  index=%d\n", currentYear, currentQuarter, currentIndex)
-					// for this index, find data and display
-					if currentQuarter == 1 {
-						fmt.Printf("\n     | ")
-					} else {
-						fmt.Printf("|| ")
-					}
-					if (currentIndex < minDate) || (currentIndex > maxDate) || (prices[currentIndex-minDate] <= 0) {
-						fmt.Printf("style=\"text-align: center;\" | &mdash; ")
-					} else {
-						fmt.Printf("style=\"text-align: right;\"  | £%-4d   ", prices[currentIndex-minDate])
-					}
-				}
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				log.Fatalf("Cannot create '%s': %s\n", dir, err.Error())
+			}
+			writeOutput(filepath.Join(dir, out_dir_basename+".wiki"), func(w io.Writer) {
+				outputWikidata(w, variant)
+			})
+		}
+	}
+	if *systemPages {
+		writeSystemPages(table, *outputDir)
+	}
+	if *byMagazine {
+		writeMagazineTables(dataset, options, table, formats, renderers, *outputDir)
+	}
+	progress.finish()
+	if *matrixFilename != "" {
+		writeOutput(*matrixFilename, func(w io.Writer) {
+			writeMatrixCSV(w, table)
+		})
+	}
+	if *auditFilename != "" {
+		writeOutput(*auditFilename, func(w io.Writer) {
+			writeAuditCSV(w, table)
+		})
+	}
+	if *sqliteFilename != "" {
+		writeOutput(*sqliteFilename, func(w io.Writer) {
+			if err := writeDatasetSQLite(w, dataset); err != nil {
+				log.Fatalf("Cannot write database: %s\n", err.Error())
 			}
-			fmt.Println("")
+		})
+	}
+	if *archiveFilename != "" {
+		if err := writeArchive(*archiveFilename, writtenFiles, *outputDir); err != nil {
+			log.Fatalf("Cannot write archive '%s': %s\n", *archiveFilename, err.Error())
 		}
-		fmt.Printf("|}\n\n") // Close the "wikitable"
 	}
 }
 
-// Process a date of the form "YYYY-MM".
-// return an error if:
-//  o the string does not conform to the pattern NNNN-NN, where N is a numeral
-//  o the year is not (inclusively) between min_year and max_year constants
-//  o the month is not from 1 to 12
-// Otherwise return the year and month as integers.
-//
-// TODO: make the upper limit for YYYY the current year
-func handle_yyyy_mm(yyyy_mm string) (year int, month int, err error) {
-	year = -1
-	month = -1
-	var local_err error
-
-	date_sep := yyyy_mm[4:5]
-	if date_sep != "-" {
-		local_err = fmt.Errorf("bad YYYY-MM separator [%s] from [%s]", date_sep, yyyy_mm)
-	} else if len(yyyy_mm) != 7 {
-		local_err = fmt.Errorf("bad YYYY-MM: length invalid: [%s]", yyyy_mm)
-	}
-	year_text := yyyy_mm[0:4]
-	year, err = strconv.Atoi(year_text)
-	if err != nil {
-		local_err = fmt.Errorf("bad Year digits [%s] (%w)", year_text, err)
-	} else if (year < min_year) || (year > max_year) {
-		local_err = fmt.Errorf("bad Year  [%d] outside range %d-%d", year, min_year, max_year)
+// How the price data handed to the output renderers is built from a dataset
+type tableOptions struct {
+	interpolate  bool   // Fill single-quarter gaps with interpolated estimates
+	carry        bool   // Carry prices forward into empty quarters
+	rounding     string // One of the priceRoundings
+	minSources   int    // Withhold prices seen in fewer independent sources than this (0 or 1 publishes every price)
+	sourcesBy    string // How independent sources are counted; one of hcp.SourceCountings
+	markSingle   bool   // Mark prices taken from a single advert
+	cellFormat   string // One of the cellFormats; "" means "price"
+	showCurrency bool   // Show the currencies that converted prices were quoted in
+}
+
+// Given a dataset, build the price data handed to the output renderers, withholding thinly-attested prices and
+// filling gaps with estimates if asked to
+func newPriceTable(dataset *hcp.Dataset, options tableOptions) priceTable {
+	systems := dataset.Prices()
+	kinds := newPriceKinds(systems)
+	observations := dataset.Observations()
+	if options.minSources > 1 {
+		withholdThinPrices(systems, kinds, observations, options.minSources, options.sourcesBy)
 	}
-	month_text := yyyy_mm[5:]
-	month, err = strconv.Atoi(month_text)
-	if err != nil {
-		local_err = fmt.Errorf("bad Month digits [%s]", month_text)
-	} else if (month < 1) || (month > 12) {
-		local_err = fmt.Errorf("bad Month [%d]", month)
+	if options.interpolate {
+		interpolateGaps(systems, kinds)
+	}
+	if options.carry {
+		carryForward(systems, kinds)
 	}
-	return year, month, local_err
-}
 
-// Process a page number of the form "pNNNN".
-// return an error if:
-// Otherwise return the page number as an integer.
-//
-// TODO: allow for roman numberals: e.g. pii
+	// Build array of keys (system names) in alphabetical order
+	keys := sortedKeys(systems)
 
-func handle_page_number(page_num_text string) (page int, err error) {
-	page = -1000
-	var local_err error
+	return priceTable{systems: systems, kinds: kinds, observations: observations, keys: keys, minDate: dataset.MinDate, maxDate: dataset.MaxDate, rounding: options.rounding, minSources: options.minSources, markSingle: options.markSingle, cellFormat: options.cellFormat, showCurrency: options.showCurrency}
+}
 
-	if len(page_num_text) < 2 {
-		local_err = fmt.Errorf("bad page number text [%s]", page_num_text)
-	} else {
-		if page_num_text[0] != 'p' {
-			local_err = fmt.Errorf("bad page number format [%s]", page_num_text)
-		} else {
-			page, err = strconv.Atoi(page_num_text[1:])
-			if err != nil {
-				local_err = fmt.Errorf("bad page number data [%s] (%w)", page_num_text, err)
-			}
-			if (page < 0) || (page > max_page_num) {
-				local_err = fmt.Errorf("bad page number value [%d]", page)
-			}
-		}
+// Call write to produce output, either on standard output or, if a filename is given, in that file
+func writeOutput(filename string, write func(w io.Writer)) {
+	if filename == "" {
+		write(os.Stdout)
+		return
+	}
+	f, err := os.Create(filename)
+	if err != nil {
+		log.Fatalf("Cannot create '%s': %s\n", filename, err.Error())
 	}
-	return page, local_err
+	write(f)
+	if err := f.Close(); err != nil {
+		log.Fatalf("Cannot write '%s': %s\n", filename, err.Error())
+	}
+	writtenFiles = append(writtenFiles, filename)
 }
 
-// Process a price of the form "£NNNN".
-// return an error if:
-// Otherwise return the price as an integer.
-//
-// TODO: allow for roman numberals: e.g. pii
-
-func handle_price(price_text string) (price int, err error) {
-	price = -1
-	var local_err error
-
-	// The price must be in pounds, must be an integer and must be less than £100,000
-	// The CSV will be encoded as UTF-8 and the "£" symbol will have to be checked as UTF-8
-	price_currency := []rune(price_text)[0]
-	price_value := string([]rune(price_text)[1:])          // Remove first character, allowing for possibility that it is UTF-8
-	price_value = strings.SplitN(price_value, ".", 2)[0]   // Remove everything after a decimal point
-	price_value = strings.ReplaceAll(price_value, ",", "") // Remove all commas
-	if price_currency != []rune("£")[0] {
-		local_err = fmt.Errorf("bad Price Currency [%c] from [%s]", price_currency, price_text)
-	} else {
-		possible_price, err := strconv.Atoi(price_value)
-		if err != nil {
-			local_err = fmt.Errorf("bad Price Data [%s]", price_value)
-		} else if possible_price > max_price {
-			local_err = fmt.Errorf("unlikely Price Data [%s] (greater than %d)", price_text, max_price)
-		} else {
-			price = possible_price
-		}
+// Read the named CSV files, printing any problems found in their rows; a file that cannot be read is fatal.
+// Only the rows for the issue chosen with -issue are read, if there is one.
+func loadAdverts(filenames []string) (adverts []hcp.Advert, minDate int, maxDate int, validations []hcp.FileValidation) {
+	adverts, minDate, maxDate, validations, err := hcp.ReadIssueAdverts(filenames, issueFilter)
+	if err != nil {
+		log.Fatalf("Cannot load adverts: %s\n", err.Error())
 	}
-	return price, local_err
+	printRowProblems(validations)
+	checkIssueRows(validations)
+	return adverts, minDate, maxDate, validations
 }
 
-// Process the advertInfo array to produce
-// Take current entry
-// is there a map for that "index"?
-// If not, create and populate
-// If there is, find this system and replace only iff new price is lower
-// byDate map is index=>systemsMap  map[int]
-// systemsMap is system=>advtertInfo map[string]advertInfo
-func buildByDate(adverts []advertInfo) map[int]map[string]advertInfo {
-	byDate := make(map[int]map[string]advertInfo)
-	for _, advert := range adverts {
-		// fmt.Printf("Processing row %d: %v\n", advert.row, advert)
-		index := buildIndexFromAdvertInfo(advert)
-		fmt.Printf("Built index %d for %v\n", index, advert)
-		if systemMap, ok := byDate[index]; ok {
-			if storedAdvert, ok := systemMap[advert.system]; ok {
-				// fmt.Printf("systemMap entry exists: %v\n", systemMap[advert.system])
-				stored_price := storedAdvert.price
-				if (advert.price > 0) && (advert.price < stored_price) {
-					fmt.Printf("%d/%d %s found as cheaper (%d against %d); row %d replaces row %d\n", advert.year, advert.month, advert.system, advert.price, stored_price, advert.row, storedAdvert.row)
-					systemMap[advert.system] = advert
-				} else {
-					fmt.Printf("%d/%d %s found as pricier (%d against %d); row %d LEAVES   row %d\n", advert.year, advert.month, advert.system, advert.price, stored_price, advert.row, storedAdvert.row)
-				}
-			} else {
-				// fmt.Printf("systemMap entry missing\n")
-				systemMap[advert.system] = advert
-			}
-		} else {
-			byDate[index] = make(map[string]advertInfo, 0)
-			systemMap = byDate[index]
-			systemMap[advert.system] = advert
-			fmt.Printf("%d/%d %s found for first time at %d; row %d\n", advert.year, advert.month, advert.system, advert.price, advert.row)
-		}
+// Given advert data for a range of systems, outputs that data in a form suitable for including in a wiki page
+func outputWikidata(w io.Writer, table priceTable) {
+	// Loop through quarters in groups of years (five years, unless -group-columns groups them adaptively).
+	// Process data for that group
+	// Move on to the next group and repeat until the groups reach the maxDate
+	groups := table.groupYears()
+	for i, group := range groups {
+		fmt.Fprintf(w, "== %d - %d ==\n\n", group.first, group.last)
+		outputWikiGroup(w, table, group)
+		progress.update("Rendering", i+1, len(groups), "tables")
 	}
-	return byDate
+	outputWikiFooter(w, table)
 }
 
-// This function applies some pre-processing to the gathered data.
-// For now this is hard-coded, but may later be driven by an external configuration file.
-// The following changes are made:
-// o "Science of Cambridge MK14" is re-written as "MK14"
-// o Data for "Apple II" is suppressed, as the configuration is unclear
-// o Data for "Exidy Sorcerer" is suppressed as the configuration is unclear
-func preprocessSystemData(systems map[string][]int) map[string][]int {
-	systemsToSuppress := []string{"Apple II", "Commodore PET", "Exidy Sorcerer", "Tandy TRS-80 Model 1"}
-	result := make(map[string][]int, 0)
-	for name, _ := range systems {
-		if sliceContainsString(systemsToSuppress, name) {
-			// Drop this data
-			fmt.Printf("Dropping %s\n", name)
-		} else if name == "Science of Cambridge MK14" {
-			result["MK14"] = systems[name]
-		} else {
-			result[name] = systems[name]
-		}
+// Outputs the metadata stamp, if there is one, as a comment that is hidden when the page is viewed
+func outputWikiStamp(w io.Writer, table priceTable) {
+	if table.stamp != "" {
+		fmt.Fprintf(w, "<!-- %s -->\n", table.stamp)
 	}
-	return result
 }
 
-// Given an advertInfo, this function produces an int that represents that year and quarter.
-// Months 1-3 are 0 (Q1), months 4-6 are 1 (Q2) etc.
-// The final index is (year*12 + quarter)
-func buildIndexFromAdvertInfo(advert advertInfo) int {
-	quarter := ((advert.month - 1) / 3)
-	return (advert.year * 4) + quarter
-}
+// The number of years covered by each table, unless -group-columns groups them adaptively
+const groupYearsBy = 5
 
-// Given a year and a quarter, combine them into a date-index integer
-func buildIndexFromYearAndQuarter(year int, quarter int) int {
-	return (year * 4) + (quarter - 1)
+// A yearGroup is a group of years, from first to last inclusive, for which a table is output
+type yearGroup struct {
+	first int
+	last  int
 }
 
-// Given a date-index, return the year and quarter which it represents
-func decodeIndexByQuarter(index int) (year int, quarter int) {
-	year = index / 4
-	quarter = index - (year * 4) + 1
-	return year, quarter
+// Return the groups of years for which a table is output: each starts at a multiple of groupYearsBy
+// and covers groupYearsBy years, unless -group-columns groups them adaptively (see adaptiveGroups)
+func (table priceTable) groupYears() []yearGroup {
+	if table.groupColumns > 0 {
+		return table.adaptiveGroups(table.groupColumns)
+	}
+	minYear, _ := hcp.DecodeIndexByQuarter(table.minDate)
+	maxYear, _ := hcp.DecodeIndexByQuarter(table.maxDate)
+	startYear := (minYear / groupYearsBy) * groupYearsBy
+	groups := make([]yearGroup, 0)
+	for groupYear := startYear; groupYear <= maxYear; groupYear = groupYear + groupYearsBy {
+		groups = append(groups, yearGroup{groupYear, groupYear + groupYearsBy - 1})
+	}
+	return groups
 }
 
-// Given a number of advertInfo objects, build a map of system => price-array
-// The price array index should be 0 for minDate and increase up to (maxDate-minDate) for maxDate
-func buildBySystem(adverts []advertInfo, minDate int, maxDate int) map[string][]int {
-	result := make(map[string][]int, 0)
+// Return the systems with a row in the table for a group of years: those with a price, or a withheld price, in those years
+func (table priceTable) groupKeys(group yearGroup) []string {
+	keys := make([]string, 0, len(table.keys))
+	for _, key := range table.keys {
+		if systemHasPriceData(group.first, group.last, table.minDate, table.maxDate, table.systems[key]) || table.hasWithheld(key, group.first, group.last) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
 
-	for _, advert := range adverts {
-		if _, ok := result[advert.system]; !ok {
-			// This system has been seen for the first time.
-			// Create its price array
-			result[advert.system] = make([]int, maxDate-minDate+1)
+// Outputs the wiki table, and the notes explaining it, for a group of years
+func outputWikiGroup(w io.Writer, table priceTable, group yearGroup) {
+	systems, minDate, maxDate := table.systems, table.minDate, table.maxDate
+	fmt.Fprintf(w, "{| class=\"wikitable\"\n")
+	fmt.Fprintf(w, "|-\n")
+	first, last := table.groupQuarters(group)
+	years := make([]string, 0, group.last-group.first+1)
+	quarters := make([]string, 0, last-first+1)
+	for year := group.first; year <= group.last; year++ {
+		shown := 0
+		for quarter, heading := range table.quarterHeadings() {
+			if index := hcp.BuildIndexFromYearAndQuarter(year, quarter+1); (index >= first) && (index <= last) {
+				quarters = append(quarters, heading)
+				shown++
+			}
 		}
-		// By this point the price array must exist, so update if appropriate.
-		index := buildIndexFromAdvertInfo(advert)
-		storedPrice := result[advert.system][index-minDate]
-		if (advert.price < storedPrice) || (storedPrice <= 0) {
-			result[advert.system][index-minDate] = advert.price
+		if shown > 0 {
+			years = append(years, fmt.Sprintf("colspan=\"%d\" | %d", shown, year))
 		}
 	}
-	return result
+	fmt.Fprintf(w, "!  || %s\n", strings.Join(years, " || "))
+	fmt.Fprintf(w, "|-\n")
+	fmt.Fprintf(w, " ! style=\"width: 10%%;\" | %s \n", table.systemHeading())
+	fmt.Fprintf(w, " ! %s\n", strings.Join(quarters, " || "))
+	for _, key := range table.groupKeys(group) {
+		// Pick up the prices for this system:
+		prices := systems[key]
+
+		fmt.Fprintf(w, "|-\n| %s", table.wikiSystem(key))
+		for currentIndex := first; currentIndex <= last; currentIndex++ {
+			_, currentQuarter := hcp.DecodeIndexByQuarter(currentIndex)
+			// for this index, find data and display
+			if (currentQuarter == 1) || (currentIndex == first) {
+				fmt.Fprintf(w, "\n     | ")
+			} else {
+				fmt.Fprintf(w, "|| ")
+			}
+			shade := table.wikiLifespanStyle(key, currentIndex)
+			if (currentIndex >= minDate) && (currentIndex <= maxDate) && (table.kind(key, currentIndex) == withheldPrice) {
+				fmt.Fprintf(w, "style=\"text-align: center;%s\" | %s ", shade, withheld_marker)
+			} else if (currentIndex < minDate) || (currentIndex > maxDate) || (prices[currentIndex-minDate] <= 0) {
+				placeholder, ok := table.placeholder(key, currentIndex)
+				if !ok {
+					placeholder = "&mdash;"
+				}
+				fmt.Fprintf(w, "style=\"text-align: center;%s\" | %s ", shade, placeholder)
+			} else {
+				price := table.markedCellText(key, currentIndex, table.wikiPrice)
+				switch table.kind(key, currentIndex) {
+				case interpolatedPrice:
+					fmt.Fprintf(w, "style=\"text-align: right;%s\"  | %-5s   ", shade, "''"+price+"''")
+				case carriedPrice:
+					fmt.Fprintf(w, "style=\"text-align: right; color: grey;%s\" | %-5s   ", shade, price)
+				default:
+					fmt.Fprintf(w, "style=\"text-align: right;%s\"  | %-5s   ", shade, price)
+				}
+			}
+		}
+		fmt.Fprintln(w, "")
+	}
+	outputWikiTotals(w, table, group)
+	fmt.Fprintf(w, "|}\n\n") // Close the "wikitable"
+	for _, note := range table.legend() {
+		fmt.Fprintf(w, "%s\n\n", note)
+	}
+}
+
+// Return the system names in a map of system => price-array, in alphabetical order
+func sortedKeys(systems map[string][]int) []string {
+	keys := make([]string, 0, len(systems))
+	for key, _ := range systems {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Split a comma-separated list of systems, as given to -systems, resolving any aliases to the names they are published under
+func splitSystems(list string, config hcp.Configuration) []string {
+	names := strings.Split(list, ",")
+	for i := range names {
+		names[i] = config.ResolveName(strings.TrimSpace(names[i]))
+	}
+	return names
 }
 
 // A helper function that determines whether there is price data available for the specified period
 func systemHasPriceData(startYear int, endYear int, minDate int, maxDate int, prices []int) bool {
 	systemHasPriceData := false
 
-	lowestIndex := buildIndexFromYearAndQuarter(startYear, 1)
+	lowestIndex := hcp.BuildIndexFromYearAndQuarter(startYear, 1)
 	lowestValidIndex := max(lowestIndex, minDate)
-	highestIndex := buildIndexFromYearAndQuarter(endYear, 4)
+	highestIndex := hcp.BuildIndexFromYearAndQuarter(endYear, 4)
 	highestValidIndex := min(highestIndex, maxDate)
 
 	for idx := lowestValidIndex; idx <= highestValidIndex; idx++ {
@@ -455,6 +662,13 @@ func max(a, b int) int {
 	return b
 }
 
+func abs(a int) int {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
+
 func sliceContainsString(slice []string, candidate string) bool {
 	for _, member := range slice {
 		if member == candidate {
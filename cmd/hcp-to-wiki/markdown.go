@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/AntonioCarlini/home-computer-prices/hcp"
+)
+
+// The markdown output holds the same tables as the wiki output, as GitHub-flavoured Markdown, for the repository's
+// own documentation and for wikis that do not accept MediaWiki markup. Markdown has no spanning cells, so each
+// quarter's column is headed by its year and quarter, and no colours, so estimates are marked in the text:
+// interpolated prices are in italics, as in the wiki tables, and carried prices are in parentheses.
+// Quarters outside a system's lifespan are not shaded.
+
+// Given advert data for a range of systems, outputs that data as Markdown tables
+func outputMarkdown(w io.Writer, table priceTable) {
+	groups := table.groupYears()
+	for i, group := range groups {
+		fmt.Fprintf(w, "## %d - %d\n\n", group.first, group.last)
+		outputMarkdownGroup(w, table, group)
+		progress.update("Rendering", i+1, len(groups), "tables")
+	}
+	outputMarkdownFooter(w, table)
+}
+
+// Outputs the Markdown table, and the notes explaining it, for a group of years
+func outputMarkdownGroup(w io.Writer, table priceTable, group yearGroup) {
+	first, last := table.groupQuarters(group)
+	headings, alignments := []string{table.systemHeading()}, []string{":--"}
+	for index := first; index <= last; index++ {
+		year, quarter := hcp.DecodeIndexByQuarter(index)
+		headings = append(headings, fmt.Sprintf("%d %s", year, table.quarterHeadings()[quarter-1]))
+		alignments = append(alignments, "--:")
+	}
+	writeMarkdownRow(w, headings)
+	writeMarkdownRow(w, alignments)
+
+	for _, key := range table.groupKeys(group) {
+		cells := []string{markdownText(key)}
+		for index := first; index <= last; index++ {
+			cells = append(cells, table.markdownCell(key, index))
+		}
+		writeMarkdownRow(w, cells)
+	}
+	if table.totals {
+		counts, medians := []string{"**" + table.note("systems-priced", "Systems priced") + "**"}, []string{"**" + table.note("median-price", "Median price") + "**"}
+		for index := first; index <= last; index++ {
+			priced, median := table.quarterSummary(index)
+			counts = append(counts, fmt.Sprintf("%d", priced))
+			if priced == 0 {
+				medians = append(medians, "&mdash;")
+			} else {
+				medians = append(medians, table.wikiPrice(median))
+			}
+		}
+		writeMarkdownRow(w, counts)
+		writeMarkdownRow(w, medians)
+	}
+	fmt.Fprintln(w, "")
+	for _, note := range table.legendWith(parenthesised_carried_notes) {
+		fmt.Fprintf(w, "%s\n\n", markdownText(note))
+	}
+}
+
+// Return the Markdown for one cell of a system's row
+func (table priceTable) markdownCell(key string, index int) string {
+	switch {
+	case (index >= table.minDate) && (index <= table.maxDate) && (table.kind(key, index) == withheldPrice):
+		return withheld_marker
+	case (index < table.minDate) || (index > table.maxDate) || (table.systems[key][index-table.minDate] <= 0):
+		if placeholder, ok := table.placeholder(key, index); ok {
+			return markdownText(placeholder)
+		}
+		return "&mdash;"
+	}
+	price := markdownText(table.markedCellText(key, index, table.wikiPrice))
+	switch table.kind(key, index) {
+	case interpolatedPrice:
+		return "*" + price + "*"
+	case carriedPrice:
+		return "(" + price + ")"
+	}
+	return price
+}
+
+// Outputs one row of a Markdown table
+func writeMarkdownRow(w io.Writer, cells []string) {
+	fmt.Fprintf(w, "| %s |\n", strings.Join(cells, " | "))
+}
+
+// Return text escaped so that Markdown shows it as it is, rather than as markup or the end of a cell
+func markdownText(text string) string {
+	return strings.NewReplacer("\\", "\\\\", "|", "\\|", "*", "\\*", "_", "\\_", "`", "\\`", "[", "\\[", "~", "\\~", "<", "&lt;").Replace(text)
+}
+
+// Outputs the footer of a Markdown document: the attribution, then the metadata stamp as a hidden comment
+func outputMarkdownFooter(w io.Writer, table priceTable) {
+	if !table.attribution.IsEmpty() {
+		text := attributionSentences(table.attribution, func(url string, text string) string {
+			return "[" + markdownText(text) + "](" + url + ")"
+		})
+		fmt.Fprintf(w, "---\n\n<small>%s</small>\n\n", text)
+	}
+	if table.stamp != "" {
+		fmt.Fprintf(w, "<!-- %s -->\n", table.stamp)
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"encoding/csv"
+	"io"
+	"log"
+
+	"github.com/AntonioCarlini/home-computer-prices/hcp"
+)
+
+// Write the published prices as a CSV matrix: a row per system and a column per quarter, headed "System", "1980Q1", ...
+// Prices are in pounds, rounded as for the other output; quarters without a price are left empty.
+// This lets downstream consumers recover the numbers without having to parse wiki markup.
+func writeMatrixCSV(w io.Writer, table priceTable) {
+	out := csv.NewWriter(w)
+	header := []string{"System"}
+	for index := table.minDate; index <= table.maxDate; index++ {
+		header = append(header, hcp.FormatQuarter(index))
+	}
+	out.Write(header)
+	for _, key := range table.keys {
+		record := []string{key}
+		for _, price := range table.systems[key] {
+			if price > 0 {
+				record = append(record, formatPrice(price, table.rounding))
+			} else {
+				record = append(record, "")
+			}
+		}
+		out.Write(record)
+	}
+	out.Flush()
+	if err := out.Error(); err != nil {
+		log.Fatalln("Cannot write CSV data:", err.Error())
+	}
+}
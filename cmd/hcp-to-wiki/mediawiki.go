@@ -0,0 +1,210 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The User-Agent sent with every request, as the Wikimedia API etiquette asks for
+const wiki_user_agent = "hcp-to-wiki (https://github.com/AntonioCarlini/home-computer-prices)"
+
+// How many times a request refused because the wiki's database is lagging is retried before giving up
+const max_maxlag_retries = 5
+
+// A client for the MediaWiki action API (api.php)
+type mediaWiki struct {
+	api    string           // URL of the wiki's api.php
+	maxlag int              // Passed with every request so that the wiki refuses it while its replicas lag by more than this many seconds
+	client *http.Client     // Holds the session cookies once logged in
+	oauth  *wikiCredentials // If set, every request is signed with these OAuth credentials instead
+}
+
+// An error reported by the API
+type mediaWikiError struct {
+	Code string `json:"code"`
+	Info string `json:"info"`
+}
+
+func (err *mediaWikiError) Error() string {
+	return fmt.Sprintf("%s (%s)", err.Info, err.Code)
+}
+
+// Create a client for the wiki whose api.php is at the given URL
+func newMediaWiki(api string, maxlag int) *mediaWiki {
+	jar, _ := cookiejar.New(nil)
+	return &mediaWiki{api: api, maxlag: maxlag, client: &http.Client{Jar: jar, Timeout: time.Minute}}
+}
+
+// Make an API request and decode its JSON response into result.
+// Requests that change something are POSTed; others use GET.
+// If the wiki refuses the request because its database is lagging, wait as long as it asks and try again.
+func (wiki *mediaWiki) call(post bool, params url.Values, result interface{}) error {
+	params.Set("format", "json")
+	params.Set("formatversion", "2")
+	if wiki.maxlag > 0 {
+		params.Set("maxlag", strconv.Itoa(wiki.maxlag))
+	}
+	for attempt := 0; ; attempt++ {
+		var request *http.Request
+		var err error
+		if post {
+			request, err = http.NewRequest("POST", wiki.api, strings.NewReader(params.Encode()))
+			if err == nil {
+				request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			}
+		} else {
+			request, err = http.NewRequest("GET", wiki.api+"?"+params.Encode(), nil)
+		}
+		if err != nil {
+			return err
+		}
+		request.Header.Set("User-Agent", wiki_user_agent)
+		if wiki.oauth != nil {
+			request.Header.Set("Authorization", wiki.oauth.oauthHeader(request.Method, wiki.api, params))
+		}
+
+		response, err := wiki.client.Do(request)
+		if err != nil {
+			return err
+		}
+		body, err := io.ReadAll(response.Body)
+		response.Body.Close()
+		if err != nil {
+			return err
+		}
+		if response.StatusCode != http.StatusOK {
+			return fmt.Errorf("wiki returned [%s]", response.Status)
+		}
+
+		var reply struct {
+			Error *mediaWikiError `json:"error"`
+		}
+		if err := json.Unmarshal(body, &reply); err != nil {
+			return fmt.Errorf("bad response from wiki (%w)", err)
+		}
+		if (reply.Error != nil) && (reply.Error.Code == "maxlag") && (attempt < max_maxlag_retries) {
+			wait, err := strconv.Atoi(response.Header.Get("Retry-After"))
+			if err != nil || wait <= 0 {
+				wait = 5
+			}
+			logf("Wiki is lagging (%s); waiting %ds\n", reply.Error.Info, wait)
+			time.Sleep(time.Duration(wait) * time.Second)
+			continue
+		}
+		if reply.Error != nil {
+			return reply.Error
+		}
+		return json.Unmarshal(body, result)
+	}
+}
+
+// Fetch a token of the given type ("login" or "csrf")
+func (wiki *mediaWiki) token(kind string) (string, error) {
+	var reply struct {
+		Query struct {
+			Tokens map[string]string `json:"tokens"`
+		} `json:"query"`
+	}
+	if err := wiki.call(false, url.Values{"action": {"query"}, "meta": {"tokens"}, "type": {kind}}, &reply); err != nil {
+		return "", err
+	}
+	token, ok := reply.Query.Tokens[kind+"token"]
+	if !ok {
+		return "", fmt.Errorf("wiki did not supply a [%s] token", kind)
+	}
+	return token, nil
+}
+
+// Authenticate with the credentials: OAuth signs each request, while a bot password logs in to a session
+func (wiki *mediaWiki) authenticate(credentials wikiCredentials) error {
+	if credentials.usesOAuth() {
+		wiki.oauth = &credentials
+		return nil
+	}
+	if credentials.User == "" {
+		return nil
+	}
+	return wiki.login(credentials.User, credentials.Password)
+}
+
+// Log in with a user name and password
+func (wiki *mediaWiki) login(user string, password string) error {
+	token, err := wiki.token("login")
+	if err != nil {
+		return err
+	}
+	var reply struct {
+		Login struct {
+			Result string `json:"result"`
+			Reason string `json:"reason"`
+		} `json:"login"`
+	}
+	if err := wiki.call(true, url.Values{"action": {"login"}, "lgname": {user}, "lgpassword": {password}, "lgtoken": {token}}, &reply); err != nil {
+		return err
+	}
+	if reply.Login.Result != "Success" {
+		return fmt.Errorf("login as [%s] failed: %s %s", user, reply.Login.Result, reply.Login.Reason)
+	}
+	return nil
+}
+
+// Return the current text of a page and the timestamp of its latest revision.
+// A page that does not exist yet has no text and an empty timestamp.
+func (wiki *mediaWiki) pageText(title string) (text string, timestamp string, err error) {
+	var reply struct {
+		Query struct {
+			Pages []struct {
+				Missing   bool `json:"missing"`
+				Revisions []struct {
+					Timestamp string `json:"timestamp"`
+					Slots     struct {
+						Main struct {
+							Content string `json:"content"`
+						} `json:"main"`
+					} `json:"slots"`
+				} `json:"revisions"`
+			} `json:"pages"`
+		} `json:"query"`
+	}
+	params := url.Values{"action": {"query"}, "prop": {"revisions"}, "rvprop": {"content|timestamp"}, "rvslots": {"main"}, "titles": {title}}
+	if err := wiki.call(false, params, &reply); err != nil {
+		return "", "", err
+	}
+	if (len(reply.Query.Pages) == 0) || reply.Query.Pages[0].Missing || (len(reply.Query.Pages[0].Revisions) == 0) {
+		return "", "", nil
+	}
+	revision := reply.Query.Pages[0].Revisions[0]
+	return revision.Slots.Main.Content, revision.Timestamp, nil
+}
+
+// Replace the text of a page, marking the edit as a bot edit.
+// baseTimestamp is the timestamp of the revision the new text was compared against, so that an edit made in
+// the meantime is reported as a conflict rather than overwritten; it is empty when creating a page.
+func (wiki *mediaWiki) edit(title string, text string, summary string, token string, baseTimestamp string) error {
+	params := url.Values{"action": {"edit"}, "title": {title}, "text": {text}, "summary": {summary}, "bot": {"1"}, "token": {token}}
+	if baseTimestamp != "" {
+		params.Set("basetimestamp", baseTimestamp)
+		params.Set("nocreate", "1")
+	} else {
+		params.Set("createonly", "1")
+	}
+	var reply struct {
+		Edit struct {
+			Result string `json:"result"`
+		} `json:"edit"`
+	}
+	if err := wiki.call(true, params, &reply); err != nil {
+		return err
+	}
+	if reply.Edit.Result != "Success" {
+		return fmt.Errorf("edit of [%s] failed: %s", title, reply.Edit.Result)
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// serve answers GET /metrics with its metrics in the Prometheus text format, so that a public instance can be monitored:
+// the rows read from each source and how many were accepted, rejected and warned about, the adverts and systems
+// being served, when the data was last refreshed and last changed, how many refreshes have failed, and a count and
+// latency histogram of the requests answered for each endpoint.
+
+// The upper bounds, in seconds, of the buckets of the request latency histogram
+var latency_buckets = []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5}
+
+// The requests a server has answered, counted by endpoint
+type serverMetrics struct {
+	mutex     sync.Mutex                 // Guards the fields below
+	requests  map[requestKey]int         // Requests answered, by endpoint and status code
+	latencies map[string]*latencyMetrics // How long requests took, by endpoint
+}
+
+// An endpoint, as the pattern it was registered with, and a status code it answered with
+type requestKey struct {
+	endpoint string
+	code     int
+}
+
+// A histogram of how long the requests to one endpoint took
+type latencyMetrics struct {
+	buckets []int   // Requests that took no longer than each of the latency_buckets
+	count   int     // All requests
+	sum     float64 // Total time taken, in seconds
+}
+
+func newServerMetrics() *serverMetrics {
+	return &serverMetrics{requests: make(map[requestKey]int), latencies: make(map[string]*latencyMetrics)}
+}
+
+// A ResponseWriter that remembers the status code of the response
+type statusRecorder struct {
+	http.ResponseWriter
+	code int
+}
+
+func (recorder *statusRecorder) WriteHeader(code int) {
+	recorder.code = code
+	recorder.ResponseWriter.WriteHeader(code)
+}
+
+// Return a handler that serves requests with the mux, counting and timing them by the pattern that handled them
+func (metrics *serverMetrics) instrument(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Paths that no pattern handles are counted together, so that probing for them cannot add endless labels
+		_, endpoint := mux.Handler(r)
+		if endpoint == "" {
+			endpoint = "other"
+		}
+		recorder := &statusRecorder{w, http.StatusOK}
+		start := time.Now()
+		mux.ServeHTTP(recorder, r)
+		metrics.record(endpoint, recorder.code, time.Since(start))
+	})
+}
+
+// Count a request to an endpoint that was answered with the status code after the given time
+func (metrics *serverMetrics) record(endpoint string, code int, elapsed time.Duration) {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+	metrics.requests[requestKey{endpoint, code}]++
+	latency, ok := metrics.latencies[endpoint]
+	if !ok {
+		latency = &latencyMetrics{buckets: make([]int, len(latency_buckets))}
+		metrics.latencies[endpoint] = latency
+	}
+	seconds := elapsed.Seconds()
+	for i, bound := range latency_buckets {
+		if seconds <= bound {
+			latency.buckets[i]++
+		}
+	}
+	latency.count++
+	latency.sum += seconds
+}
+
+// Write the metrics of the snapshot being served and of the requests answered so far in the Prometheus text format.
+// failures is the number of refreshes that have failed.
+func (metrics *serverMetrics) write(w io.Writer, snapshot *servedSnapshot, failures int64) {
+	dataset := snapshot.dataset
+	metric := func(name string, kind string, help string) {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+	}
+
+	metric("hcp_source_rows", "gauge", "Data rows read from each source.")
+	for _, validation := range dataset.Validations {
+		fmt.Fprintf(w, "hcp_source_rows{source=%s} %d\n", metricLabel(validation.Filename), validation.Rows)
+	}
+	metric("hcp_source_rows_accepted", "gauge", "Rows of each source that passed validation.")
+	for _, validation := range dataset.Validations {
+		fmt.Fprintf(w, "hcp_source_rows_accepted{source=%s} %d\n", metricLabel(validation.Filename), validation.Accepted)
+	}
+	metric("hcp_source_rows_rejected", "gauge", "Rows of each source rejected by validation.")
+	for _, validation := range dataset.Validations {
+		fmt.Fprintf(w, "hcp_source_rows_rejected{source=%s} %d\n", metricLabel(validation.Filename), validation.Rejected)
+	}
+	metric("hcp_source_rows_warnings", "gauge", "Rows of each source used despite a problem.")
+	for _, validation := range dataset.Validations {
+		fmt.Fprintf(w, "hcp_source_rows_warnings{source=%s} %d\n", metricLabel(validation.Filename), validation.Warnings)
+	}
+	metric("hcp_adverts", "gauge", "Adverts being served.")
+	fmt.Fprintf(w, "hcp_adverts %d\n", len(dataset.Adverts))
+	metric("hcp_systems", "gauge", "Systems with published prices.")
+	fmt.Fprintf(w, "hcp_systems %d\n", len(dataset.Systems()))
+	metric("hcp_last_refresh_timestamp_seconds", "gauge", "When the data being served was loaded.")
+	fmt.Fprintf(w, "hcp_last_refresh_timestamp_seconds %d\n", snapshot.loaded.Unix())
+	metric("hcp_last_change_timestamp_seconds", "gauge", "When the data being served last changed.")
+	fmt.Fprintf(w, "hcp_last_change_timestamp_seconds %d\n", snapshot.changed.Unix())
+	metric("hcp_refresh_failures_total", "counter", "Refreshes that failed, leaving the previous data in place.")
+	fmt.Fprintf(w, "hcp_refresh_failures_total %d\n", failures)
+
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+	keys := make([]requestKey, 0, len(metrics.requests))
+	for key := range metrics.requests {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].endpoint != keys[j].endpoint {
+			return keys[i].endpoint < keys[j].endpoint
+		}
+		return keys[i].code < keys[j].code
+	})
+	metric("hcp_http_requests_total", "counter", "HTTP requests answered, by endpoint and status code.")
+	for _, key := range keys {
+		fmt.Fprintf(w, "hcp_http_requests_total{endpoint=%s,code=\"%d\"} %d\n", metricLabel(key.endpoint), key.code, metrics.requests[key])
+	}
+	endpoints := make([]string, 0, len(metrics.latencies))
+	for endpoint := range metrics.latencies {
+		endpoints = append(endpoints, endpoint)
+	}
+	sort.Strings(endpoints)
+	metric("hcp_http_request_duration_seconds", "histogram", "How long HTTP requests took to answer, by endpoint.")
+	for _, endpoint := range endpoints {
+		latency, label := metrics.latencies[endpoint], metricLabel(endpoint)
+		for i, bound := range latency_buckets {
+			fmt.Fprintf(w, "hcp_http_request_duration_seconds_bucket{endpoint=%s,le=\"%s\"} %d\n", label, strconv.FormatFloat(bound, 'g', -1, 64), latency.buckets[i])
+		}
+		fmt.Fprintf(w, "hcp_http_request_duration_seconds_bucket{endpoint=%s,le=\"+Inf\"} %d\n", label, latency.count)
+		fmt.Fprintf(w, "hcp_http_request_duration_seconds_sum{endpoint=%s} %g\n", label, latency.sum)
+		fmt.Fprintf(w, "hcp_http_request_duration_seconds_count{endpoint=%s} %d\n", label, latency.count)
+	}
+}
+
+// Return text as the quoted value of a Prometheus label
+func metricLabel(text string) string {
+	return "\"" + strings.NewReplacer("\\", "\\\\", "\"", "\\\"", "\n", "\\n").Replace(text) + "\""
+}
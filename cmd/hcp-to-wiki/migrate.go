@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"flag"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/AntonioCarlini/home-computer-prices/hcp"
+)
+
+// Implements "migrate [-to v2] [-o out.csv] [-region US] [-csv-delimiter auto] data.csv".
+// Upgrades a CSV file of adverts written for an earlier schema version (see hcp.SchemaVersion) to a later one,
+// by default the latest, so that contributors' older files stay usable: the columns the later version requires
+// are added to each header, with their fields left empty, or for the Region column set to -region.
+// Nothing else is changed, and comment lines are kept as they were, as by normalize.
+func runMigrate(args []string) {
+	flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	to := flags.String("to", "v"+strconv.Itoa(hcp.SchemaVersion), "the schema version to upgrade to, such as v2")
+	outputFilename := flags.String("o", "", "write the migrated data to this file instead of standard output")
+	region := flags.String("region", "", "the region of the rows of blocks without a Region column (default: none, as for UK magazines)")
+	delimiterName := flags.String("csv-delimiter", "auto", "the delimiter between the input's fields: auto to detect it, tab, or a single character such as ;")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		log.Fatalf("Exactly 1 CSV file required but %d supplied\n", flags.NArg())
+	}
+	version, err := strconv.Atoi(strings.TrimPrefix(*to, "v"))
+	if err != nil {
+		log.Fatalf("Bad schema version '%s'\n", *to)
+	}
+	delimiter, err := hcp.ParseDelimiter(*delimiterName)
+	if err != nil {
+		log.Fatalf("Cannot read CSV: %s\n", err.Error())
+	}
+	f, err := os.Open(flags.Arg(0))
+	if err != nil {
+		log.Fatalf("Cannot open CSV: %s\n", err.Error())
+	}
+	rows, delimiter, err := hcp.ReadCSVRows(f, delimiter)
+	f.Close()
+	if err != nil {
+		log.Fatalf("Cannot read CSV data from '%s': %s\n", flags.Arg(0), err.Error())
+	}
+	rows, err = hcp.MigrateRows(rows, version, map[string]string{"Region": *region})
+	if err != nil {
+		log.Fatalf("Cannot migrate '%s': %s\n", flags.Arg(0), err.Error())
+	}
+
+	writeOutput(*outputFilename, func(w io.Writer) {
+		buffered := bufio.NewWriter(w)
+		out := csv.NewWriter(buffered)
+		for _, row := range rows {
+			if hcp.IsComment(row) {
+				// A comment is written back as it was read, not quoted as a field would be
+				out.Flush()
+				buffered.WriteString(strings.Join(row, string(delimiter)) + "\n")
+				continue
+			}
+			out.Write(row)
+		}
+		out.Flush()
+		if err := out.Error(); err != nil {
+			log.Fatalln("Cannot write CSV data:", err.Error())
+		}
+		if err := buffered.Flush(); err != nil {
+			log.Fatalln("Cannot write CSV data:", err.Error())
+		}
+	})
+}
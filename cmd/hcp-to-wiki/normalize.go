@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"flag"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/AntonioCarlini/home-computer-prices/hcp"
+)
+
+// Implements "normalize [-o out.csv] [-csv-delimiter auto] data.csv".
+// Rewrites a CSV file of adverts in a canonical form: separated by commas, without a byte order mark,
+// with the whitespace around each field removed and each row padded to the width of its block's header line.
+// Nothing else is changed: comment lines (beginning with "#") are kept as they were and the Notes column is kept,
+// so that contributors' annotations survive, and the rows are not validated.
+func runNormalize(args []string) {
+	flags := flag.NewFlagSet("normalize", flag.ExitOnError)
+	outputFilename := flags.String("o", "", "write the normalized data to this file instead of standard output")
+	delimiterName := flags.String("csv-delimiter", "auto", "the delimiter between the input's fields: auto to detect it, tab, or a single character such as ;")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		log.Fatalf("Exactly 1 CSV file required but %d supplied\n", flags.NArg())
+	}
+	delimiter, err := hcp.ParseDelimiter(*delimiterName)
+	if err != nil {
+		log.Fatalf("Cannot read CSV: %s\n", err.Error())
+	}
+	f, err := os.Open(flags.Arg(0))
+	if err != nil {
+		log.Fatalf("Cannot open CSV: %s\n", err.Error())
+	}
+	rows, delimiter, err := hcp.ReadCSVRows(f, delimiter)
+	f.Close()
+	if err != nil {
+		log.Fatalf("Cannot read CSV data from '%s': %s\n", flags.Arg(0), err.Error())
+	}
+
+	writeOutput(*outputFilename, func(w io.Writer) {
+		buffered := bufio.NewWriter(w)
+		out := csv.NewWriter(buffered)
+		width := 0 // The number of fields in the current block's header line
+		for _, row := range rows {
+			if hcp.IsComment(row) {
+				// A comment is written back as it was read, not quoted as a field would be
+				out.Flush()
+				buffered.WriteString(strings.Join(row, string(delimiter)) + "\n")
+				continue
+			}
+			if hcp.IsHeader(row) {
+				width = len(row)
+			}
+			fields := make([]string, 0, max(len(row), width))
+			for _, field := range row {
+				fields = append(fields, strings.TrimSpace(field))
+			}
+			for len(fields) < width {
+				fields = append(fields, "")
+			}
+			out.Write(fields)
+		}
+		out.Flush()
+		if err := out.Error(); err != nil {
+			log.Fatalln("Cannot write CSV data:", err.Error())
+		}
+		if err := buffered.Flush(); err != nil {
+			log.Fatalln("Cannot write CSV data:", err.Error())
+		}
+	})
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+
+	"github.com/AntonioCarlini/home-computer-prices/hcp"
+)
+
+// Output formats that are not built in can be provided by plugins, so that an exotic format needs no change to this program.
+// A plugin for the format "foo" is any executable called hcp-to-wiki-foo on the PATH; "-format foo" runs it with the
+// published prices written to its standard input as a pluginDocument in JSON, and whatever it writes to its standard
+// output becomes the output. Anything it writes to standard error is passed through, and it should exit with a
+// non-zero status if it fails. With -out-dir, its output is written to a file with the extension ".foo".
+//
+// Example input:
+//
+//	{
+//	  "version": 1,
+//	  "first": "1981Q1",
+//	  "last": "1981Q4",
+//	  "rounding": "trunc",
+//	  "systems": [
+//	    { "name": "Sinclair ZX81", "prices": [ { "quarter": "1981Q1", "pence": 6995, "price": "69", "kind": "observed" } ] }
+//	  ]
+//	}
+
+// The prefix of the name of a plugin executable
+const plugin_prefix = "hcp-to-wiki-"
+
+// The version of the plugin protocol, incremented if the document changes in a way that could break existing plugins
+const plugin_protocol_version = 1
+
+// The document written to a plugin
+type pluginDocument struct {
+	Version     int              `json:"version"`
+	First       string           `json:"first"`                 // The first quarter with data, such as "1981Q1", or empty if there is none
+	Last        string           `json:"last"`                  // The last quarter with data, or empty if there is none
+	Rounding    string           `json:"rounding"`              // How each price has been formatted; one of the priceRoundings
+	Stamp       string           `json:"stamp,omitempty"`       // The -stamp metadata, if requested
+	Attribution *hcp.Attribution `json:"attribution,omitempty"` // The configuration's attribution, if it has one
+	Systems     []pluginSystem   `json:"systems"`               // In the order they are to be output
+}
+
+type pluginSystem struct {
+	Name   string        `json:"name"`
+	Prices []pluginPrice `json:"prices"` // Only quarters with a price are included, oldest first
+}
+
+type pluginPrice struct {
+	Quarter string `json:"quarter"`
+	Pence   int    `json:"pence"`
+	Price   string `json:"price"`   // The price in pounds, formatted according to the rounding
+	Kind    string `json:"kind"`    // "observed", "interpolated" or "carried"
+	Adverts int    `json:"adverts"` // How many adverts an observed price was chosen from
+}
+
+// Return the output format with the given name: either a built-in format or, failing that, a plugin
+func lookupFormat(name string) (outputFormat, bool) {
+	if format, ok := outputFormats[name]; ok {
+		return format, true
+	}
+	path, err := exec.LookPath(plugin_prefix + name)
+	if err != nil {
+		return outputFormat{}, false
+	}
+	return outputFormat{pluginRenderer(path), "." + name}, true
+}
+
+// Return a renderer that runs the plugin executable at path
+func pluginRenderer(path string) outputRenderer {
+	return func(w io.Writer, table priceTable) {
+		input, err := json.Marshal(newPluginDocument(table))
+		if err != nil {
+			log.Fatalln("Cannot encode data for plugin:", err.Error())
+		}
+		command := exec.Command(path)
+		command.Stdin = bytes.NewReader(input)
+		command.Stdout = w
+		command.Stderr = os.Stderr
+		if err := command.Run(); err != nil {
+			log.Fatalf("Plugin '%s' failed: %s\n", path, err.Error())
+		}
+	}
+}
+
+// Given advert data for a range of systems, build the document written to a plugin
+func newPluginDocument(table priceTable) pluginDocument {
+	document := pluginDocument{
+		Version:  plugin_protocol_version,
+		Rounding: table.rounding,
+		Stamp:    table.stamp,
+		Systems:  make([]pluginSystem, 0, len(table.keys)),
+	}
+	if table.maxDate >= table.minDate {
+		document.First, document.Last = hcp.FormatQuarter(table.minDate), hcp.FormatQuarter(table.maxDate)
+	}
+	if !table.attribution.IsEmpty() {
+		document.Attribution = &table.attribution
+	}
+	for _, key := range table.keys {
+		system := pluginSystem{Name: key, Prices: make([]pluginPrice, 0)}
+		for index := table.minDate; index <= table.maxDate; index++ {
+			pence := table.systems[key][index-table.minDate]
+			if pence <= 0 {
+				continue
+			}
+			adverts := 0
+			if table.kind(key, index) == observedPrice {
+				adverts = len(table.observations[key][index-table.minDate])
+			}
+			price := pluginPrice{hcp.FormatQuarter(index), pence, formatPrice(pence, table.rounding), table.kind(key, index).String(), adverts}
+			system.Prices = append(system.Prices, price)
+		}
+		document.Systems = append(document.Systems, system)
+	}
+	return document
+}
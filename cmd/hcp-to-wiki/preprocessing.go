@@ -0,0 +1,28 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/AntonioCarlini/home-computer-prices/hcp"
+)
+
+// Given the systems whose data the configuration's suppress and rename rules changed, list them with the diagnostics,
+// with how many adverts each lost or had renamed, followed by a summary that is given even when nothing changed,
+// so that data dropped or merged by the rules (such as the suppressed Apple II) is visible in every run
+func logPreprocessing(changes []hcp.PreprocessedSystem) {
+	dropped, droppedAdverts, renamed, renamedAdverts := 0, 0, 0, 0
+	for _, change := range changes {
+		if change.Published == "" {
+			logf("Dropping %s: %d advert(s), as the configuration suppresses it\n", change.System, change.Adverts)
+			dropped, droppedAdverts = dropped+1, droppedAdverts+change.Adverts
+			continue
+		}
+		merged := ""
+		if len(change.Merged) > 0 {
+			merged = ", combined with those of " + strings.Join(change.Merged, ", ")
+		}
+		logf("Renaming %s to %s: %d advert(s)%s\n", change.System, change.Published, change.Adverts, merged)
+		renamed, renamedAdverts = renamed+1, renamedAdverts+change.Adverts
+	}
+	logf("Preprocessing dropped %d advert(s) of %d system(s) and renamed %d advert(s) of %d system(s)\n", droppedAdverts, dropped, renamedAdverts, renamed)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"strings"
+)
+
+// Implements "preview-diff -api URL [-page TITLE] page.wiki|directory ...".
+// Shows how publishing freshly generated pages would change the generated content of the pages on the wiki,
+// as a unified diff of each, without logging in: the pages are read anonymously, so no credentials are needed.
+// The pages are named as for publish; -page previews only the page with that title, such as
+// "Home computer prices/1980–1984". Only the generated content of a live page is compared (see generatedPattern),
+// as the rest of it belongs to the wiki's editors; a live page without any is compared as a whole.
+// The exit status is 1 if any page differs, so that a scheduled job can tell whether the wiki is out of date.
+func runPreviewDiff(args []string) {
+	flags := flag.NewFlagSet("preview-diff", flag.ExitOnError)
+	api := flags.String("api", "", "URL of the wiki's api.php")
+	title := flags.String("page", "", "preview only the page with this title")
+	maxlag := flags.Int("maxlag", 5, "ask the wiki to refuse requests while its database lags by more than this many seconds (0 disables)")
+	inputs := parseInterspersed(flags, args)
+	if *api == "" {
+		log.Fatalf("-api is required\n")
+	}
+	if len(inputs) < 1 {
+		log.Fatalf("At least 1 page file or directory required but %d supplied\n", len(inputs))
+	}
+
+	pages, err := readPages(inputs)
+	if err != nil {
+		log.Fatalf("Cannot read pages: %s\n", err.Error())
+	}
+	if *title != "" {
+		selected := make([]wikiPage, 0, 1)
+		for _, page := range pages {
+			if page.title == *title {
+				selected = append(selected, page)
+			}
+		}
+		if len(selected) == 0 {
+			log.Fatalf("No generated page is titled '%s'\n", *title)
+		}
+		pages = selected
+	}
+
+	wiki := newMediaWiki(*api, *maxlag)
+	differing := 0
+	for _, page := range pages {
+		current, timestamp, err := wiki.pageText(page.title)
+		if err != nil {
+			log.Fatalf("Cannot fetch '%s': %s\n", page.title, err.Error())
+		}
+		live := current
+		if match := generatedPattern.FindStringSubmatch(current); match != nil {
+			live = match[2]
+		} else if timestamp != "" {
+			logf("%s: the page has no generated content, so the whole page is compared\n", page.title)
+		}
+		// The wiki drops trailing whitespace when a page is saved, and so does wrapGenerated
+		live, generated := strings.TrimRight(live, " \n")+"\n", strings.TrimRight(page.text, " \n")+"\n"
+		switch {
+		case timestamp == "":
+			logf("%s: not on the wiki yet\n", page.title)
+		case live == generated:
+			logf("%s: unchanged\n", page.title)
+			continue
+		}
+		differing++
+		writeDiff(os.Stdout, page.title+" (wiki)", page.title+" (generated)", live, generated)
+	}
+	logf("%d of %d page(s) would change\n", differing, len(pages))
+	if differing > 0 {
+		os.Exit(1)
+	}
+}
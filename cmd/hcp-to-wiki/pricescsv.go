@@ -0,0 +1,38 @@
+package main
+
+import (
+	"encoding/csv"
+	"io"
+	"log"
+	"strconv"
+)
+
+// The csv output is the aggregated prices as CSV, a row per system and quarter with a price, for loading into a
+// spreadsheet. It holds the same records as the json output (see pricesjson.go): the advert columns name the advert
+// an observed price was taken from and are empty for an estimate, as is Page where the page number could not be read.
+
+// The header line of the csv output
+var prices_csv_columns = []string{"System", "Year", "Quarter", "Pence", "Price", "Kind", "Adverts", "File", "Row", "Magazine", "Issue", "Page"}
+
+// Given advert data for a range of systems, outputs the aggregated prices as CSV
+func outputPricesCSV(w io.Writer, table priceTable) {
+	out := csv.NewWriter(w)
+	out.Write(prices_csv_columns)
+	for _, price := range table.aggregatedPrices() {
+		record := []string{price.System, strconv.Itoa(price.Year), strconv.Itoa(price.Quarter), strconv.Itoa(price.Pence), price.Price, price.Kind, strconv.Itoa(price.Adverts)}
+		if source := price.Source; source != nil {
+			page := ""
+			if source.Page != nil {
+				page = strconv.Itoa(*source.Page)
+			}
+			record = append(record, source.File, strconv.Itoa(source.Row), source.Magazine, source.Issue, page)
+		} else {
+			record = append(record, "", "", "", "", "")
+		}
+		out.Write(record)
+	}
+	out.Flush()
+	if err := out.Error(); err != nil {
+		log.Fatalln("Cannot write CSV data:", err.Error())
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/AntonioCarlini/home-computer-prices/hcp"
+)
+
+// The json output is the aggregated prices themselves, a record per system and quarter with a price, for other
+// tooling to consume without parsing wiki markup. Each observed price names the advert it was taken from, the first
+// in the data whose price is the one published; with -aggregate median, an even number of adverts may leave none.
+// Estimated prices name no advert. Prices are in pence, as in the CSV data once VAT has been added.
+//
+// Example output:
+//
+//	{
+//	  "first": "1981Q1",
+//	  "last": "1981Q4",
+//	  "rounding": "trunc",
+//	  "prices": [
+//	    { "system": "Sinclair ZX81", "year": 1981, "quarter": 1, "pence": 6995, "price": "69", "kind": "observed", "adverts": 2,
+//	      "source": { "file": "data.csv", "row": 12, "magazine": "PCW", "issue": "1981-03", "page": 41 } }
+//	  ]
+//	}
+
+// The document written by the json output
+type pricesDocument struct {
+	First    *string       `json:"first"`           // The first quarter with data, such as "1981Q1", or null if there is none
+	Last     *string       `json:"last"`            // The last quarter with data, or null if there is none
+	Rounding string        `json:"rounding"`        // How each price has been formatted; one of the priceRoundings
+	Stamp    string        `json:"stamp,omitempty"` // The -stamp metadata, if requested
+	Prices   []pricesEntry `json:"prices"`          // By system, in the order they are output, then oldest first
+}
+
+// One system's price in one quarter
+type pricesEntry struct {
+	System  string        `json:"system"` // As published
+	Year    int           `json:"year"`
+	Quarter int           `json:"quarter"`
+	Pence   int           `json:"pence"`
+	Price   string        `json:"price"`   // The price in pounds, formatted according to the rounding
+	Kind    string        `json:"kind"`    // "observed", "interpolated" or "carried"
+	Adverts int           `json:"adverts"` // How many adverts an observed price was chosen from
+	Source  *pricesSource `json:"source"`  // The advert the price was taken from, or null
+}
+
+// The row of the CSV data holding the advert a price was taken from
+type pricesSource struct {
+	File     string `json:"file"`
+	Row      int    `json:"row"`
+	Magazine string `json:"magazine"`
+	Issue    string `json:"issue"` // Such as "1981-03"
+	Page     *int   `json:"page"`  // Null if the page number could not be read
+}
+
+// Given advert data for a range of systems, outputs the aggregated prices as a JSON document
+func outputPricesJSON(w io.Writer, table priceTable) {
+	document := pricesDocument{
+		Rounding: table.rounding,
+		Stamp:    table.stamp,
+		Prices:   table.aggregatedPrices(),
+	}
+	if table.maxDate >= table.minDate {
+		first, last := hcp.FormatQuarter(table.minDate), hcp.FormatQuarter(table.maxDate)
+		document.First, document.Last = &first, &last
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(document); err != nil {
+		log.Fatalln("Cannot write JSON data:", err.Error())
+	}
+}
+
+// Return a record of each system's price in each quarter with one, by system then quarter, as the json and csv outputs hold
+func (table priceTable) aggregatedPrices() []pricesEntry {
+	prices := make([]pricesEntry, 0)
+	for _, key := range table.keys {
+		for index := table.minDate; index <= table.maxDate; index++ {
+			pence := table.systems[key][index-table.minDate]
+			if pence <= 0 {
+				continue
+			}
+			year, quarter := hcp.DecodeIndexByQuarter(index)
+			entry := pricesEntry{System: key, Year: year, Quarter: quarter, Pence: pence, Price: formatPrice(pence, table.rounding), Kind: table.kind(key, index).String()}
+			if table.kind(key, index) == observedPrice {
+				adverts := table.observations[key][index-table.minDate]
+				entry.Adverts = len(adverts)
+				entry.Source = chosenSource(adverts, pence)
+			}
+			prices = append(prices, entry)
+		}
+	}
+	return prices
+}
+
+// Return the source of the first of the adverts whose price is the one published, or nil if there is none
+func chosenSource(adverts []hcp.Advert, pence int) *pricesSource {
+	for _, advert := range adverts {
+		if advert.Price != pence {
+			continue
+		}
+		source := pricesSource{File: advert.File, Row: advert.Row, Magazine: advert.Magazine, Issue: fmt.Sprintf("%04d-%02d", advert.Year, advert.Month)}
+		if advert.Page >= 0 {
+			page := advert.Page
+			source.Page = &page
+		}
+		return &source
+	}
+	return nil
+}
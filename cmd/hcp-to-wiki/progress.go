@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+)
+
+// How often the progress line is redrawn
+const progress_interval = 100 * time.Millisecond
+
+// A progressMeter shows how far a long run has got on a single line of standard error, which is redrawn as the run proceeds.
+// It only draws when standard error is a terminal, so logs and CI output are never cluttered; -no-progress turns it off entirely.
+// A nil progressMeter does nothing, so callers need not check whether progress is being shown.
+type progressMeter struct {
+	drawn time.Time // When the line was last drawn
+	width int       // Length of the line last drawn, so that a shorter one can blank it out
+}
+
+// The progress meter for this run, if progress is being shown.
+// logf removes the progress line before each diagnostic so that the two do not end up on the same line.
+var progress *progressMeter
+
+// Add the -no-progress option to a set of flags, returning a function that creates the progress meter once the flags are parsed
+func addProgressFlag(flags *flag.FlagSet) func() *progressMeter {
+	disabled := flags.Bool("no-progress", false, "do not show progress, even on a terminal")
+	return func() *progressMeter {
+		if *disabled {
+			return nil
+		}
+		if info, err := os.Stderr.Stat(); (err != nil) || (info.Mode()&os.ModeCharDevice == 0) {
+			return nil
+		}
+		return &progressMeter{}
+	}
+}
+
+// Show the progress of a stage, such as "Parsing", which has reached done out of total steps.
+// The line is only redrawn occasionally, except for a stage's final step.
+func (meter *progressMeter) update(stage string, done int, total int, detail string) {
+	if meter == nil {
+		return
+	}
+	if (done < total) && (time.Since(meter.drawn) < progress_interval) {
+		return
+	}
+	meter.drawn = time.Now()
+	line := fmt.Sprintf("%s: %d/%d %s", stage, done, total, detail)
+	fmt.Fprintf(os.Stderr, "\r%-*s", meter.width, line)
+	meter.width = len(line)
+}
+
+// Remove the progress line, so that whatever is printed next starts on a clean line
+func (meter *progressMeter) finish() {
+	if (meter == nil) || (meter.width == 0) {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "\r%*s\r", meter.width, "")
+	meter.width = 0
+}
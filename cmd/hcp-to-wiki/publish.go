@@ -0,0 +1,284 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/AntonioCarlini/home-computer-prices/hcp"
+)
+
+// Implements "publish -api URL [-dry-run] [-yes] page.wiki|directory ...".
+// Publishes generated pages to a MediaWiki wiki. Each page is a file named as by writePages, so the output of
+// "-subpages TITLE -o directory" can be published by naming the directory; a single page can be named directly.
+//
+// Pages whose text has not changed are left alone. For every other page a diff against the wiki's current text
+// is shown; -dry-run stops there. Otherwise the changed pages are only published once confirmed,
+// either by answering the prompt or, for unattended runs, with -yes.
+// Only the generated content of each page is replaced (see generatedPattern), so editors may add text around it.
+// A page whose generated content has been edited on the wiki is refused rather than overwritten, unless -force is given.
+// Credentials come from -credentials and the environment (see wikiCredentials); without any, edits are made anonymously.
+// Edits are spaced out by -edit-interval and made with maxlag set, so that bulk updates do not overload a live wiki.
+// With -languages, the language variants written by -languages in the same directories are published too, each to
+// the sister wiki named in the configuration (see hcp.Language); -api may then be left out to publish only those.
+// Once published, a changelog of what changed in the tables is printed (see tableChanges); -changelog also writes it
+// to a file, for a release note, and -webhook posts it to a chat webhook as the generation run does.
+// Before any page is edited, the text being replaced is saved to -snapshot-dir, so that "rollback" can restore it.
+func runPublish(args []string) {
+	flags := flag.NewFlagSet("publish", flag.ExitOnError)
+	api := flags.String("api", "", "URL of the wiki's api.php")
+	credentialsFilename := flags.String("credentials", "", "JSON file of the bot password or OAuth credentials to edit with (HCP_WIKI_* environment variables override it)")
+	summary := flags.String("summary", "Update home computer prices", "edit summary")
+	dryRun := flags.Bool("dry-run", false, "show what would change on each page without editing anything")
+	yes := flags.Bool("yes", false, "publish without asking for confirmation")
+	maxlag := flags.Int("maxlag", 5, "ask the wiki to refuse edits while its database lags by more than this many seconds (0 disables)")
+	interval := flags.Duration("edit-interval", 10*time.Second, "minimum time between edits")
+	force := flags.Bool("force", false, "replace generated content even where it has been edited on the wiki")
+	configFilename := flags.String("config", "", "JSON file of rules, for the sister wikis of -languages")
+	languages := flags.String("languages", "", "also publish the pages in each of these languages' subdirectories to the language's sister wiki, named in the configuration")
+	changelogFilename := flags.String("changelog", "", "also write the changelog of what was published to this file")
+	webhookURL := flags.String("webhook", "", "post the changelog of what was published to this chat webhook URL")
+	webhookFormat := flags.String("webhook-format", "slack", "the kind of chat webhook: slack, discord or matrix")
+	snapshots := flags.String("snapshot-dir", default_snapshot_dir, "directory in which the text of the pages is saved before they are edited (\"\" saves nothing)")
+	logFilename, logFormat := addLoggingFlags(flags)
+	newProgress := addProgressFlag(flags)
+	flags.Parse(args)
+	setupLogging(*logFilename, *logFormat)
+	progress = newProgress()
+
+	if (*api == "") && (*languages == "") {
+		log.Fatalf("-api or -languages is required\n")
+	}
+	if _, ok := webhookStyles[*webhookFormat]; !ok {
+		log.Fatalf("Unknown webhook format '%s'\n", *webhookFormat)
+	}
+	options := publishOptions{*summary, *dryRun, *yes, *maxlag, *interval, *force, *snapshots}
+
+	// Each wiki to publish to, with its pages and credentials; the language variants are read from subdirectories
+	type target struct {
+		api         string
+		paths       []string
+		credentials string
+	}
+	targets := make([]target, 0)
+	if *api != "" {
+		targets = append(targets, target{*api, flags.Args(), *credentialsFilename})
+	}
+	if *languages != "" {
+		config, err := hcp.LoadConfiguration(*configFilename)
+		if err != nil {
+			log.Fatalf("Cannot load configuration: %s\n", err.Error())
+		}
+		for _, name := range strings.Split(*languages, ",") {
+			language, ok := config.Languages[name]
+			if !ok || (language.Wiki == "") {
+				log.Fatalf("No wiki for language '%s' in the configuration\n", name)
+			}
+			paths := make([]string, 0, flags.NArg())
+			for _, path := range flags.Args() {
+				paths = append(paths, filepath.Join(path, name))
+			}
+			credentials := language.Credentials
+			if credentials == "" {
+				credentials = *credentialsFilename
+			}
+			targets = append(targets, target{language.Wiki, paths, credentials})
+		}
+	}
+
+	// A page refused on one wiki does not stop the others being published, but makes the run fail once they have been
+	// and the changelog of what was published has been written
+	changelog := make([]string, 0, len(targets))
+	refused := 0
+	for _, target := range targets {
+		pages, err := readPages(target.paths)
+		if err != nil {
+			log.Fatalf("Cannot read pages: %s\n", err.Error())
+		}
+		if len(pages) == 0 {
+			log.Fatalf("At least 1 page required but none supplied\n")
+		}
+		credentials, err := loadCredentials(target.credentials)
+		if err != nil {
+			log.Fatalf("Cannot load credentials: %s\n", err.Error())
+		}
+		changes, targetRefused := publishPages(target.api, credentials, pages, options)
+		if changes != "" {
+			changelog = append(changelog, fmt.Sprintf("%s: %s", target.api, changes))
+		}
+		refused += targetRefused
+	}
+
+	if len(changelog) > 0 {
+		for _, line := range changelog {
+			fmt.Printf("Changelog: %s\n", line)
+		}
+		if *changelogFilename != "" {
+			if err := os.WriteFile(*changelogFilename, []byte(strings.Join(changelog, "\n")+"\n"), 0644); err != nil {
+				log.Fatalf("Cannot write changelog: %s\n", err.Error())
+			}
+		}
+		if *webhookURL != "" {
+			message := "hcp-to-wiki published home computer prices:\n" + strings.Join(changelog, "\n")
+			if err := postWebhook(*webhookURL, *webhookFormat, message); err != nil {
+				logf("Cannot post to webhook: %s\n", err.Error())
+			}
+		}
+	}
+	if refused > 0 {
+		log.Fatalf("%d page(s) refused\n", refused)
+	}
+}
+
+// The publish options that apply to every wiki
+type publishOptions struct {
+	summary  string
+	dryRun   bool
+	yes      bool
+	maxlag   int
+	interval time.Duration
+	force    bool
+	snapshot string
+}
+
+// Publish the pages that have changed to the wiki whose api.php is at the given URL.
+// Return a changelog of what changed in the price tables, or "" if nothing was published, and how many pages were refused.
+func publishPages(api string, credentials wikiCredentials, pages []wikiPage, options publishOptions) (string, int) {
+	wiki := newMediaWiki(api, options.maxlag)
+	if err := wiki.authenticate(credentials); err != nil {
+		log.Fatalf("Cannot log in: %s\n", err.Error())
+	}
+
+	// Compare each page with what is on the wiki now.
+	// Only the generated content of a page is replaced; a page whose generated content has been edited by hand is refused.
+	changes := make([]pageChange, 0)
+	refused := 0
+	var oldTables, newTables strings.Builder // Every page that is not refused, as it is and as it would be, for the changelog
+	for i, page := range pages {
+		progress.update("Comparing", i+1, len(pages), "pages")
+		current, timestamp, err := wiki.pageText(page.title)
+		if err != nil {
+			log.Fatalf("Cannot fetch '%s': %s\n", page.title, err.Error())
+		}
+		before, after, unmodified, found := findGenerated(current)
+		switch {
+		case found && !unmodified && !options.force:
+			logf("%s: refused, as the generated content has been edited on the wiki since it was last published (-force overwrites it)\n", page.title)
+			refused++
+			continue
+		case !found && (timestamp != "") && !options.force:
+			logf("%s: refused, as the page exists but has no generated content to replace (-force replaces the whole page)\n", page.title)
+			refused++
+			continue
+		}
+		text := before + wrapGenerated(page.text) + after
+		oldTables.WriteString(current + "\n")
+		newTables.WriteString(text + "\n")
+		// The wiki drops trailing whitespace when a page is saved
+		if strings.TrimRight(current, " \n") == strings.TrimRight(text, " \n") {
+			logf("%s: unchanged\n", page.title)
+			continue
+		}
+		if timestamp == "" {
+			logf("%s: new page\n", page.title)
+		}
+		progress.finish()
+		writeDiff(os.Stdout, page.title+" (wiki)", page.title+" (generated)", current, text)
+		changes = append(changes, pageChange{wikiPage{page.title, text}, timestamp, current})
+	}
+	progress.finish()
+
+	if options.dryRun {
+		logf("%d of %d page(s) would change: %s\n", len(changes), len(pages), compareWikiTables(oldTables.String(), newTables.String()))
+		return "", refused
+	}
+	if len(changes) == 0 {
+		logf("Nothing to publish\n")
+		return "", refused
+	}
+	if !options.yes && !confirm(fmt.Sprintf("Publish %d changed page(s) to %s?", len(changes), api)) {
+		logf("Nothing published\n")
+		return "", refused
+	}
+
+	if options.snapshot != "" {
+		dir, err := saveSnapshot(options.snapshot, api, changes)
+		if err != nil {
+			log.Fatalf("Cannot save snapshot: %s\n", err.Error())
+		}
+		if dir != "" {
+			logf("Snapshot of the pages as they were saved to %s\n", dir)
+		}
+	}
+	token, err := wiki.token("csrf")
+	if err != nil {
+		log.Fatalf("Cannot get an edit token: %s\n", err.Error())
+	}
+	for i, change := range changes {
+		progress.update("Publishing", i, len(changes), "pages")
+		if i > 0 {
+			time.Sleep(options.interval)
+		}
+		if err := wiki.edit(change.page.title, change.page.text, options.summary, token, change.baseTimestamp); err != nil {
+			log.Fatalf("Cannot publish '%s': %s\n", change.page.title, err.Error())
+		}
+		logf("%s: published\n", change.page.title)
+	}
+	progress.finish()
+	return compareWikiTables(oldTables.String(), newTables.String()).String(), refused
+}
+
+// A page whose generated text differs from the wiki's, along with the timestamp and text of the revision it was compared against
+type pageChange struct {
+	page          wikiPage
+	baseTimestamp string
+	previous      string
+}
+
+// Read the pages in the named files and directories.
+// A directory contributes every ".wiki" file in it. Each page's title comes from its file name, reversing writePages.
+// The pages are returned in title order, so a subpage index comes before its subpages.
+func readPages(paths []string) ([]wikiPage, error) {
+	filenames := make([]string, 0)
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			filenames = append(filenames, path)
+			continue
+		}
+		matches, err := filepath.Glob(filepath.Join(path, "*.wiki"))
+		if err != nil {
+			return nil, err
+		}
+		filenames = append(filenames, matches...)
+	}
+
+	pages := make([]wikiPage, 0, len(filenames))
+	for _, filename := range filenames {
+		text, err := os.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		title := strings.ReplaceAll(strings.TrimSuffix(filepath.Base(filename), ".wiki"), "%2F", "/")
+		pages = append(pages, wikiPage{title: title, text: string(text)})
+	}
+	sort.Slice(pages, func(i, j int) bool { return pages[i].title < pages[j].title })
+	return pages, nil
+}
+
+// Ask a yes/no question on the terminal, returning true only if the answer is yes
+func confirm(question string) bool {
+	fmt.Printf("%s [y/N] ", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return (answer == "y") || (answer == "yes")
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"encoding/csv"
+	"io"
+	"log"
+	"strconv"
+
+	"github.com/AntonioCarlini/home-computer-prices/hcp"
+)
+
+// The field named by the row problems of rows whose date could not be read
+const date_problem_field = "YYYY-DD"
+
+// Writes the rows that were rejected because their date could not be read, as CSV, so that fixing them can be tracked as a work queue.
+// Each row is written as it was found, after the file and row it came from and what was wrong with its date.
+func writeQuarantineCSV(w io.Writer, validations []hcp.FileValidation) {
+	out := csv.NewWriter(w)
+	out.Write([]string{"File", "Row", "Error", "Source", "YYYY-MM", "Page", "System", "Price", "", "Kit", "Board"})
+	for _, validation := range validations {
+		for _, problem := range validation.Problems {
+			if !problem.Rejected || (problem.Field != date_problem_field) {
+				continue
+			}
+			out.Write(append([]string{validation.Filename, strconv.Itoa(problem.Row), problem.Err.Error()}, problem.Record...))
+		}
+	}
+	out.Flush()
+	if err := out.Error(); err != nil {
+		log.Fatalln("Cannot write CSV data:", err.Error())
+	}
+}
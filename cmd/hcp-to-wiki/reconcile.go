@@ -0,0 +1,166 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/AntonioCarlini/home-computer-prices/hcp"
+)
+
+// Implements "reconcile [-issue "PCW 1982-06"] first.csv second.csv".
+// Compares two independent transcriptions of the same adverts, row by row, so that mistakes are caught before
+// either is merged. Rows are matched regardless of their order and of the order of the columns. Rows that agree
+// in every column are not mentioned; rows for the same advert (the same source, date, page and system) that differ
+// are listed with the columns that differ, and rows that one file has and the other lacks are listed as such.
+// The Notes and Contributor columns are not compared. -issue compares only the rows for that issue, as when each
+// volunteer's file holds more than the issue both transcribed. The run fails if there is any mismatch.
+func runReconcile(args []string) {
+	flags := flag.NewFlagSet("reconcile", flag.ExitOnError)
+	newIssue := addIssueFlag(flags, "compare only the rows for this issue, such as \"PCW 1982-06\"")
+	inputs := parseInterspersed(flags, args)
+	if len(inputs) != 2 {
+		log.Fatalf("Exactly 2 CSV files required but %d supplied\n", len(inputs))
+	}
+	issue := newIssue()
+
+	first, second := readTranscription(inputs[0], issue), readTranscription(inputs[1], issue)
+	// Rows that agree in every column are set aside first, so that the rest can be paired by the advert they are for
+	unmatched := make([]transcribedRow, 0)
+	for _, row := range first {
+		if i := findTranscribedRow(second, row, true); i >= 0 {
+			second = append(second[:i], second[i+1:]...)
+			continue
+		}
+		unmatched = append(unmatched, row)
+	}
+	matched := len(first) - len(unmatched)
+
+	mismatches := 0
+	for _, row := range unmatched {
+		i := findTranscribedRow(second, row, false)
+		if i < 0 {
+			fmt.Printf("Only in %s: line %d: %s\n", inputs[0], row.line, row)
+			mismatches++
+			continue
+		}
+		other := second[i]
+		second = append(second[:i], second[i+1:]...)
+		for _, column := range row.differences(other) {
+			fmt.Printf("Differ: %s: %s is [%s] in %s line %d but [%s] in %s line %d\n", row.key, column, row.fields[column], inputs[0], row.line, other.fields[column], inputs[1], other.line)
+		}
+		mismatches++
+	}
+	for _, row := range second {
+		fmt.Printf("Only in %s: line %d: %s\n", inputs[1], row.line, row)
+		mismatches++
+	}
+
+	fmt.Printf("%d row(s) agree, %d mismatch(es)\n", matched, mismatches)
+	if mismatches > 0 {
+		log.Fatalf("The transcriptions do not agree\n")
+	}
+}
+
+// The columns that are not compared, as they are expected to differ between transcriptions
+var reconcile_ignored_columns = []string{"Notes", "Contributor"}
+
+// A data row of a transcription: its line and its fields keyed by their heading
+type transcribedRow struct {
+	line   int
+	key    string // The source, date, page and system, identifying the advert
+	fields map[string]string
+}
+
+// Return the row as the advert it is for, as in "PCW 1982-06 p12 ZX81 £49.95"
+func (row transcribedRow) String() string {
+	return strings.TrimSpace(row.key + " " + row.fields["Price"])
+}
+
+// Return the headings of the columns in which the two rows differ, in alphabetical order
+func (row transcribedRow) differences(other transcribedRow) []string {
+	columns := make([]string, 0)
+	for column, value := range row.fields {
+		if other.fields[column] != value {
+			columns = append(columns, column)
+		}
+	}
+	for column, value := range other.fields {
+		if _, ok := row.fields[column]; !ok && (value != "") {
+			columns = append(columns, column)
+		}
+	}
+	sort.Strings(columns)
+	return columns
+}
+
+// Return the index of the first of the rows that is for the same advert as row, and, if exact, agrees with it
+// in every column; or -1 if there is none
+func findTranscribedRow(rows []transcribedRow, row transcribedRow, exact bool) int {
+	for i, candidate := range rows {
+		if (candidate.key == row.key) && (!exact || (len(row.differences(candidate)) == 0)) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Read the data rows of a CSV file, or only those from the issue if it is not nil; a file that cannot be read is fatal
+func readTranscription(filename string, issue *hcp.Issue) []transcribedRow {
+	f, err := os.Open(filename)
+	if err != nil {
+		log.Fatalf("Cannot open CSV: %s\n", err.Error())
+	}
+	data, _, err := hcp.ReadCSVRows(f, 0)
+	f.Close()
+	if err != nil {
+		log.Fatalf("Cannot read CSV data from '%s': %s\n", filename, err.Error())
+	}
+
+	rows := make([]transcribedRow, 0)
+	var headings []string
+	for i, record := range data {
+		if hcp.IsComment(record) {
+			continue
+		}
+		if hcp.IsHeader(record) {
+			headings = make([]string, len(record))
+			for column, heading := range record {
+				headings[column] = strings.TrimSpace(heading)
+				if headings[column] == "" {
+					headings[column] = "column " + strconv.Itoa(column+1)
+				}
+			}
+			continue
+		}
+		// As when the data is parsed: nothing before the first header line is data, nor is a row without a system
+		if (headings == nil) || (len(record) < 4) || (strings.TrimSpace(record[3]) == "") {
+			continue
+		}
+		if (issue != nil) && !issue.HasRow(record) {
+			continue
+		}
+		row := transcribedRow{line: i + 1, fields: make(map[string]string)}
+		for column, value := range record {
+			heading := "column " + strconv.Itoa(column+1)
+			if column < len(headings) {
+				heading = headings[column]
+			}
+			if value = strings.TrimSpace(value); !sliceContainsString(reconcile_ignored_columns, heading) && (value != "") {
+				row.fields[heading] = value
+			}
+		}
+		// The Source, YYYY-MM, Page and System columns of the advert CSV format
+		key := make([]string, 0, 4)
+		for _, value := range record[:4] {
+			key = append(key, strings.TrimSpace(value))
+		}
+		row.key = strings.Join(key, " ")
+		rows = append(rows, row)
+	}
+	return rows
+}
@@ -0,0 +1,416 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strconv"
+
+	"github.com/AntonioCarlini/home-computer-prices/hcp"
+)
+
+// Implements "volume [-o report.csv] data.csv ...".
+// Outputs, as CSV, the number of adverts seen in each magazine for every quarter in the data.
+// This tracks the health of the magazine advertising market itself and shows which periods have been transcribed.
+// Quarters with far fewer adverts than usual are listed afterwards as possibly under-transcribed.
+func runVolumeReport(args []string) {
+	flags := flag.NewFlagSet("volume", flag.ExitOnError)
+	outputFilename := flags.String("o", "", "write the report to this file instead of standard output")
+	flags.Parse(args)
+	if flags.NArg() < 1 {
+		log.Fatalf("At least 1 argument required but %d supplied\n", flags.NArg())
+	}
+
+	adverts, minDate, maxDate, _ := loadAdverts(flags.Args())
+	if len(adverts) == 0 {
+		log.Fatalf("No adverts found\n")
+	}
+
+	// counts[magazine][date-index - minDate] is the number of adverts
+	counts := make(map[string][]int)
+	for _, advert := range adverts {
+		if _, ok := counts[advert.Magazine]; !ok {
+			counts[advert.Magazine] = make([]int, maxDate-minDate+1)
+		}
+		counts[advert.Magazine][hcp.BuildIndexFromAdvert(advert)-minDate]++
+	}
+	magazines := make([]string, 0, len(counts))
+	for magazine := range counts {
+		magazines = append(magazines, magazine)
+	}
+	sort.Strings(magazines)
+
+	totals := make([]int, maxDate-minDate+1)
+	writeOutput(*outputFilename, func(w io.Writer) {
+		out := csv.NewWriter(w)
+		out.Write(append(append([]string{"Quarter"}, magazines...), "Total"))
+		for index := minDate; index <= maxDate; index++ {
+			record := []string{hcp.FormatQuarter(index)}
+			for _, magazine := range magazines {
+				count := counts[magazine][index-minDate]
+				totals[index-minDate] += count
+				record = append(record, strconv.Itoa(count))
+			}
+			out.Write(append(record, strconv.Itoa(totals[index-minDate])))
+		}
+		out.Flush()
+		if err := out.Error(); err != nil {
+			log.Fatalln("Cannot write CSV data:", err.Error())
+		}
+	})
+
+	// A quarter is possibly under-transcribed if it has less than a quarter of the median number of adverts
+	sorted := append([]int(nil), totals...)
+	sort.Ints(sorted)
+	median := sorted[len(sorted)/2]
+	for index := minDate; index <= maxDate; index++ {
+		if totals[index-minDate]*4 < median {
+			fmt.Printf("Possibly under-transcribed: %s has %d adverts (median %d)\n", hcp.FormatQuarter(index), totals[index-minDate], median)
+		}
+	}
+}
+
+// Implements "advert-chart [-o chart.svg] [-config rules.json] [-top N] [-systems A,B] data.csv ...".
+// Draws an SVG chart of how many adverts each system attracted in each quarter, a proxy for its market presence.
+// The rename and suppress rules are applied, so the systems match those in the price tables.
+// By default the systems with the most adverts are charted; -systems names them explicitly.
+func runAdvertChart(args []string) {
+	flags := flag.NewFlagSet("advert-chart", flag.ExitOnError)
+	outputFilename := flags.String("o", "", "write the chart to this file instead of standard output")
+	configFilename := flags.String("config", "", "JSON file of rules applied to the data (default: the built-in rules)")
+	top := flags.Int("top", 10, "chart this many of the most advertised systems")
+	systemList := flags.String("systems", "", "comma-separated list of systems to chart instead of the most advertised, by their published names or aliases")
+	flags.Parse(args)
+	if flags.NArg() < 1 {
+		log.Fatalf("At least 1 argument required but %d supplied\n", flags.NArg())
+	}
+
+	config, err := hcp.LoadConfiguration(*configFilename)
+	if err != nil {
+		log.Fatalf("Cannot load configuration: %s\n", err.Error())
+	}
+	adverts, minDate, maxDate, _ := loadAdverts(flags.Args())
+
+	counts := make(map[string][]int)
+	totals := make(map[string]int)
+	for _, advert := range adverts {
+		name, ok := config.PublishedName(advert.System)
+		if !ok {
+			continue
+		}
+		if _, ok := counts[name]; !ok {
+			counts[name] = make([]int, maxDate-minDate+1)
+		}
+		counts[name][hcp.BuildIndexFromAdvert(advert)-minDate]++
+		totals[name]++
+	}
+
+	var names []string
+	if *systemList != "" {
+		names = splitSystems(*systemList, config)
+		for _, name := range names {
+			if _, ok := counts[name]; !ok {
+				log.Fatalf("No adverts found for system '%s'\n", name)
+			}
+		}
+	} else {
+		for name := range counts {
+			names = append(names, name)
+		}
+		sort.Slice(names, func(i, j int) bool {
+			if totals[names[i]] != totals[names[j]] {
+				return totals[names[i]] > totals[names[j]]
+			}
+			return names[i] < names[j]
+		})
+		if len(names) > *top {
+			names = names[:*top]
+		}
+	}
+
+	series := make([]chartSeries, 0, len(names))
+	for _, name := range names {
+		series = append(series, chartSeries{name, counts[name]})
+	}
+	writeOutput(*outputFilename, func(w io.Writer) {
+		writeLineChart(w, "Adverts per quarter", minDate, maxDate, series, strconv.Itoa, nil)
+	})
+}
+
+// Implements "seasonal [-o report.csv] [-config rules.json] [-aggregate min] data.csv ...".
+// Compares each system's Q4 (Christmas) price with its average price over the quarters of that year that have prices,
+// to quantify seasonal discounting. Only years with a Q4 price and at least one other quarter's price are compared.
+// The report is CSV with one row per system and year, followed by an "All systems" row per year and overall,
+// each giving the mean difference.
+func runSeasonalReport(args []string) {
+	flags := flag.NewFlagSet("seasonal", flag.ExitOnError)
+	outputFilename := flags.String("o", "", "write the report to this file instead of standard output")
+	configFilename := flags.String("config", "", "JSON file of rules applied to the data (default: the built-in rules)")
+	aggregation := flags.String("aggregate", "min", "how each quarter's price is chosen from its adverts: min, mode or median")
+	flags.Parse(args)
+	if flags.NArg() < 1 {
+		log.Fatalf("At least 1 argument required but %d supplied\n", flags.NArg())
+	}
+	if _, ok := hcp.PriceAggregations[*aggregation]; !ok {
+		log.Fatalf("Unknown aggregation '%s'\n", *aggregation)
+	}
+
+	config, err := hcp.LoadConfiguration(*configFilename)
+	if err != nil {
+		log.Fatalf("Cannot load configuration: %s\n", err.Error())
+	}
+	adverts, minDate, maxDate, _ := loadAdverts(flags.Args())
+	systems, _ := hcp.PublishedPrices(adverts, minDate, maxDate, config, *aggregation)
+	keys := sortedKeys(systems)
+
+	minYear, _ := hcp.DecodeIndexByQuarter(minDate)
+	maxYear, _ := hcp.DecodeIndexByQuarter(maxDate)
+	differencesByYear := make(map[int][]float64)
+	allDifferences := make([]float64, 0)
+
+	writeOutput(*outputFilename, func(w io.Writer) {
+		out := csv.NewWriter(w)
+		out.Write([]string{"System", "Year", "Q4 price", "Year average", "Q4 difference %"})
+		for _, key := range keys {
+			prices := systems[key]
+			for year := minYear; year <= maxYear; year++ {
+				total, count, q4 := 0, 0, 0
+				for quarter := 1; quarter <= 4; quarter++ {
+					index := hcp.BuildIndexFromYearAndQuarter(year, quarter)
+					if (index < minDate) || (index > maxDate) || (prices[index-minDate] <= 0) {
+						continue
+					}
+					total += prices[index-minDate]
+					count++
+					if quarter == 4 {
+						q4 = prices[index-minDate]
+					}
+				}
+				if (q4 <= 0) || (count < 2) {
+					continue
+				}
+				average := float64(total) / float64(count)
+				difference := (float64(q4) - average) * 100 / average
+				differencesByYear[year] = append(differencesByYear[year], difference)
+				allDifferences = append(allDifferences, difference)
+				out.Write([]string{key, strconv.Itoa(year), formatPrice(q4, "exact"), fmt.Sprintf("%.2f", average/100), fmt.Sprintf("%.1f", difference)})
+			}
+		}
+		for year := minYear; year <= maxYear; year++ {
+			if differences, ok := differencesByYear[year]; ok {
+				out.Write([]string{"All systems", strconv.Itoa(year), "", "", fmt.Sprintf("%.1f", meanOf(differences))})
+			}
+		}
+		if len(allDifferences) > 0 {
+			out.Write([]string{"All systems", "All years", "", "", fmt.Sprintf("%.1f", meanOf(allDifferences))})
+		}
+		out.Flush()
+		if err := out.Error(); err != nil {
+			log.Fatalln("Cannot write CSV data:", err.Error())
+		}
+	})
+}
+
+// Return the mean of a non-empty list of values
+func meanOf(values []float64) float64 {
+	total := 0.0
+	for _, value := range values {
+		total += value
+	}
+	return total / float64(len(values))
+}
+
+// Implements "quarter [-o report.csv] [-config rules.json] [-issue "PCW 1983-02"] data.csv ... 1983Q1".
+// Lists, as CSV, every system advertised in the quarter with its cheapest advert and where that advert was found;
+// with -issue, only that issue's adverts are considered.
+// The rename and suppress rules are applied, so the systems match those in the price tables;
+// of equally cheap adverts, the first in the data is listed.
+func runQuarterReport(args []string) {
+	flags := flag.NewFlagSet("quarter", flag.ExitOnError)
+	outputFilename := flags.String("o", "", "write the report to this file instead of standard output")
+	configFilename := flags.String("config", "", "JSON file of rules applied to the data (default: the built-in rules)")
+	newIssue := addIssueFlag(flags, "list only the adverts from this issue, such as \"PCW 1983-02\"")
+	args = parseInterspersed(flags, args)
+	issueFilter = newIssue()
+	if len(args) < 2 {
+		log.Fatalf("At least 2 arguments required but %d supplied\n", len(args))
+	}
+	index, err := hcp.ParseQuarter(args[len(args)-1])
+	if err != nil {
+		log.Fatalf("Cannot report: %s\n", err.Error())
+	}
+
+	config, err := hcp.LoadConfiguration(*configFilename)
+	if err != nil {
+		log.Fatalf("Cannot load configuration: %s\n", err.Error())
+	}
+	adverts, _, _, _ := loadAdverts(args[:len(args)-1])
+	cheapest := hcp.BuildByDate(publishedAdverts(adverts, config))[index]
+	systems := make([]string, 0, len(cheapest))
+	for system := range cheapest {
+		systems = append(systems, system)
+	}
+	sort.Strings(systems)
+
+	writeOutput(*outputFilename, func(w io.Writer) {
+		out := csv.NewWriter(w)
+		out.Write([]string{"System", "Price", "Magazine", "Issue", "Page", "File", "Row"})
+		for _, system := range systems {
+			advert := cheapest[system]
+			page := ""
+			if advert.Page >= 0 {
+				page = "p" + strconv.Itoa(advert.Page)
+			}
+			out.Write([]string{system, formatPrice(advert.Price, "exact"), advert.Magazine, fmt.Sprintf("%04d-%02d", advert.Year, advert.Month), page, advert.File, strconv.Itoa(advert.Row)})
+		}
+		out.Flush()
+		if err := out.Error(); err != nil {
+			log.Fatalln("Cannot write CSV data:", err.Error())
+		}
+	})
+}
+
+// Implements "freshness [-o report.csv] [-expect 1986Q4] data.csv ...".
+// Outputs, as CSV, the first and most recent quarters with adverts for each magazine and for the data as a whole,
+// to track how far transcription has got. With -expect, a warning is given if the data has not been extended to that quarter,
+// along with each magazine that stops short of it.
+func runFreshnessReport(args []string) {
+	flags := flag.NewFlagSet("freshness", flag.ExitOnError)
+	outputFilename := flags.String("o", "", "write the report to this file instead of standard output")
+	expectText := flags.String("expect", "", "warn if the data does not reach this quarter, such as 1986Q4")
+	flags.Parse(args)
+	if flags.NArg() < 1 {
+		log.Fatalf("At least 1 argument required but %d supplied\n", flags.NArg())
+	}
+	expected := -1
+	if *expectText != "" {
+		index, err := hcp.ParseQuarter(*expectText)
+		if err != nil {
+			log.Fatalf("Cannot report: %s\n", err.Error())
+		}
+		expected = index
+	}
+
+	adverts, minDate, maxDate, _ := loadAdverts(flags.Args())
+	if len(adverts) == 0 {
+		log.Fatalf("No adverts found\n")
+	}
+
+	// first[magazine] and last[magazine] are the date-indices of its earliest and latest adverts
+	first := make(map[string]int)
+	last := make(map[string]int)
+	counts := make(map[string]int)
+	for _, advert := range adverts {
+		index := hcp.BuildIndexFromAdvert(advert)
+		if existing, ok := first[advert.Magazine]; !ok || (index < existing) {
+			first[advert.Magazine] = index
+		}
+		if existing, ok := last[advert.Magazine]; !ok || (index > existing) {
+			last[advert.Magazine] = index
+		}
+		counts[advert.Magazine]++
+	}
+	magazines := make([]string, 0, len(counts))
+	for magazine := range counts {
+		magazines = append(magazines, magazine)
+	}
+	sort.Strings(magazines)
+
+	writeOutput(*outputFilename, func(w io.Writer) {
+		out := csv.NewWriter(w)
+		out.Write([]string{"Magazine", "First", "Latest", "Adverts"})
+		for _, magazine := range magazines {
+			out.Write([]string{magazine, hcp.FormatQuarter(first[magazine]), hcp.FormatQuarter(last[magazine]), strconv.Itoa(counts[magazine])})
+		}
+		out.Write([]string{"All magazines", hcp.FormatQuarter(minDate), hcp.FormatQuarter(maxDate), strconv.Itoa(len(adverts))})
+		out.Flush()
+		if err := out.Error(); err != nil {
+			log.Fatalln("Cannot write CSV data:", err.Error())
+		}
+	})
+
+	if expected >= 0 {
+		if maxDate < expected {
+			fmt.Printf("Out of date: the data ends at %s but is expected to reach %s\n", hcp.FormatQuarter(maxDate), hcp.FormatQuarter(expected))
+		}
+		for _, magazine := range magazines {
+			if last[magazine] < expected {
+				fmt.Printf("Behind: %s ends at %s\n", magazine, hcp.FormatQuarter(last[magazine]))
+			}
+		}
+	}
+}
+
+// Implements "contributors [-o report.csv] [-names] [-issue "PCW 1982-06"] data.csv ...".
+// Outputs, as CSV, the rows transcribed by each contributor, as named in the optional Contributor column,
+// with how many were accepted, rejected and warned about and the percentage rejected, so that volunteers can be
+// acknowledged and proofreading aimed where it is most needed. Contributors are listed by rows transcribed, most first.
+// The report is anonymised unless -names is given: each contributor is shown only by their place in that order,
+// as "Contributor 1" and so on. Rows with no contributor are shown as "(none)". -issue counts only that issue's rows.
+func runContributorReport(args []string) {
+	flags := flag.NewFlagSet("contributors", flag.ExitOnError)
+	outputFilename := flags.String("o", "", "write the report to this file instead of standard output")
+	names := flags.Bool("names", false, "show the contributors' names rather than anonymising them")
+	newIssue := addIssueFlag(flags, "count only the rows for this issue, such as \"PCW 1982-06\"")
+	flags.Parse(args)
+	issueFilter = newIssue()
+	if flags.NArg() < 1 {
+		log.Fatalf("At least 1 argument required but %d supplied\n", flags.NArg())
+	}
+
+	_, _, _, validations := loadAdverts(flags.Args())
+	totals := make(map[string]*hcp.ContributorCounts)
+	for _, validation := range validations {
+		for contributor, counts := range validation.Contributors {
+			total, ok := totals[contributor]
+			if !ok {
+				total = &hcp.ContributorCounts{}
+				totals[contributor] = total
+			}
+			total.Rows += counts.Rows
+			total.Accepted += counts.Accepted
+			total.Rejected += counts.Rejected
+			total.Warnings += counts.Warnings
+		}
+	}
+	if len(totals) == 0 {
+		log.Fatalf("No Contributor column found\n")
+	}
+	contributors := make([]string, 0, len(totals))
+	for contributor := range totals {
+		contributors = append(contributors, contributor)
+	}
+	// Ties are broken by name, so that the anonymised order is the same from one run to the next
+	sort.Slice(contributors, func(i, j int) bool {
+		if totals[contributors[i]].Rows != totals[contributors[j]].Rows {
+			return totals[contributors[i]].Rows > totals[contributors[j]].Rows
+		}
+		return contributors[i] < contributors[j]
+	})
+
+	writeOutput(*outputFilename, func(w io.Writer) {
+		out := csv.NewWriter(w)
+		out.Write([]string{"Contributor", "Rows", "Accepted", "Rejected", "Warnings", "Rejected %"})
+		place := 0
+		for _, contributor := range contributors {
+			counts := totals[contributor]
+			name := contributor
+			switch {
+			case contributor == "":
+				name = "(none)"
+			case !*names:
+				place++
+				name = fmt.Sprintf("Contributor %d", place)
+			}
+			rejected := 100 * float64(counts.Rejected) / float64(counts.Rows)
+			out.Write([]string{name, strconv.Itoa(counts.Rows), strconv.Itoa(counts.Accepted), strconv.Itoa(counts.Rejected), strconv.Itoa(counts.Warnings), fmt.Sprintf("%.1f", rejected)})
+		}
+		out.Flush()
+		if err := out.Error(); err != nil {
+			log.Fatalln("Cannot write CSV data:", err.Error())
+		}
+	})
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Before publish edits any page, it saves the page's current text as a snapshot: a directory under -snapshot-dir
+// named for the time of the run and the wiki's api.php, such as ".hcp-snapshots/20261014-093000/wiki.example.org%2Fw%2Fapi.php/",
+// holding one file per page named as by writePages. Pages that publish creates have nothing to save.
+
+// The directory in which snapshots are kept unless -snapshot-dir says otherwise
+const default_snapshot_dir = ".hcp-snapshots"
+
+// The layout of the time in the name of a snapshot, which sorts in the order the snapshots were taken
+const snapshot_time_layout = "20060102-150405"
+
+// Return the name under which the snapshots of the wiki whose api.php is at the given URL are kept.
+// The path is part of the name, as sister wikis may share a host, such as example.org/en/ and example.org/fr/.
+func snapshotWiki(api string) string {
+	if parsed, err := url.Parse(api); (err == nil) && (parsed.Host != "") {
+		return strings.ReplaceAll(parsed.Host, ":", "_") + url.PathEscape(parsed.Path)
+	}
+	return strings.ReplaceAll(api, "/", "%2F")
+}
+
+// Save the text of the changed pages as they were, before they are edited, in a new snapshot under base.
+// Return the directory the snapshot was saved in, or "" if none of the pages existed before.
+func saveSnapshot(base string, api string, changes []pageChange) (string, error) {
+	pages := make([]wikiPage, 0, len(changes))
+	for _, change := range changes {
+		if change.baseTimestamp != "" {
+			pages = append(pages, wikiPage{change.page.title, change.previous})
+		}
+	}
+	if len(pages) == 0 {
+		return "", nil
+	}
+	// A second snapshot of the same wiki in the same second, as when a rollback follows at once, is given a suffix
+	name := time.Now().Format(snapshot_time_layout)
+	dir := filepath.Join(base, name, snapshotWiki(api))
+	for n := 2; ; n++ {
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			break
+		}
+		dir = filepath.Join(base, fmt.Sprintf("%s-%02d", name, n), snapshotWiki(api))
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	writePages(dir, pages)
+	return dir, nil
+}
+
+// Return the names of the snapshots under base that hold pages of the wiki whose api.php is at the given URL, oldest first
+func listSnapshots(base string, api string) ([]string, error) {
+	entries, err := os.ReadDir(base)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if info, err := os.Stat(filepath.Join(base, entry.Name(), snapshotWiki(api))); entry.IsDir() && (err == nil) && info.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Implements "rollback -api URL [-snapshot NAME] [-dry-run] [-yes]".
+// Restores the pages in a snapshot taken by publish, to undo the publication of a bad dataset.
+// The most recent snapshot of the wiki is restored unless -snapshot names another, such as 20261014-093000; -list lists them.
+// Each page is put back whole, as it was before publish edited it. As with publish, a diff is shown for each page
+// that would change, -dry-run stops there and otherwise the rollback is only made once confirmed or with -yes.
+// The text being replaced is itself saved as a snapshot first, so a rollback can be rolled back.
+func runRollback(args []string) {
+	flags := flag.NewFlagSet("rollback", flag.ExitOnError)
+	api := flags.String("api", "", "URL of the wiki's api.php")
+	credentialsFilename := flags.String("credentials", "", "JSON file of the bot password or OAuth credentials to edit with (HCP_WIKI_* environment variables override it)")
+	base := flags.String("snapshot-dir", default_snapshot_dir, "directory in which publish saved the snapshots")
+	name := flags.String("snapshot", "", "the snapshot to restore (default: the most recent)")
+	list := flags.Bool("list", false, "list the snapshots of the wiki instead of restoring one")
+	summary := flags.String("summary", "Roll back home computer prices", "edit summary")
+	dryRun := flags.Bool("dry-run", false, "show what would change on each page without editing anything")
+	yes := flags.Bool("yes", false, "roll back without asking for confirmation")
+	maxlag := flags.Int("maxlag", 5, "ask the wiki to refuse edits while its database lags by more than this many seconds (0 disables)")
+	interval := flags.Duration("edit-interval", 10*time.Second, "minimum time between edits")
+	logFilename, logFormat := addLoggingFlags(flags)
+	flags.Parse(args)
+	setupLogging(*logFilename, *logFormat)
+
+	if *api == "" {
+		log.Fatalf("-api is required\n")
+	}
+	snapshots, err := listSnapshots(*base, *api)
+	if err != nil {
+		log.Fatalf("Cannot read snapshots: %s\n", err.Error())
+	}
+	if *list {
+		for _, snapshot := range snapshots {
+			fmt.Println(snapshot)
+		}
+		return
+	}
+	if len(snapshots) == 0 {
+		log.Fatalf("No snapshots of %s in '%s'\n", *api, *base)
+	}
+	snapshot := snapshots[len(snapshots)-1]
+	if *name != "" {
+		if !sliceContainsString(snapshots, *name) {
+			log.Fatalf("No snapshot '%s' of %s in '%s'\n", *name, *api, *base)
+		}
+		snapshot = *name
+	}
+	pages, err := readPages([]string{filepath.Join(*base, snapshot, snapshotWiki(*api))})
+	if err != nil {
+		log.Fatalf("Cannot read snapshot: %s\n", err.Error())
+	}
+	credentials, err := loadCredentials(*credentialsFilename)
+	if err != nil {
+		log.Fatalf("Cannot load credentials: %s\n", err.Error())
+	}
+	wiki := newMediaWiki(*api, *maxlag)
+	if err := wiki.authenticate(credentials); err != nil {
+		log.Fatalf("Cannot log in: %s\n", err.Error())
+	}
+
+	changes := make([]pageChange, 0)
+	for _, page := range pages {
+		current, timestamp, err := wiki.pageText(page.title)
+		if err != nil {
+			log.Fatalf("Cannot fetch '%s': %s\n", page.title, err.Error())
+		}
+		if timestamp == "" {
+			logf("%s: no longer on the wiki, so not restored\n", page.title)
+			continue
+		}
+		if strings.TrimRight(current, " \n") == strings.TrimRight(page.text, " \n") {
+			logf("%s: unchanged\n", page.title)
+			continue
+		}
+		writeDiff(os.Stdout, page.title+" (wiki)", page.title+" (snapshot "+snapshot+")", current, page.text)
+		changes = append(changes, pageChange{page, timestamp, current})
+	}
+
+	if *dryRun {
+		logf("%d of %d page(s) would be rolled back to snapshot %s\n", len(changes), len(pages), snapshot)
+		return
+	}
+	if len(changes) == 0 {
+		logf("Nothing to roll back\n")
+		return
+	}
+	if !*yes && !confirm(fmt.Sprintf("Roll back %d page(s) on %s to snapshot %s?", len(changes), *api, snapshot)) {
+		logf("Nothing rolled back\n")
+		return
+	}
+
+	dir, err := saveSnapshot(*base, *api, changes)
+	if err != nil {
+		log.Fatalf("Cannot save snapshot: %s\n", err.Error())
+	}
+	if dir != "" {
+		logf("Snapshot of the pages as they were saved to %s\n", dir)
+	}
+	token, err := wiki.token("csrf")
+	if err != nil {
+		log.Fatalf("Cannot get an edit token: %s\n", err.Error())
+	}
+	for i, change := range changes {
+		if i > 0 {
+			time.Sleep(*interval)
+		}
+		if err := wiki.edit(change.page.title, change.page.text, *summary, token, change.baseTimestamp); err != nil {
+			log.Fatalf("Cannot roll back '%s': %s\n", change.page.title, err.Error())
+		}
+		logf("%s: rolled back\n", change.page.title)
+	}
+}
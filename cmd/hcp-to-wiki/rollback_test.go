@@ -0,0 +1,23 @@
+package main
+
+import "testing"
+
+func TestSnapshotWiki(t *testing.T) {
+	tests := []struct {
+		api  string
+		want string
+	}{
+		{"https://wiki.example.org/w/api.php", "wiki.example.org%2Fw%2Fapi.php"},
+		{"http://localhost:8080/api.php", "localhost_8080%2Fapi.php"},
+		{"https://example.org", "example.org"},
+		{"api.php", "api.php"},
+	}
+	for _, test := range tests {
+		if got := snapshotWiki(test.api); got != test.want {
+			t.Errorf("snapshotWiki(%q) = %q; want %q", test.api, got, test.want)
+		}
+	}
+	if snapshotWiki("https://example.org/en/api.php") == snapshotWiki("https://example.org/fr/api.php") {
+		t.Errorf("snapshotWiki() gives sister wikis on one host the same name")
+	}
+}
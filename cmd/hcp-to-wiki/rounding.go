@@ -0,0 +1,26 @@
+package main
+
+import "fmt"
+
+// The ways in which a price held in pence may be published, selected with -price-rounding.
+// Prices are often something like £99.95, which most tables show as whole pounds.
+// o trunc drops the pennies (£99.95 is shown as 99), which is what the tables have always done
+// o round rounds to the nearest pound (£99.95 is shown as 100; £99.50 rounds up)
+// o ceil rounds any pennies up to the next pound
+// o exact shows the pennies when there are any (£99.95 is shown as 99.95, £99.00 as 99)
+var priceRoundings = map[string]func(pence int) string{
+	"trunc": func(pence int) string { return fmt.Sprintf("%d", pence/100) },
+	"round": func(pence int) string { return fmt.Sprintf("%d", (pence+50)/100) },
+	"ceil":  func(pence int) string { return fmt.Sprintf("%d", (pence+99)/100) },
+	"exact": func(pence int) string {
+		if pence%100 == 0 {
+			return fmt.Sprintf("%d", pence/100)
+		}
+		return fmt.Sprintf("%d.%02d", pence/100, pence%100)
+	},
+}
+
+// Given a price in pence, return it as a number of pounds (without any currency symbol) according to the rounding policy
+func formatPrice(pence int, rounding string) string {
+	return priceRoundings[rounding](pence)
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"sort"
+	"strconv"
+
+	"github.com/AntonioCarlini/home-computer-prices/hcp"
+)
+
+// Implements "sample [-n 50] [-seed 1] [-o sample.csv] [-issue "PCW 1982-06"] data.csv ...".
+// Outputs, as CSV, a random sample of the adverts for checking by hand against the magazine scans.
+// The sample is stratified by magazine and year: each magazine's year contributes in proportion to its adverts,
+// so that a sample is not all from the few best-transcribed titles. The same -seed and data always give the same sample,
+// so that a proofreading round can be repeated and shared. The sample is listed in magazine, issue and page order.
+// -issue samples only the adverts from that issue, to proofread a newly transcribed issue.
+func runSample(args []string) {
+	flags := flag.NewFlagSet("sample", flag.ExitOnError)
+	size := flags.Int("n", 50, "how many adverts to sample")
+	seed := flags.Int64("seed", 1, "seed of the random selection")
+	outputFilename := flags.String("o", "", "write the sample to this file instead of standard output")
+	newIssue := addIssueFlag(flags, "sample only the adverts from this issue, such as \"PCW 1982-06\"")
+	flags.Parse(args)
+	issueFilter = newIssue()
+	if flags.NArg() < 1 {
+		log.Fatalf("At least 1 argument required but %d supplied\n", flags.NArg())
+	}
+	if *size < 1 {
+		log.Fatalf("-n must be at least 1\n")
+	}
+
+	adverts, _, _, _ := loadAdverts(flags.Args())
+	// An advert dated only by year is one row placed in several months; each row is sampled once
+	type rowKey struct {
+		file string
+		row  int
+	}
+	type stratumKey struct {
+		magazine string
+		year     int
+	}
+	seen := make(map[rowKey]bool)
+	strata := make(map[stratumKey][]hcp.Advert)
+	keys := make([]stratumKey, 0)
+	rows := 0
+	for _, advert := range adverts {
+		if seen[rowKey{advert.File, advert.Row}] {
+			continue
+		}
+		seen[rowKey{advert.File, advert.Row}] = true
+		key := stratumKey{advert.Magazine, advert.Year}
+		if _, ok := strata[key]; !ok {
+			keys = append(keys, key)
+		}
+		strata[key] = append(strata[key], advert)
+		rows++
+	}
+	if rows == 0 {
+		log.Fatalf("No adverts found\n")
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].magazine != keys[j].magazine {
+			return keys[i].magazine < keys[j].magazine
+		}
+		return keys[i].year < keys[j].year
+	})
+
+	// Share out the sample in proportion to each stratum's rows, the remainders going to the largest fractions
+	shares := make([]int, len(keys))
+	remainders := make([]int, len(keys))
+	allocated := 0
+	wanted := min(*size, rows)
+	for i, key := range keys {
+		shares[i] = wanted * len(strata[key]) / rows
+		remainders[i] = wanted * len(strata[key]) % rows
+		allocated += shares[i]
+	}
+	order := make([]int, len(keys))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return remainders[order[a]] > remainders[order[b]] })
+	for _, i := range order[:wanted-allocated] {
+		shares[i]++
+	}
+
+	random := rand.New(rand.NewSource(*seed))
+	sample := make([]hcp.Advert, 0, wanted)
+	for i, key := range keys {
+		stratum := strata[key]
+		random.Shuffle(len(stratum), func(a, b int) { stratum[a], stratum[b] = stratum[b], stratum[a] })
+		sample = append(sample, stratum[:shares[i]]...)
+	}
+	sort.SliceStable(sample, func(i, j int) bool {
+		a, b := sample[i], sample[j]
+		switch {
+		case a.Magazine != b.Magazine:
+			return a.Magazine < b.Magazine
+		case a.Year != b.Year:
+			return a.Year < b.Year
+		case a.Month != b.Month:
+			return a.Month < b.Month
+		case a.Page != b.Page:
+			return a.Page < b.Page
+		default:
+			return a.System < b.System
+		}
+	})
+
+	writeOutput(*outputFilename, func(w io.Writer) {
+		out := csv.NewWriter(w)
+		out.Write([]string{"Magazine", "Issue", "Page", "System", "Price", "Ex VAT", "File", "Row"})
+		for _, advert := range sample {
+			// A page number that could not be read is not worth repeating
+			page := ""
+			if advert.Page >= 0 {
+				page = "p" + strconv.Itoa(advert.Page)
+			}
+			issue := fmt.Sprintf("%04d-%02d", advert.Year, advert.Month)
+			if advert.ApproximateDate {
+				issue = strconv.Itoa(advert.Year)
+			}
+			exVAT := "N"
+			if advert.ExVAT {
+				exVAT = "Y"
+			}
+			out.Write([]string{advert.Magazine, issue, page, advert.System, formatPrice(advert.Price, "exact"), exVAT, advert.File, strconv.Itoa(advert.Row)})
+		}
+		out.Flush()
+		if err := out.Error(); err != nil {
+			log.Fatalln("Cannot write CSV data:", err.Error())
+		}
+	})
+}
@@ -0,0 +1,363 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/AntonioCarlini/home-computer-prices/hcp"
+)
+
+// Implements "serve [-listen host:port] [-refresh interval] [-config rules.json] [-aggregate min|mode|median] source ...",
+// or "serve -sqlite dataset.sqlite [-listen host:port] [-config rules.json]" to answer from an exported database (see servesqlite.go).
+// Loads the data, then answers queries about it as JSON over HTTP until stopped.
+// Each source is a CSV file or an http(s) URL of CSV data, such as the "Publish to the web" CSV link of a Google Sheet.
+// With -refresh the sources are fetched again at that interval and the new data replaces the old in one step,
+// so that a long-running public instance stays current without being restarted; if fetching fails, the old data is kept.
+// Responses other than /api/status carry an ETag, from the dataset's digest, and a Last-Modified time, when the data last
+// changed; a request with a matching If-None-Match or a later If-Modified-Since is answered 304 Not Modified, so
+// clients and caching proxies need only fetch the data again when a refresh has changed it.
+//
+//	GET /api/systems                          => ["Acorn Atom", ...]
+//	GET /api/system?name=Acorn+Atom           => [{"year": 1981, "quarter": 2, "pence": 17000, "adverts": 3}, ...]
+//	GET /api/quarter?year=1983&quarter=1      => [{"system": "Acorn Atom", "pence": 17000, "adverts": 3}, ...]
+//	GET /api/search?q=spectrum&limit=10      => [{"system": "ZX Spectrum", "first": "1982Q2", "last": "1986Q4", "quarters": 19, "adverts": 41, "lowest": 9900, "highest": 17500, "latest": 9900}, ...]
+//	GET /api/status                           => when the data was loaded and last changed, its digest and the validation results for each source
+//	GET /download/dataset.csv                 => the adverts, as CSV (see download.go); also dataset.json and dataset.sqlite
+//	GET /embed/system/Acorn%20Atom            => an HTML chart and table of the system's prices, for an iframe (see embed.go)
+//	GET /metrics                              => the same and the requests answered, for Prometheus (see serverMetrics)
+func runServer(args []string) {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	listen := flags.String("listen", "localhost:8080", "address to listen on")
+	refresh := flags.Duration("refresh", 0, "fetch the sources again at this interval, e.g. 15m (0 means never)")
+	configFilename := flags.String("config", "", "JSON file of rules applied to the data (default: the built-in rules)")
+	aggregation := flags.String("aggregate", "min", "how each quarter's price is chosen from its adverts: min, mode or median")
+	sqliteFilename := flags.String("sqlite", "", "answer from this SQLite database, as downloaded from /download/dataset.sqlite, instead of loading sources")
+	logFilename, logFormat := addLoggingFlags(flags)
+	flags.Parse(args)
+	setupLogging(*logFilename, *logFormat)
+
+	sources := flags.Args()
+	config, err := hcp.LoadConfiguration(*configFilename)
+	if err != nil {
+		log.Fatalf("Cannot load configuration: %s\n", err.Error())
+	}
+	if *sqliteFilename != "" {
+		if (len(sources) > 0) || (*refresh > 0) {
+			log.Fatalf("-sqlite takes no sources and no -refresh\n")
+		}
+		runSQLiteServer(*listen, *sqliteFilename, config)
+		return
+	}
+	if len(sources) < 1 {
+		log.Fatalf("At least 1 source required but %d supplied\n", len(sources))
+	}
+	live := &liveDataset{load: func() (*hcp.Dataset, error) {
+		return hcp.LoadFiles(sources, hcp.Options{Config: &config, Aggregation: *aggregation, Open: openSource})
+	}, attribution: config.Attribution, lifespans: config.Lifespans}
+	if err := live.reload(); err != nil {
+		log.Fatalf("Cannot load adverts: %s\n", err.Error())
+	}
+	if *refresh > 0 {
+		go live.refreshEvery(*refresh)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/systems", live.cached(handleSystems))
+	mux.HandleFunc("/api/system", live.cached(handleSystem))
+	mux.HandleFunc("/api/quarter", live.cached(handleQuarter))
+	mux.HandleFunc("/api/search", live.cached(handleSearch))
+	mux.HandleFunc("/api/status", live.uncached(handleStatus))
+	mux.HandleFunc("/embed/system/{name}", live.cached(handleEmbed))
+	mux.HandleFunc("/download/dataset.csv", live.cached(handleDownloadCSV))
+	mux.HandleFunc("/download/dataset.json", live.cached(handleDownloadJSON))
+	mux.HandleFunc("/download/dataset.sqlite", live.cached(handleDownloadSQLite))
+	metrics := newServerMetrics()
+	mux.HandleFunc("/metrics", live.uncached(func(w http.ResponseWriter, r *http.Request, snapshot *servedSnapshot) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		metrics.write(w, snapshot, live.failures.Load())
+	}))
+	logf("Serving HTTP on %s\n", *listen)
+	if err := http.ListenAndServe(*listen, metrics.instrument(mux)); err != nil {
+		log.Fatalf("HTTP server failed: %s\n", err.Error())
+	}
+}
+
+// Open a source of CSV data: an http(s) URL is fetched, anything else is a file
+func openSource(name string) (io.ReadCloser, error) {
+	if !strings.HasPrefix(name, "http://") && !strings.HasPrefix(name, "https://") {
+		return os.Open(name)
+	}
+	request, err := http.NewRequest("GET", name, nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("User-Agent", wiki_user_agent)
+	client := &http.Client{Timeout: time.Minute}
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode != http.StatusOK {
+		response.Body.Close()
+		return nil, fmt.Errorf("fetching [%s] returned [%s]", name, response.Status)
+	}
+	return response.Body, nil
+}
+
+// The dataset being served, which a refresh replaces while requests are being answered.
+// Requests never wait for a refresh: each reads whichever snapshot is current when it starts and uses that
+// one throughout, while a refresh builds the next snapshot alongside and then swaps it in atomically.
+type liveDataset struct {
+	load        func() (*hcp.Dataset, error) // Builds a new dataset from the sources
+	attribution hcp.Attribution              // Credited in the HTML responses
+	lifespans   map[string]hcp.Lifespan      // Shaded in the charts of the HTML responses
+	reloading   sync.Mutex                   // Held while reloading, so that a slow reload cannot replace the data of a later one
+	snapshot    atomic.Pointer[servedSnapshot]
+	failures    atomic.Int64 // Refreshes that have failed since the server started
+}
+
+// A dataset being served along with when it was built; none of it is changed once it is being served, only replaced
+type servedSnapshot struct {
+	dataset *hcp.Dataset
+	loaded  time.Time
+	table   priceTable // The dataset's prices as the tables would publish them, for the HTML responses
+	digest  string     // The dataset's Digest
+	changed time.Time  // When the data last changed: loaded, unless the previous snapshot had the same digest
+}
+
+// A snapshotHandler answers a request from the snapshot that was current when the request arrived
+type snapshotHandler func(w http.ResponseWriter, r *http.Request, snapshot *servedSnapshot)
+
+// Build a new dataset from the sources and, if that succeeds, serve it in place of the old one
+func (live *liveDataset) reload() error {
+	live.reloading.Lock()
+	defer live.reloading.Unlock()
+	dataset, err := live.load()
+	if err != nil {
+		return err
+	}
+	printValidationSummary(dataset.Validations)
+	snapshot := &servedSnapshot{dataset: dataset, loaded: time.Now(), digest: dataset.Digest()}
+	snapshot.table = newPriceTable(dataset, tableOptions{rounding: "trunc", sourcesBy: "issue"})
+	snapshot.table.attribution = live.attribution
+	snapshot.table.lifespans = live.lifespans
+	snapshot.changed = snapshot.loaded
+	if previous := live.snapshot.Load(); (previous != nil) && (previous.digest == snapshot.digest) {
+		snapshot.changed = previous.changed
+	}
+	live.snapshot.Store(snapshot)
+	return nil
+}
+
+// Return a handler that answers each request from the current snapshot
+func (live *liveDataset) uncached(handler snapshotHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handler(w, r, live.snapshot.Load())
+	}
+}
+
+// Return a handler that answers each request from the current snapshot, marking the response with the snapshot's
+// ETag and Last-Modified time and answering 304 Not Modified to a client that already has that version
+func (live *liveDataset) cached(handler snapshotHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		snapshot := live.snapshot.Load()
+		etag := "\"" + snapshot.digest + "\""
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Last-Modified", snapshot.changed.UTC().Format(http.TimeFormat))
+		if notModified(r, etag, snapshot.changed) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		handler(w, r, snapshot)
+	}
+}
+
+// Return true if the request's conditional headers show that the client already has the version of the response
+// with the given ETag, last changed at the given time. If-None-Match takes precedence over If-Modified-Since.
+func notModified(r *http.Request, etag string, changed time.Time) bool {
+	if (r.Method != http.MethodGet) && (r.Method != http.MethodHead) {
+		return false
+	}
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		for _, candidate := range strings.Split(match, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if (candidate == etag) || (candidate == "*") {
+				return true
+			}
+		}
+		return false
+	}
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	// HTTP dates are in whole seconds
+	return (err == nil) && !changed.Truncate(time.Second).After(since)
+}
+
+// Reload the dataset at each interval, for ever; a failure is logged and the old data kept
+func (live *liveDataset) refreshEvery(interval time.Duration) {
+	for range time.Tick(interval) {
+		if err := live.reload(); err != nil {
+			live.failures.Add(1)
+			logf("Cannot refresh adverts, keeping the previous data: %s\n", err.Error())
+		}
+	}
+}
+
+// The JSON form of a price published for a system in a quarter
+type servedQuarterPrice struct {
+	Year    int `json:"year"`
+	Quarter int `json:"quarter"`
+	Pence   int `json:"pence"`
+	Adverts int `json:"adverts"` // How many adverts the price was chosen from
+}
+
+// The JSON form of a price published for a system in a particular quarter
+type servedSystemPrice struct {
+	System  string `json:"system"`
+	Pence   int    `json:"pence"`
+	Adverts int    `json:"adverts"`
+}
+
+// The JSON form of a system found by a search, with a summary of its published prices for showing beside its name
+type servedSearchResult struct {
+	System   string `json:"system"`
+	First    string `json:"first"`    // The first quarter with a price
+	Last     string `json:"last"`     // The last quarter with a price
+	Quarters int    `json:"quarters"` // How many quarters have a price
+	Adverts  int    `json:"adverts"`  // How many adverts the prices were chosen from
+	Lowest   int    `json:"lowest"`
+	Highest  int    `json:"highest"`
+	Latest   int    `json:"latest"` // The price in the last quarter with a price
+}
+
+// The most systems a search returns unless limit asks for fewer or more
+const default_search_limit = 10
+
+func handleSystems(w http.ResponseWriter, r *http.Request, snapshot *servedSnapshot) {
+	dataset := snapshot.dataset
+	writeJSON(w, dataset.Systems())
+}
+
+func handleSystem(w http.ResponseWriter, r *http.Request, snapshot *servedSnapshot) {
+	dataset := snapshot.dataset
+	name := r.URL.Query().Get("name")
+	prices := servedPrices(dataset.PricesFor(name))
+	if len(prices) == 0 {
+		http.Error(w, fmt.Sprintf("no prices for [%s]", name), http.StatusNotFound)
+		return
+	}
+	writeJSON(w, prices)
+}
+
+// Return a system's published prices in their JSON form
+func servedPrices(prices []hcp.QuarterPrice) []servedQuarterPrice {
+	result := make([]servedQuarterPrice, 0, len(prices))
+	for _, price := range prices {
+		result = append(result, servedQuarterPrice{price.Year, price.Quarter, price.Price, len(price.Adverts)})
+	}
+	return result
+}
+
+func handleQuarter(w http.ResponseWriter, r *http.Request, snapshot *servedSnapshot) {
+	dataset := snapshot.dataset
+	year, quarter, ok := quarterParameters(w, r)
+	if !ok {
+		return
+	}
+	result := make([]servedSystemPrice, 0)
+	for _, price := range dataset.Quarter(year, quarter) {
+		result = append(result, servedSystemPrice{price.System, price.Price, len(price.Adverts)})
+	}
+	writeJSON(w, result)
+}
+
+// Return the year and quarter asked for by a request, answering it with an error if they are missing or bad
+func quarterParameters(w http.ResponseWriter, r *http.Request) (year int, quarter int, ok bool) {
+	year, yearErr := strconv.Atoi(r.URL.Query().Get("year"))
+	quarter, quarterErr := strconv.Atoi(r.URL.Query().Get("quarter"))
+	if (yearErr != nil) || (quarterErr != nil) || (quarter < 1) || (quarter > 4) {
+		http.Error(w, "year and quarter (1 to 4) are needed", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	return year, quarter, true
+}
+
+func handleSearch(w http.ResponseWriter, r *http.Request, snapshot *servedSnapshot) {
+	dataset := snapshot.dataset
+	query, limit, ok := searchParameters(w, r)
+	if !ok {
+		return
+	}
+	result := make([]servedSearchResult, 0)
+	for _, system := range dataset.Search(query) {
+		if len(result) == limit {
+			break
+		}
+		if prices := servedPrices(dataset.PricesFor(system)); len(prices) > 0 {
+			result = append(result, newSearchResult(system, prices))
+		}
+	}
+	writeJSON(w, result)
+}
+
+// Return the query and the limit asked for by a search, answering it with an error if they are missing or bad
+func searchParameters(w http.ResponseWriter, r *http.Request) (query string, limit int, ok bool) {
+	query = r.URL.Query().Get("q")
+	limit = default_search_limit
+	if text := r.URL.Query().Get("limit"); text != "" {
+		var err error
+		if limit, err = strconv.Atoi(text); (err != nil) || (limit < 1) {
+			http.Error(w, "limit must be a positive number", http.StatusBadRequest)
+			return "", 0, false
+		}
+	}
+	if strings.TrimSpace(query) == "" {
+		http.Error(w, "q is needed", http.StatusBadRequest)
+		return "", 0, false
+	}
+	return query, limit, true
+}
+
+// Return the search result for a system with the given prices, of which there is at least one
+func newSearchResult(system string, prices []servedQuarterPrice) servedSearchResult {
+	found := servedSearchResult{System: system, Quarters: len(prices), Lowest: prices[0].Pence}
+	found.First = hcp.FormatQuarter(hcp.BuildIndexFromYearAndQuarter(prices[0].Year, prices[0].Quarter))
+	latest := prices[len(prices)-1]
+	found.Last = hcp.FormatQuarter(hcp.BuildIndexFromYearAndQuarter(latest.Year, latest.Quarter))
+	found.Latest = latest.Pence
+	for _, price := range prices {
+		found.Adverts += price.Adverts
+		found.Lowest = min(found.Lowest, price.Pence)
+		found.Highest = max(found.Highest, price.Pence)
+	}
+	return found
+}
+
+func handleStatus(w http.ResponseWriter, r *http.Request, snapshot *servedSnapshot) {
+	dataset := snapshot.dataset
+	writeJSON(w, map[string]interface{}{
+		"loaded":      snapshot.loaded.UTC().Format(time.RFC3339),
+		"changed":     snapshot.changed.UTC().Format(time.RFC3339),
+		"digest":      snapshot.digest,
+		"first":       hcp.FormatQuarter(dataset.MinDate),
+		"last":        hcp.FormatQuarter(dataset.MaxDate),
+		"validations": dataset.Validations,
+	})
+}
+
+// Write a value as the JSON response to a request
+func writeJSON(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		logf("Cannot write response: %s\n", err.Error())
+	}
+}
@@ -0,0 +1,269 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/AntonioCarlini/home-computer-prices/hcp"
+)
+
+// serve -sqlite answers the queries from a SQLite database as downloaded from /download/dataset.sqlite, rather than
+// from CSV data loaded into memory, for a merged dataset too large for a small host to hold. The prices are found
+// through the database's indexes (see download_price_indexes), reading only the pages that each query needs, so that
+// a query takes longer but the server's memory stays small whatever the size of the data.
+// The database is only read: to serve new data, replace the file and restart the server.
+// The /api queries and /download/dataset.sqlite are answered as usual, with an ETag from the file's hash; the status
+// has no validation results, and the embedded charts, the other downloads and the metrics, which need the data in
+// memory, are not offered.
+
+// A database being served
+type servedDatabase struct {
+	file      *os.File
+	size      int64
+	database  *sqliteDatabase
+	prices    int // The root pages of the prices table and its indexes
+	bySystem  int
+	byQuarter int
+	config    hcp.Configuration // For the aliases of the systems
+	loaded    time.Time
+	changed   time.Time // When the file was last changed
+	digest    string    // The first 16 hexadecimal digits of the file's SHA-256
+	first     string    // The first and last quarters with a price
+	last      string
+}
+
+// A databaseHandler answers a request from the database being served
+type databaseHandler func(w http.ResponseWriter, r *http.Request, served *servedDatabase)
+
+// Serve the database in the named file on the given address until stopped
+func runSQLiteServer(listen string, filename string, config hcp.Configuration) {
+	served, err := openServedDatabase(filename, config)
+	if err != nil {
+		log.Fatalf("Cannot open database: %s\n", err.Error())
+	}
+	logf("Serving '%s', %s to %s\n", filename, served.first, served.last)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/systems", served.cached(handleDatabaseSystems))
+	mux.HandleFunc("/api/system", served.cached(handleDatabaseSystem))
+	mux.HandleFunc("/api/quarter", served.cached(handleDatabaseQuarter))
+	mux.HandleFunc("/api/search", served.cached(handleDatabaseSearch))
+	mux.HandleFunc("/api/status", func(w http.ResponseWriter, r *http.Request) { handleDatabaseStatus(w, r, served) })
+	mux.HandleFunc("/download/dataset.sqlite", served.cached(handleDatabaseDownload))
+	logf("Serving HTTP on %s\n", listen)
+	if err := http.ListenAndServe(listen, mux); err != nil {
+		log.Fatalf("HTTP server failed: %s\n", err.Error())
+	}
+}
+
+// Open the database in the named file and check that it has the prices table and its indexes
+func openServedDatabase(filename string, config hcp.Configuration) (*servedDatabase, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.New()
+	if _, err := io.Copy(digest, file); err != nil {
+		return nil, fmt.Errorf("cannot read [%s] (%w)", filename, err)
+	}
+	database, err := openSQLite(file)
+	if err != nil {
+		return nil, fmt.Errorf("[%s]: %w", filename, err)
+	}
+	served := &servedDatabase{file: file, size: info.Size(), database: database, config: config, loaded: time.Now(), changed: info.ModTime(), digest: hex.EncodeToString(digest.Sum(nil))[:16]}
+	for name, root := range map[string]*int{"prices": &served.prices, "prices_by_system": &served.bySystem, "prices_by_quarter": &served.byQuarter} {
+		if *root, err = database.root(name); err != nil {
+			return nil, fmt.Errorf("[%s]: %w; a database downloaded from an older release has no indexes, so download it again", filename, err)
+		}
+	}
+	// The index of the quarters is in date order, so its first and last entries are the first and last quarters
+	err = database.seekIndex(served.byQuarter, nil, func(entry []interface{}) bool {
+		year, _ := entry[0].(int64)
+		quarter, _ := entry[1].(int64)
+		if served.first == "" {
+			served.first = hcp.FormatQuarter(hcp.BuildIndexFromYearAndQuarter(int(year), int(quarter)))
+		}
+		served.last = hcp.FormatQuarter(hcp.BuildIndexFromYearAndQuarter(int(year), int(quarter)))
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("[%s]: %w", filename, err)
+	}
+	return served, nil
+}
+
+// Return a handler that answers each request from the database, marking the response with the database's ETag and
+// Last-Modified time and answering 304 Not Modified to a client that already has that version, as for served data
+func (served *servedDatabase) cached(handler databaseHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		etag := "\"" + served.digest + "\""
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Last-Modified", served.changed.UTC().Format(http.TimeFormat))
+		if notModified(r, etag, served.changed) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		handler(w, r, served)
+	}
+}
+
+// Return the names of the published systems in alphabetical order
+func (served *servedDatabase) systems() ([]string, error) {
+	systems := make([]string, 0)
+	err := served.database.seekIndex(served.bySystem, nil, func(entry []interface{}) bool {
+		if system, _ := entry[0].(string); (len(systems) == 0) || (systems[len(systems)-1] != system) {
+			systems = append(systems, system)
+		}
+		return true
+	})
+	return systems, err
+}
+
+// Return the published prices for a system, named by any of its names, oldest first
+func (served *servedDatabase) pricesFor(system string) ([]servedQuarterPrice, error) {
+	system = served.config.ResolveName(system)
+	rows, err := served.indexedRows(served.bySystem, []interface{}{system})
+	result := make([]servedQuarterPrice, 0, len(rows))
+	for _, row := range rows {
+		result = append(result, servedQuarterPrice{databaseInt(row[1]), databaseInt(row[2]), databaseInt(row[3]), databaseInt(row[4])})
+	}
+	return result, err
+}
+
+// Return the prices published for every system in the given year and quarter, in alphabetical order of system
+func (served *servedDatabase) quarter(year int, quarter int) ([]servedSystemPrice, error) {
+	rows, err := served.indexedRows(served.byQuarter, []interface{}{year, quarter})
+	result := make([]servedSystemPrice, 0, len(rows))
+	for _, row := range rows {
+		system, _ := row[0].(string)
+		result = append(result, servedSystemPrice{system, databaseInt(row[3]), databaseInt(row[4])})
+	}
+	return result, err
+}
+
+// Return the rows of the prices table whose entries in the given index start with the given values, in index order
+func (served *servedDatabase) indexedRows(index int, prefix []interface{}) ([][]interface{}, error) {
+	rowids := make([]int64, 0)
+	err := served.database.seekIndex(index, prefix, func(entry []interface{}) bool {
+		if sqliteCompareKeys(entry[:min(len(entry), len(prefix))], prefix) != 0 {
+			return false
+		}
+		rowid, _ := entry[len(entry)-1].(int64)
+		rowids = append(rowids, rowid)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	rows := make([][]interface{}, 0, len(rowids))
+	for _, rowid := range rowids {
+		row, ok, err := served.database.row(served.prices, rowid)
+		if err != nil {
+			return nil, err
+		}
+		if !ok || (len(row) < 5) {
+			return nil, fmt.Errorf("the index names row %d of the prices, which it does not have", rowid)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// Return a value read from the database as an int, or 0 if it is not an integer
+func databaseInt(value interface{}) int {
+	v, _ := value.(int64)
+	return int(v)
+}
+
+// Answer a request with the error from reading the database
+func databaseError(w http.ResponseWriter, err error) {
+	logf("Cannot read database: %s\n", err.Error())
+	http.Error(w, fmt.Sprintf("cannot read database: %s", err.Error()), http.StatusInternalServerError)
+}
+
+func handleDatabaseSystems(w http.ResponseWriter, r *http.Request, served *servedDatabase) {
+	systems, err := served.systems()
+	if err != nil {
+		databaseError(w, err)
+		return
+	}
+	writeJSON(w, systems)
+}
+
+func handleDatabaseSystem(w http.ResponseWriter, r *http.Request, served *servedDatabase) {
+	name := r.URL.Query().Get("name")
+	prices, err := served.pricesFor(name)
+	switch {
+	case err != nil:
+		databaseError(w, err)
+	case len(prices) == 0:
+		http.Error(w, fmt.Sprintf("no prices for [%s]", name), http.StatusNotFound)
+	default:
+		writeJSON(w, prices)
+	}
+}
+
+func handleDatabaseQuarter(w http.ResponseWriter, r *http.Request, served *servedDatabase) {
+	year, quarter, ok := quarterParameters(w, r)
+	if !ok {
+		return
+	}
+	prices, err := served.quarter(year, quarter)
+	if err != nil {
+		databaseError(w, err)
+		return
+	}
+	writeJSON(w, prices)
+}
+
+func handleDatabaseSearch(w http.ResponseWriter, r *http.Request, served *servedDatabase) {
+	query, limit, ok := searchParameters(w, r)
+	if !ok {
+		return
+	}
+	systems, err := served.systems()
+	if err != nil {
+		databaseError(w, err)
+		return
+	}
+	result := make([]servedSearchResult, 0)
+	for _, system := range hcp.SearchSystems(systems, query, served.config) {
+		if len(result) == limit {
+			break
+		}
+		prices, err := served.pricesFor(system)
+		if err != nil {
+			databaseError(w, err)
+			return
+		}
+		if len(prices) > 0 {
+			result = append(result, newSearchResult(system, prices))
+		}
+	}
+	writeJSON(w, result)
+}
+
+func handleDatabaseStatus(w http.ResponseWriter, r *http.Request, served *servedDatabase) {
+	writeJSON(w, map[string]interface{}{
+		"loaded":  served.loaded.UTC().Format(time.RFC3339),
+		"changed": served.changed.UTC().Format(time.RFC3339),
+		"digest":  served.digest,
+		"first":   served.first,
+		"last":    served.last,
+	})
+}
+
+func handleDatabaseDownload(w http.ResponseWriter, r *http.Request, served *servedDatabase) {
+	setDownload(w, "application/vnd.sqlite3", "dataset.sqlite")
+	http.ServeContent(w, r, "dataset.sqlite", served.changed, io.NewSectionReader(served.file, 0, served.size))
+}
@@ -0,0 +1,471 @@
+package main
+
+import (
+	"cmp"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+)
+
+// A minimal writer of SQLite database files, so that the data can be offered as a database without a cgo driver.
+// It writes the tables in one go, each as a table b-tree in rowid order (rowids counting from 1), along with any
+// indexes over them, following https://www.sqlite.org/fileformat2.html. The result can be opened, queried and
+// changed by SQLite as usual, or read by the reader in sqliteread.go.
+
+// A table to write to a SQLite database
+type sqliteTable struct {
+	name    string
+	columns []string        // Each column's definition, such as "price INTEGER"
+	rows    [][]interface{} // Each value is nil, a bool, an int, an int64, a float64 or a string
+	indexes []sqliteIndex
+}
+
+// An index over a table, as made by CREATE INDEX
+type sqliteIndex struct {
+	name    string
+	columns []int // The positions in the table's rows of the indexed columns
+}
+
+// Database layout
+const (
+	sqlite_page_size      = 4096
+	sqlite_header_size    = 100 // The database header at the start of page 1
+	sqlite_leaf_table     = 0x0d
+	sqlite_interior_page  = 0x05
+	sqlite_leaf_index     = 0x0a
+	sqlite_interior_index = 0x02
+)
+
+// Write the tables as a SQLite database
+func writeSQLite(w io.Writer, tables []sqliteTable) error {
+	pages := [][]byte{nil} // Page 1, the schema, is written last, once the root page of every table is known
+	schema := make([][]byte, 0, len(tables))
+	used := sqlite_header_size + 8
+	for _, table := range tables {
+		records := make([][]byte, 0, len(table.rows))
+		for _, row := range table.rows {
+			record, err := sqliteRecord(row)
+			if err != nil {
+				return fmt.Errorf("table [%s]: %w", table.name, err)
+			}
+			records = append(records, record)
+		}
+		root := sqliteTree(&pages, records)
+		sql := fmt.Sprintf("CREATE TABLE %s (%s)", sqliteName(table.name), strings.Join(table.columns, ", "))
+		record, _ := sqliteRecord([]interface{}{"table", table.name, table.name, root, sql})
+		cell := sqliteLeafCell(&pages, int64(len(schema)+1), record)
+		schema = append(schema, cell)
+		used += 2 + len(cell)
+		for _, index := range table.indexes {
+			root, err := sqliteIndexTree(&pages, table.rows, index.columns)
+			if err != nil {
+				return fmt.Errorf("index [%s]: %w", index.name, err)
+			}
+			names := make([]string, 0, len(index.columns))
+			for _, column := range index.columns {
+				names = append(names, strings.Fields(table.columns[column])[0])
+			}
+			sql := fmt.Sprintf("CREATE INDEX %s ON %s (%s)", sqliteName(index.name), sqliteName(table.name), strings.Join(names, ", "))
+			record, _ := sqliteRecord([]interface{}{"index", index.name, table.name, root, sql})
+			cell := sqliteLeafCell(&pages, int64(len(schema)+1), record)
+			schema = append(schema, cell)
+			used += 2 + len(cell)
+		}
+	}
+	if used > sqlite_page_size {
+		return fmt.Errorf("too many tables for the schema page")
+	}
+	pages[0] = sqlitePage(sqlite_leaf_table, schema, 0, sqlite_header_size)
+
+	header := pages[0][:sqlite_header_size]
+	copy(header, "SQLite format 3\x00")
+	binary.BigEndian.PutUint16(header[16:], sqlite_page_size)
+	header[18], header[19] = 1, 1                               // Legacy journalling for reading and writing
+	header[21], header[22], header[23] = 64, 32, 32             // Payload fractions, which must be these
+	binary.BigEndian.PutUint32(header[24:], 1)                  // File change counter
+	binary.BigEndian.PutUint32(header[28:], uint32(len(pages))) // Database size in pages
+	binary.BigEndian.PutUint32(header[40:], 1)                  // Schema cookie
+	binary.BigEndian.PutUint32(header[44:], 4)                  // Schema format
+	binary.BigEndian.PutUint32(header[56:], 1)                  // UTF-8
+	binary.BigEndian.PutUint32(header[92:], 1)                  // Version-valid-for, matching the change counter
+	binary.BigEndian.PutUint32(header[96:], 3_040_000)          // The SQLite version the format follows
+
+	for _, page := range pages {
+		if _, err := w.Write(page); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// A page of a b-tree being built, with the largest rowid in it
+type sqliteChild struct {
+	page   int
+	maxKey int64
+}
+
+// Build a table b-tree holding the records, adding its pages to pages, and return the number of its root page
+func sqliteTree(pages *[][]byte, records [][]byte) int {
+	level := sqliteLeaves(pages, records)
+	for len(level) > 1 {
+		level = sqliteInteriors(pages, level)
+	}
+	return level[0].page
+}
+
+// Pack the records into as many leaf pages as they need, adding them to pages, and return the leaves in order.
+// Records are given rowids from 1. Even no records need a page, as a table's root page always exists.
+func sqliteLeaves(pages *[][]byte, records [][]byte) []sqliteChild {
+	leaves := make([]sqliteChild, 0)
+	cells := make([][]byte, 0)
+	used := 8
+	flush := func(maxKey int64) {
+		*pages = append(*pages, sqlitePage(sqlite_leaf_table, cells, 0, 0))
+		leaves = append(leaves, sqliteChild{len(*pages), maxKey})
+		cells, used = cells[:0], 8
+	}
+	for i, record := range records {
+		cell := sqliteLeafCell(pages, int64(i+1), record)
+		if (used+2+len(cell) > sqlite_page_size) && (len(cells) > 0) {
+			flush(int64(i))
+		}
+		cells = append(cells, cell)
+		used += 2 + len(cell)
+	}
+	if (len(cells) > 0) || (len(leaves) == 0) {
+		flush(int64(len(records)))
+	}
+	return leaves
+}
+
+// Build the interior pages over one level of a b-tree, adding them to pages, and return them in order
+func sqliteInteriors(pages *[][]byte, children []sqliteChild) []sqliteChild {
+	parents := make([]sqliteChild, 0)
+	cells := make([][]byte, 0)
+	used := 12
+	for i, child := range children {
+		last := (i == len(children)-1)
+		cell := binary.BigEndian.AppendUint32(nil, uint32(child.page))
+		cell = sqliteVarint(cell, uint64(child.maxKey))
+		// The last child of each page is its rightmost pointer rather than a cell
+		if last || (used+2+len(cell)+2+len(cell) > sqlite_page_size) {
+			*pages = append(*pages, sqlitePage(sqlite_interior_page, cells, child.page, 0))
+			parents = append(parents, sqliteChild{len(*pages), child.maxKey})
+			cells, used = cells[:0], 12
+			continue
+		}
+		cells = append(cells, cell)
+		used += 2 + len(cell)
+	}
+	return parents
+}
+
+// Build an index b-tree over the values of the rows in the given columns, adding its pages to pages, and return the
+// number of its root page. Each entry is a record of the values followed by the row's rowid, in the order in which
+// SQLite sorts them (see sqliteCompare). Unlike a table's, an index's interior pages hold entries of their own:
+// the entry between two pages of a level is moved up to the level above rather than repeated there.
+func sqliteIndexTree(pages *[][]byte, rows [][]interface{}, columns []int) (int, error) {
+	keys := make([][]interface{}, 0, len(rows))
+	for i, row := range rows {
+		key := make([]interface{}, 0, len(columns)+1)
+		for _, column := range columns {
+			key = append(key, row[column])
+		}
+		keys = append(keys, append(key, int64(i+1)))
+	}
+	sort.Slice(keys, func(a int, b int) bool { return sqliteCompareKeys(keys[a], keys[b]) < 0 })
+	entries := make([][]byte, 0, len(keys))
+	for _, key := range keys {
+		record, err := sqliteRecord(key)
+		if err != nil {
+			return 0, err
+		}
+		// Entries too large to be kept in the page would need overflow pages, which no index here does
+		if len(record) > sqliteMaxLocal(sqlite_page_size, true) {
+			return 0, fmt.Errorf("an entry of %d bytes is too large", len(record))
+		}
+		entries = append(entries, append(sqliteVarint(nil, uint64(len(record))), record...))
+	}
+	children, entries := sqliteIndexLevel(pages, entries, nil)
+	for len(children) > 1 {
+		children, entries = sqliteIndexLevel(pages, entries, children)
+	}
+	return children[0], nil
+}
+
+// Pack one level of an index b-tree into pages, adding them to pages, and return the pages in order along with the
+// entries moved up to the level above, one between each two pages. children is nil for the leaves; otherwise the
+// entries lie between the children, each of the level below, so there is one more child than there are entries.
+func sqliteIndexLevel(pages *[][]byte, entries [][]byte, children []int) ([]int, [][]byte) {
+	kind, header := byte(sqlite_leaf_index), 8
+	if children != nil {
+		kind, header = sqlite_interior_index, 12
+	}
+	level, above := make([]int, 0), make([][]byte, 0)
+	cells := make([][]byte, 0)
+	used := header
+	flush := func(right int) {
+		*pages = append(*pages, sqlitePage(kind, cells, right, 0))
+		level = append(level, len(*pages))
+		cells, used = cells[:0], header
+	}
+	for i, entry := range entries {
+		cell := entry
+		if children != nil {
+			cell = append(binary.BigEndian.AppendUint32(nil, uint32(children[i])), entry...)
+		}
+		if (used+2+len(cell) <= sqlite_page_size) || (len(cells) == 0) {
+			cells = append(cells, cell)
+			used += 2 + len(cell)
+			continue
+		}
+		switch {
+		case children == nil:
+			// The entry moves up, and the next leaf starts with the entry after it; the last entry has none after it,
+			// so it starts the last leaf instead
+			flush(0)
+			if i == len(entries)-1 {
+				cells = append(cells, cell)
+				used += 2 + len(cell)
+				continue
+			}
+			above = append(above, entry)
+		case i == len(entries)-1:
+			// An interior page needs an entry as well as its rightmost child, so the page's own last entry moves up
+			// in place of this one; a page that is full holds enough entries to spare one
+			last := cells[len(cells)-1]
+			cells = cells[:len(cells)-1]
+			flush(int(binary.BigEndian.Uint32(last)))
+			above = append(above, last[4:])
+			cells = append(cells, cell)
+			used += 2 + len(cell)
+		default:
+			// The entry moves up, and its child becomes the rightmost child of the page
+			flush(children[i])
+			above = append(above, entry)
+		}
+	}
+	right := 0
+	if children != nil {
+		right = children[len(children)-1]
+	}
+	flush(right)
+	return level, above
+}
+
+// Return the most of a payload that is kept in a page of the given usable size, the rest going to overflow pages,
+// for a table leaf or for an index page
+func sqliteMaxLocal(usable int, index bool) int {
+	if index {
+		return (usable-12)*64/255 - 23
+	}
+	return usable - 35
+}
+
+// Return the least of a payload that is kept in a page of the given usable size when the rest overflows
+func sqliteMinLocal(usable int) int {
+	return (usable-12)*32/255 - 23
+}
+
+// Return a b-tree page of the given type holding the cells, which are known to fit.
+// right is the rightmost child of an interior page; offset is where the page header starts.
+func sqlitePage(kind byte, cells [][]byte, right int, offset int) []byte {
+	page := make([]byte, sqlite_page_size)
+	page[offset] = kind
+	binary.BigEndian.PutUint16(page[offset+3:], uint16(len(cells)))
+	pointers := offset + 8
+	if (kind == sqlite_interior_page) || (kind == sqlite_interior_index) {
+		binary.BigEndian.PutUint32(page[offset+8:], uint32(right))
+		pointers = offset + 12
+	}
+	content := sqlite_page_size
+	for i, cell := range cells {
+		content -= len(cell)
+		copy(page[content:], cell)
+		binary.BigEndian.PutUint16(page[pointers+2*i:], uint16(content))
+	}
+	binary.BigEndian.PutUint16(page[offset+5:], uint16(content))
+	return page
+}
+
+// Return the cell of a table leaf page holding a record with the given rowid.
+// The part of a record too large to be kept in the page goes to a chain of overflow pages, added to pages.
+func sqliteLeafCell(pages *[][]byte, rowid int64, record []byte) []byte {
+	cell := sqliteVarint(nil, uint64(len(record)))
+	cell = sqliteVarint(cell, uint64(rowid))
+	// The limits on how much of a payload is kept in the page, from the file format
+	usable := sqlite_page_size
+	maxLocal, minLocal := sqliteMaxLocal(usable, false), sqliteMinLocal(usable)
+	if len(record) <= maxLocal {
+		return append(cell, record...)
+	}
+	local := minLocal + (len(record)-minLocal)%(usable-4)
+	if local > maxLocal {
+		local = minLocal
+	}
+	cell = append(cell, record[:local]...)
+	cell = binary.BigEndian.AppendUint32(cell, uint32(len(*pages)+1))
+	for rest := record[local:]; len(rest) > 0; {
+		page := make([]byte, sqlite_page_size)
+		n := copy(page[4:], rest)
+		rest = rest[n:]
+		if len(rest) > 0 {
+			binary.BigEndian.PutUint32(page, uint32(len(*pages)+2))
+		}
+		*pages = append(*pages, page)
+	}
+	return cell
+}
+
+// Return a row of values encoded as a SQLite record
+func sqliteRecord(values []interface{}) ([]byte, error) {
+	types := make([]uint64, 0, len(values))
+	body := make([]byte, 0)
+	for _, value := range values {
+		switch v := value.(type) {
+		case nil:
+			types = append(types, 0)
+		case bool:
+			if v {
+				types = append(types, 9)
+			} else {
+				types = append(types, 8)
+			}
+		case int:
+			kind, bytes := sqliteInteger(int64(v))
+			types, body = append(types, kind), append(body, bytes...)
+		case int64:
+			kind, bytes := sqliteInteger(v)
+			types, body = append(types, kind), append(body, bytes...)
+		case float64:
+			types, body = append(types, 7), binary.BigEndian.AppendUint64(body, math.Float64bits(v))
+		case string:
+			types, body = append(types, uint64(2*len(v)+13)), append(body, v...)
+		default:
+			return nil, fmt.Errorf("cannot store a value of type [%T]", value)
+		}
+	}
+	header := make([]byte, 0)
+	for _, kind := range types {
+		header = sqliteVarint(header, kind)
+	}
+	// The header's length includes the varint holding it, which is one byte for any header a table here needs
+	size := len(header) + 1
+	if size > 127 {
+		size++
+	}
+	return append(append(sqliteVarint(nil, uint64(size)), header...), body...), nil
+}
+
+// Compare two values as SQLite orders them with the BINARY collation: NULL first, then numbers by value, then
+// text byte by byte, then blobs; a bool is the number 0 or 1, as it is stored.
+// The result is negative, zero or positive as a is before, the same as or after b.
+func sqliteCompare(a interface{}, b interface{}) int {
+	rank := func(value interface{}) (int, float64, string) {
+		switch v := value.(type) {
+		case nil:
+			return 0, 0, ""
+		case bool:
+			if v {
+				return 1, 1, ""
+			}
+			return 1, 0, ""
+		case int:
+			return 1, float64(v), ""
+		case int64:
+			return 1, float64(v), ""
+		case float64:
+			return 1, v, ""
+		case string:
+			return 2, 0, v
+		case []byte:
+			return 3, 0, string(v)
+		}
+		return 4, 0, ""
+	}
+	rankA, numberA, textA := rank(a)
+	rankB, numberB, textB := rank(b)
+	switch {
+	case rankA != rankB:
+		return rankA - rankB
+	case rankA != 1:
+		return strings.Compare(textA, textB)
+	}
+	// Two integers are compared exactly, as a float64 cannot tell apart every pair of large ones
+	if x, ok := sqliteInt64(a); ok {
+		if y, ok := sqliteInt64(b); ok {
+			return cmp.Compare(x, y)
+		}
+	}
+	return cmp.Compare(numberA, numberB)
+}
+
+// Return a value as an int64, if it is an integer or a bool
+func sqliteInt64(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	case int:
+		return int64(v), true
+	case int64:
+		return v, true
+	}
+	return 0, false
+}
+
+// Compare two keys of an index, value by value, as sqliteCompare; a key that is a prefix of the other is before it
+func sqliteCompareKeys(a []interface{}, b []interface{}) int {
+	for i := 0; (i < len(a)) && (i < len(b)); i++ {
+		if c := sqliteCompare(a[i], b[i]); c != 0 {
+			return c
+		}
+	}
+	return len(a) - len(b)
+}
+
+// Return the serial type and big-endian bytes of an integer, in the fewest bytes that hold it
+func sqliteInteger(v int64) (uint64, []byte) {
+	bytes := binary.BigEndian.AppendUint64(nil, uint64(v))
+	switch {
+	case v == 0:
+		return 8, nil
+	case v == 1:
+		return 9, nil
+	case (v >= math.MinInt8) && (v <= math.MaxInt8):
+		return 1, bytes[7:]
+	case (v >= math.MinInt16) && (v <= math.MaxInt16):
+		return 2, bytes[6:]
+	case (v >= -1<<23) && (v < 1<<23):
+		return 3, bytes[5:]
+	case (v >= math.MinInt32) && (v <= math.MaxInt32):
+		return 4, bytes[4:]
+	case (v >= -1<<47) && (v < 1<<47):
+		return 5, bytes[2:]
+	default:
+		return 6, bytes
+	}
+}
+
+// Append a SQLite variable-length integer: big-endian groups of 7 bits, each byte but the last with its top bit set.
+// Values needing more than 56 bits, which no length or rowid here does, are not supported.
+func sqliteVarint(b []byte, v uint64) []byte {
+	groups := []byte{byte(v & 0x7f)}
+	for v >>= 7; v > 0; v >>= 7 {
+		groups = append(groups, byte(v&0x7f)|0x80)
+	}
+	for i := len(groups) - 1; i >= 0; i-- {
+		b = append(b, groups[i])
+	}
+	return b
+}
+
+// Return a name quoted for use in SQL
+func sqliteName(name string) string {
+	return "\"" + strings.ReplaceAll(name, "\"", "\"\"") + "\""
+}
@@ -0,0 +1,373 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// A minimal reader of SQLite database files, the counterpart of the writer in sqlite.go, so that serve can answer
+// queries from a database without loading it (see servesqlite.go). Each page is read from the file when it is needed
+// and not kept, so a query costs a read per page it visits and the memory used does not grow with the database.
+// It reads the table and index b-trees of any database in the file format, as SQLite may have changed it,
+// but not one with changes still in a write-ahead log, and it never writes.
+
+// A SQLite database being read
+type sqliteDatabase struct {
+	file     io.ReaderAt
+	pageSize int
+	usable   int            // The bytes of each page that hold its content, before the space reserved at its end
+	roots    map[string]int // The root page of each table and index, by name
+}
+
+// Open a SQLite database, reading its header and its schema
+func openSQLite(file io.ReaderAt) (*sqliteDatabase, error) {
+	header := make([]byte, sqlite_header_size)
+	if _, err := file.ReadAt(header, 0); err != nil {
+		return nil, fmt.Errorf("cannot read the database header (%w)", err)
+	}
+	if string(header[:16]) != "SQLite format 3\x00" {
+		return nil, fmt.Errorf("not a SQLite database")
+	}
+	database := &sqliteDatabase{file: file, pageSize: int(binary.BigEndian.Uint16(header[16:])), roots: make(map[string]int)}
+	// The largest page size, 65536, does not fit in the field, which holds 1 instead
+	if database.pageSize == 1 {
+		database.pageSize = 65536
+	}
+	database.usable = database.pageSize - int(header[20])
+	if header[18] == 2 {
+		return nil, fmt.Errorf("the database is in write-ahead log mode, so it may not be complete without its log")
+	}
+	if text := binary.BigEndian.Uint32(header[56:]); text != 1 {
+		return nil, fmt.Errorf("the database's text is not UTF-8")
+	}
+	err := database.scanTable(1, func(rowid int64, values []interface{}) bool {
+		if len(values) < 4 {
+			return true
+		}
+		kind, _ := values[0].(string)
+		name, _ := values[1].(string)
+		root, _ := values[3].(int64)
+		if ((kind == "table") || (kind == "index")) && (root > 0) {
+			database.roots[name] = int(root)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot read the schema (%w)", err)
+	}
+	return database, nil
+}
+
+// Return the root page of the named table or index
+func (database *sqliteDatabase) root(name string) (int, error) {
+	root, ok := database.roots[name]
+	if !ok {
+		return 0, fmt.Errorf("the database has no [%s]", name)
+	}
+	return root, nil
+}
+
+// Read a page of the database; pages are numbered from 1
+func (database *sqliteDatabase) page(number int) ([]byte, error) {
+	page := make([]byte, database.pageSize)
+	if _, err := database.file.ReadAt(page, int64(number-1)*int64(database.pageSize)); err != nil {
+		return nil, fmt.Errorf("cannot read page %d (%w)", number, err)
+	}
+	return page, nil
+}
+
+// A b-tree page that has been read, with its header decoded
+type sqliteBTreePage struct {
+	data  []byte
+	kind  byte
+	cells []int // The offset of each cell
+	right int   // The rightmost child, of an interior page
+}
+
+// Read a b-tree page; the header of page 1 follows the database header
+func (database *sqliteDatabase) bTreePage(number int) (sqliteBTreePage, error) {
+	data, err := database.page(number)
+	if err != nil {
+		return sqliteBTreePage{}, err
+	}
+	offset := 0
+	if number == 1 {
+		offset = sqlite_header_size
+	}
+	page := sqliteBTreePage{data: data, kind: data[offset]}
+	pointers := offset + 8
+	switch page.kind {
+	case sqlite_interior_page, sqlite_interior_index:
+		page.right = int(binary.BigEndian.Uint32(data[offset+8:]))
+		pointers = offset + 12
+	case sqlite_leaf_table, sqlite_leaf_index:
+	default:
+		return sqliteBTreePage{}, fmt.Errorf("page %d is not a b-tree page", number)
+	}
+	count := int(binary.BigEndian.Uint16(data[offset+3:]))
+	if pointers+2*count > len(data) {
+		return sqliteBTreePage{}, fmt.Errorf("page %d is corrupt", number)
+	}
+	for i := 0; i < count; i++ {
+		page.cells = append(page.cells, int(binary.BigEndian.Uint16(data[pointers+2*i:])))
+	}
+	return page, nil
+}
+
+// Return the payload of a cell, given where it starts in the page and its size, following its overflow pages if it has any
+func (database *sqliteDatabase) payload(page []byte, offset int, size int, index bool) ([]byte, error) {
+	maxLocal, minLocal := sqliteMaxLocal(database.usable, index), sqliteMinLocal(database.usable)
+	local := size
+	if size > maxLocal {
+		local = minLocal + (size-minLocal)%(database.usable-4)
+		if local > maxLocal {
+			local = minLocal
+		}
+	}
+	if offset+local > len(page) {
+		return nil, fmt.Errorf("a cell runs off its page")
+	}
+	payload := append(make([]byte, 0, size), page[offset:offset+local]...)
+	if local == size {
+		return payload, nil
+	}
+	if offset+local+4 > len(page) {
+		return nil, fmt.Errorf("a cell runs off its page")
+	}
+	next := int(binary.BigEndian.Uint32(page[offset+local:]))
+	for len(payload) < size {
+		if next == 0 {
+			return nil, fmt.Errorf("a chain of overflow pages ends early")
+		}
+		overflow, err := database.page(next)
+		if err != nil {
+			return nil, err
+		}
+		next = int(binary.BigEndian.Uint32(overflow))
+		payload = append(payload, overflow[4:min(database.usable, 4+size-len(payload))]...)
+	}
+	return payload, nil
+}
+
+// Visit the rows of a table in rowid order, until visit returns false
+func (database *sqliteDatabase) scanTable(root int, visit func(rowid int64, values []interface{}) bool) error {
+	_, err := database.walkTable(root, visit)
+	return err
+}
+
+// Visit the rows held in a table b-tree page and the pages below it; the result is false once visit has returned false
+func (database *sqliteDatabase) walkTable(number int, visit func(rowid int64, values []interface{}) bool) (bool, error) {
+	page, err := database.bTreePage(number)
+	if err != nil {
+		return false, err
+	}
+	for _, offset := range page.cells {
+		if page.kind == sqlite_interior_page {
+			if more, err := database.walkTable(int(binary.BigEndian.Uint32(page.data[offset:])), visit); !more || (err != nil) {
+				return more, err
+			}
+			continue
+		}
+		rowid, values, err := database.tableCell(page, offset)
+		if err != nil {
+			return false, fmt.Errorf("page %d: %w", number, err)
+		}
+		if !visit(rowid, values) {
+			return false, nil
+		}
+	}
+	if page.kind == sqlite_interior_page {
+		return database.walkTable(page.right, visit)
+	}
+	return true, nil
+}
+
+// Return the row of a table with the given rowid; ok is false if there is none
+func (database *sqliteDatabase) row(root int, rowid int64) (values []interface{}, ok bool, err error) {
+	for number := root; ; {
+		page, err := database.bTreePage(number)
+		if err != nil {
+			return nil, false, err
+		}
+		if page.kind == sqlite_leaf_table {
+			for _, offset := range page.cells {
+				found, values, err := database.tableCell(page, offset)
+				if (err != nil) || (found == rowid) {
+					return values, err == nil, err
+				}
+			}
+			return nil, false, nil
+		}
+		if page.kind != sqlite_interior_page {
+			return nil, false, fmt.Errorf("page %d is not part of a table", number)
+		}
+		// Each cell's child holds the rowids up to the cell's key; the rightmost child holds the rest
+		next := page.right
+		for _, offset := range page.cells {
+			key, _, err := sqliteReadVarint(page.data, offset+4)
+			if err != nil {
+				return nil, false, err
+			}
+			if rowid <= int64(key) {
+				next = int(binary.BigEndian.Uint32(page.data[offset:]))
+				break
+			}
+		}
+		number = next
+	}
+}
+
+// Return the rowid and the values of the row in a cell of a table leaf page
+func (database *sqliteDatabase) tableCell(page sqliteBTreePage, offset int) (int64, []interface{}, error) {
+	size, offset, err := sqliteReadVarint(page.data, offset)
+	if err != nil {
+		return 0, nil, err
+	}
+	rowid, offset, err := sqliteReadVarint(page.data, offset)
+	if err != nil {
+		return 0, nil, err
+	}
+	payload, err := database.payload(page.data, offset, int(size), false)
+	if err != nil {
+		return 0, nil, err
+	}
+	values, err := sqliteDecodeRecord(payload)
+	return int64(rowid), values, err
+}
+
+// Visit the entries of an index in order, starting with the first whose leading values are not before from, until
+// visit returns false. Each entry is the indexed values followed by the rowid of their row in the table.
+// Only the pages on the way to the entries visited are read, as SQLite itself does for an indexed query.
+func (database *sqliteDatabase) seekIndex(root int, from []interface{}, visit func(entry []interface{}) bool) error {
+	_, err := database.walkIndex(root, from, visit)
+	return err
+}
+
+// Visit the entries held in an index b-tree page and the pages below it that are not before from;
+// the result is false once visit has returned false
+func (database *sqliteDatabase) walkIndex(number int, from []interface{}, visit func(entry []interface{}) bool) (bool, error) {
+	page, err := database.bTreePage(number)
+	if err != nil {
+		return false, err
+	}
+	if (page.kind != sqlite_leaf_index) && (page.kind != sqlite_interior_index) {
+		return false, fmt.Errorf("page %d is not part of an index", number)
+	}
+	for _, offset := range page.cells {
+		child := 0
+		if page.kind == sqlite_interior_index {
+			child, offset = int(binary.BigEndian.Uint32(page.data[offset:])), offset+4
+		}
+		size, offset, err := sqliteReadVarint(page.data, offset)
+		if err != nil {
+			return false, err
+		}
+		payload, err := database.payload(page.data, offset, int(size), true)
+		if err != nil {
+			return false, err
+		}
+		entry, err := sqliteDecodeRecord(payload)
+		if err != nil {
+			return false, fmt.Errorf("page %d: %w", number, err)
+		}
+		// Everything in the child is before the entry, so neither is wanted if the entry is before from
+		if sqliteCompareKeys(entry[:min(len(entry), len(from))], from) < 0 {
+			continue
+		}
+		if child != 0 {
+			if more, err := database.walkIndex(child, from, visit); !more || (err != nil) {
+				return more, err
+			}
+		}
+		if !visit(entry) {
+			return false, nil
+		}
+	}
+	if page.kind == sqlite_interior_index {
+		return database.walkIndex(page.right, from, visit)
+	}
+	return true, nil
+}
+
+// Decode a SQLite record into its values: each is nil, an int64, a float64, a string or a []byte
+func sqliteDecodeRecord(record []byte) ([]interface{}, error) {
+	size, offset, err := sqliteReadVarint(record, 0)
+	if err != nil {
+		return nil, err
+	}
+	if int(size) > len(record) {
+		return nil, fmt.Errorf("bad record header")
+	}
+	body := int(size)
+	values := make([]interface{}, 0)
+	for offset < int(size) {
+		var kind uint64
+		if kind, offset, err = sqliteReadVarint(record, offset); err != nil {
+			return nil, err
+		}
+		length := 0
+		switch {
+		case (kind >= 1) && (kind <= 4):
+			length = int(kind)
+		case kind == 5:
+			length = 6
+		case (kind == 6) || (kind == 7):
+			length = 8
+		case (kind >= 12) && (kind%2 == 0):
+			length = int(kind-12) / 2
+		case kind >= 13:
+			length = int(kind-13) / 2
+		}
+		if body+length > len(record) {
+			return nil, fmt.Errorf("bad record")
+		}
+		field := record[body : body+length]
+		body += length
+		switch {
+		case kind == 0:
+			values = append(values, nil)
+		case kind <= 6:
+			// A big-endian two's complement integer, sign-extended from its top byte
+			v := int64(int8(field[0]))
+			for _, b := range field[1:] {
+				v = v<<8 | int64(b)
+			}
+			values = append(values, v)
+		case kind == 7:
+			values = append(values, math.Float64frombits(binary.BigEndian.Uint64(field)))
+		case kind == 8:
+			values = append(values, int64(0))
+		case kind == 9:
+			values = append(values, int64(1))
+		case kind < 12:
+			return nil, fmt.Errorf("bad serial type %d", kind)
+		case kind%2 == 0:
+			values = append(values, append([]byte(nil), field...))
+		default:
+			values = append(values, string(field))
+		}
+	}
+	return values, nil
+}
+
+// Read a SQLite variable-length integer (see sqliteVarint) starting at offset, returning it and the offset after it.
+// The ninth byte, if there is one, contributes all 8 of its bits.
+func sqliteReadVarint(b []byte, offset int) (uint64, int, error) {
+	v := uint64(0)
+	for i := 0; i < 9; i++ {
+		if offset+i >= len(b) {
+			return 0, 0, fmt.Errorf("a number runs off the end of its page")
+		}
+		c := b[offset+i]
+		if i == 8 {
+			return v<<8 | uint64(c), offset + 9, nil
+		}
+		v = v<<7 | uint64(c&0x7f)
+		if c&0x80 == 0 {
+			return v, offset + i + 1, nil
+		}
+	}
+	return v, offset + 9, nil
+}
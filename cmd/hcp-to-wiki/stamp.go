@@ -0,0 +1,72 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"runtime/debug"
+	"time"
+
+	"github.com/AntonioCarlini/home-computer-prices/hcp"
+)
+
+// Build the metadata stamp added to the output with -stamp, so that a published table says what produced it.
+// It names the tool version, a hash of the input files (so pages built from older data can be spotted),
+// the row counts and the date of generation, e.g.
+// "Generated by hcp-to-wiki v1.2.0 on 2026-10-14 from 2 file(s), sha256 0123456789abcdef: 480 rows, 471 accepted, 9 rejected".
+func generationStamp(filenames []string, validations []hcp.FileValidation) string {
+	digest := sha256.New()
+	for _, filename := range filenames {
+		f, err := os.Open(filename)
+		if err != nil {
+			log.Fatalf("Cannot read '%s': %s\n", filename, err.Error())
+		}
+		_, err = io.Copy(digest, f)
+		f.Close()
+		if err != nil {
+			log.Fatalf("Cannot read '%s': %s\n", filename, err.Error())
+		}
+	}
+	rows, accepted, rejected := 0, 0, 0
+	for _, validation := range validations {
+		rows += validation.Rows
+		accepted += validation.Accepted
+		rejected += validation.Rejected
+	}
+	return fmt.Sprintf("Generated by hcp-to-wiki %s on %s from %d file(s), sha256 %s: %d rows, %d accepted, %d rejected",
+		toolVersion(), time.Now().UTC().Format("2006-01-02"), len(filenames), hex.EncodeToString(digest.Sum(nil))[:16], rows, accepted, rejected)
+}
+
+// Return the version of this program: its module version if it was installed as a release,
+// otherwise the revision of the source it was built from, if known
+func toolVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "(unknown version)"
+	}
+	if (info.Main.Version != "") && (info.Main.Version != "(devel)") {
+		return info.Main.Version
+	}
+	revision, modified := "", false
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			revision = setting.Value
+		case "vcs.modified":
+			modified = setting.Value == "true"
+		}
+	}
+	if revision == "" {
+		return "(development version)"
+	}
+	if len(revision) > 12 {
+		revision = revision[:12]
+	}
+	if modified {
+		revision += "+modified"
+	}
+	return "revision " + revision
+}
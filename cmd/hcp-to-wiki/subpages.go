@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// A wikiPage is the complete wikitext of one page, ready to be published under its title
+type wikiPage struct {
+	title string
+	text  string
+}
+
+// Given advert data for a range of systems, build the pages for the subpage layout used by large wiki datasets:
+// each group of years gets its own subpage, such as "Home computer prices/1980–1984", and the page named
+// by base becomes an index that transcludes each subpage under its own heading.
+// The index page is first.
+func wikiSubpages(table priceTable, base string) []wikiPage {
+	index := wikiPage{title: base}
+	subpages := make([]wikiPage, 0)
+	groups := table.groupYears()
+	for i, group := range groups {
+		title := fmt.Sprintf("%s/%d–%d", base, group.first, group.last)
+		var text bytes.Buffer
+		outputWikiGroup(&text, table, group)
+		outputWikiFooter(&text, table)
+		subpages = append(subpages, wikiPage{title: title, text: text.String()})
+		index.text += fmt.Sprintf("== %d - %d ==\n\n{{:%s}}\n\n", group.first, group.last, title)
+		progress.update("Rendering", i+1, len(groups), "tables")
+	}
+	var footer bytes.Buffer
+	outputWikiFooter(&footer, table)
+	index.text += footer.String()
+	return append([]wikiPage{index}, subpages...)
+}
+
+// Write each page to its own file in the directory (the current directory if none is given), creating the directory if necessary.
+// A file is named after its page's title, with any "/" written as "%2F" and ".wiki" appended.
+func writePages(dir string, pages []wikiPage) {
+	if dir == "" {
+		dir = "."
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		log.Fatalf("Cannot create '%s': %s\n", dir, err.Error())
+	}
+	for _, page := range pages {
+		filename := filepath.Join(dir, strings.ReplaceAll(page.title, "/", "%2F")+".wiki")
+		if err := os.WriteFile(filename, []byte(page.text), 0644); err != nil {
+			log.Fatalf("Cannot write '%s': %s\n", filename, err.Error())
+		}
+		writtenFiles = append(writtenFiles, filename)
+	}
+}
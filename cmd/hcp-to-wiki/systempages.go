@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/AntonioCarlini/home-computer-prices/hcp"
+)
+
+// With -system-pages, the archive output is accompanied by a static site of a page per system, written to
+// -out-dir/systems/ with an index.html linking to them all. Each page has the system's chart and price table,
+// as in the archive, followed by a summary of where its prices came from and a list of every advert behind them,
+// so that a visitor looking for one machine need not scroll through the whole archive.
+
+// The subdirectory of -out-dir holding the system pages
+const system_pages_dir = "systems"
+
+// Return the name of the file holding a system's page
+func systemPageFilename(system string) string {
+	return strings.ReplaceAll(system, "/", "%2F") + ".html"
+}
+
+// Write a page for each system with prices, and an index of them, to the systems subdirectory of outputDir
+func writeSystemPages(table priceTable, outputDir string) {
+	dir := filepath.Join(outputDir, system_pages_dir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		log.Fatalf("Cannot create '%s': %s\n", dir, err.Error())
+	}
+	systems := make([]string, 0, len(table.keys))
+	for _, key := range table.keys {
+		if table.hasPrices(key) {
+			systems = append(systems, key)
+		}
+	}
+	for i, key := range systems {
+		progress.update("Writing", i+1, len(systems), "system pages")
+		writeOutput(filepath.Join(dir, systemPageFilename(key)), func(w io.Writer) {
+			outputSystemPage(w, table, key)
+		})
+	}
+	writeOutput(filepath.Join(dir, "index.html"), func(w io.Writer) {
+		outputSystemIndex(w, table, systems)
+	})
+}
+
+// Outputs the start of a system page or the index, up to and including its heading
+func outputSystemPageHeader(w io.Writer, title string) {
+	fmt.Fprintf(w, "<!DOCTYPE html>\n<html lang=\"en\">\n<head>\n<meta charset=\"utf-8\">\n")
+	fmt.Fprintf(w, "<title>%s</title>\n<style>\n%s\n</style>\n</head>\n<body>\n", html.EscapeString(title), archive_style)
+	fmt.Fprintf(w, "<h1>%s</h1>\n", html.EscapeString(title))
+}
+
+// Outputs the end of a system page or the index: the attribution and the metadata stamp
+func outputSystemPageFooter(w io.Writer, table priceTable) {
+	outputHTMLAttribution(w, table)
+	if table.stamp != "" {
+		fmt.Fprintf(w, "<!-- %s -->\n", table.stamp)
+	}
+	fmt.Fprintf(w, "</body>\n</html>\n")
+}
+
+// Outputs the index of the system pages, a link to each with the span of quarters it has prices for
+func outputSystemIndex(w io.Writer, table priceTable, systems []string) {
+	outputSystemPageHeader(w, table.pageTitle())
+	fmt.Fprintf(w, "<ul>\n")
+	for _, key := range systems {
+		first, last := table.priceSpan(key)
+		fmt.Fprintf(w, "<li><a href=\"%s\">%s</a> (%s to %s)</li>\n", html.EscapeString(url.PathEscape(systemPageFilename(key))), html.EscapeString(key), hcp.FormatQuarter(first), hcp.FormatQuarter(last))
+	}
+	fmt.Fprintf(w, "</ul>\n")
+	outputSystemPageFooter(w, table)
+}
+
+// Outputs the page for one system: its chart and prices, a summary of its adverts and the list of them
+func outputSystemPage(w io.Writer, table priceTable, key string) {
+	outputSystemPageHeader(w, key)
+	fmt.Fprintf(w, "<p><a href=\"index.html\">All systems</a></p>\n")
+	for _, note := range table.legend() {
+		fmt.Fprintf(w, "<p>%s</p>\n", html.EscapeString(note))
+	}
+	writeSystemPrices(w, table, key)
+
+	// Every advert behind the system's prices, in date order; a withheld price's adverts are listed too
+	adverts := make([]hcp.Advert, 0)
+	for _, quarter := range table.observations[key] {
+		adverts = append(adverts, quarter...)
+	}
+	magazines := make([]string, 0)
+	low, high := 0, 0
+	for _, advert := range adverts {
+		if !sliceContainsString(magazines, advert.Magazine) {
+			magazines = append(magazines, advert.Magazine)
+		}
+		if (low == 0) || (advert.Price < low) {
+			low = advert.Price
+		}
+		high = max(high, advert.Price)
+	}
+	first, last := table.priceSpan(key)
+	fmt.Fprintf(w, "<h2>Summary</h2>\n<dl>\n")
+	fmt.Fprintf(w, "<dt>Prices</dt><dd>%s to %s</dd>\n", hcp.FormatQuarter(first), hcp.FormatQuarter(last))
+	fmt.Fprintf(w, "<dt>Adverts</dt><dd>%d</dd>\n", len(adverts))
+	if len(adverts) > 0 {
+		fmt.Fprintf(w, "<dt>Advert prices</dt><dd>£%s to £%s</dd>\n", formatPrice(low, "exact"), formatPrice(high, "exact"))
+		fmt.Fprintf(w, "<dt>Magazines</dt><dd>%s</dd>\n", html.EscapeString(strings.Join(magazines, ", ")))
+	}
+	fmt.Fprintf(w, "</dl>\n")
+
+	if len(adverts) > 0 {
+		fmt.Fprintf(w, "<h2>Adverts</h2>\n<table>\n<tr><th>Issue</th><th>Magazine</th><th>Page</th><th>Price</th><th>Notes</th></tr>\n")
+		for _, advert := range adverts {
+			// A page number that could not be read is not worth repeating
+			page := ""
+			if advert.Page >= 0 {
+				page = strconv.Itoa(advert.Page)
+			}
+			notes := make([]string, 0)
+			if advert.System != key {
+				notes = append(notes, "as "+advert.System)
+			}
+			if advert.ExVAT {
+				notes = append(notes, "VAT added")
+			}
+			if advert.ApproximateDate {
+				notes = append(notes, "dated by year only")
+			}
+			if advert.Region != "" {
+				notes = append(notes, "region "+advert.Region)
+			}
+			if advert.Size != "" {
+				notes = append(notes, advert.Size)
+			}
+			fmt.Fprintf(w, "<tr><td>%04d-%02d</td><td>%s</td><td>%s</td><td class=\"price\">£%s</td><td>%s</td></tr>\n", advert.Year, advert.Month, html.EscapeString(advert.Magazine), page, formatPrice(advert.Price, "exact"), html.EscapeString(strings.Join(notes, ", ")))
+		}
+		fmt.Fprintf(w, "</table>\n")
+	}
+	outputSystemPageFooter(w, table)
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/AntonioCarlini/home-computer-prices/hcp"
+)
+
+// The template output supplies only the data, leaving the presentation to templates maintained on the wiki.
+// Each half-decade group, as in the wiki output, becomes:
+//
+//	== 1980 - 1984 ==
+//
+//	{{PriceTableStart|first=1980|last=1984}}
+//	{{PriceRow|system=Sinclair ZX81|1981Q1=69|1981Q2=59|1981Q2 kind=interpolated|1981Q3=49}}
+//	{{PriceTableEnd}}
+//
+// Quarters without a price are left out of the PriceRow call; an estimated price is followed by its kind.
+
+// Given advert data for a range of systems, outputs that data as calls to wiki templates
+func outputTemplates(w io.Writer, table priceTable) {
+	systems, keys, minDate, maxDate := table.systems, table.keys, table.minDate, table.maxDate
+
+	for _, group := range table.groupYears() {
+		groupYear, lastYear := group.first, group.last
+		fmt.Fprintf(w, "== %d - %d ==\n\n", groupYear, lastYear)
+		fmt.Fprintf(w, "{{PriceTableStart|first=%d|last=%d}}\n", groupYear, lastYear)
+		for _, key := range keys {
+			prices := systems[key]
+			if !systemHasPriceData(groupYear, lastYear, minDate, maxDate, prices) {
+				continue
+			}
+			fmt.Fprintf(w, "{{PriceRow|system=%s", templateValue(key))
+			first := max(hcp.BuildIndexFromYearAndQuarter(groupYear, 1), minDate)
+			last := min(hcp.BuildIndexFromYearAndQuarter(lastYear, 4), maxDate)
+			for index := first; index <= last; index++ {
+				if prices[index-minDate] <= 0 {
+					continue
+				}
+				quarter := hcp.FormatQuarter(index)
+				fmt.Fprintf(w, "|%s=%s", quarter, formatPrice(prices[index-minDate], table.rounding))
+				if kind := table.kind(key, index); kind != observedPrice {
+					fmt.Fprintf(w, "|%s kind=%s", quarter, kind)
+				}
+			}
+			fmt.Fprintf(w, "}}\n")
+		}
+		fmt.Fprintf(w, "{{PriceTableEnd}}\n\n")
+	}
+	outputWikiFooter(w, table)
+}
+
+// Given a string, return it in a form that can safely be passed as a template parameter value
+func templateValue(text string) string {
+	return strings.NewReplacer("|", "{{!}}", "{{", "&#123;&#123;", "}}", "&#125;&#125;").Replace(text)
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/AntonioCarlini/home-computer-prices/hcp"
+)
+
+// With -totals, each wiki table ends with two footer rows summarising the market at a glance: the number of
+// systems priced in each quarter and the median of their prices. Estimated prices shown in the table count,
+// as they are shown; withheld prices do not.
+
+// Given a date-index, return how many systems have a price in the table for that quarter and the median of those prices
+func (table priceTable) quarterSummary(index int) (priced int, median int) {
+	if (index < table.minDate) || (index > table.maxDate) {
+		return 0, 0
+	}
+	prices := make([]int, 0, len(table.keys))
+	for _, key := range table.keys {
+		if price := table.systems[key][index-table.minDate]; price > 0 {
+			prices = append(prices, price)
+		}
+	}
+	if len(prices) == 0 {
+		return 0, 0
+	}
+	return len(prices), hcp.PriceAggregations["median"](prices)
+}
+
+// Outputs the footer rows of the wiki table for a group of years, if -totals asked for them
+func outputWikiTotals(w io.Writer, table priceTable, group yearGroup) {
+	if !table.totals {
+		return
+	}
+	counts, medians := make([]string, 0), make([]string, 0)
+	first, last := table.groupQuarters(group)
+	for index := first; index <= last; index++ {
+		priced, median := table.quarterSummary(index)
+		counts = append(counts, fmt.Sprintf("style=\"text-align: right;\" | %d", priced))
+		if priced == 0 {
+			medians = append(medians, "style=\"text-align: center;\" | &mdash;")
+		} else {
+			medians = append(medians, "style=\"text-align: right;\" | "+table.wikiPrice(median))
+		}
+	}
+	fmt.Fprintf(w, "|-\n! %s\n     | %s\n", table.note("systems-priced", "Systems priced"), strings.Join(counts, " || "))
+	fmt.Fprintf(w, "|-\n! %s\n     | %s\n", table.note("median-price", "Median price"), strings.Join(medians, " || "))
+}
@@ -0,0 +1,40 @@
+package main
+
+import "github.com/AntonioCarlini/home-computer-prices/hcp"
+
+// With -trim-quarters, each table leaves out the quarters before the first in which any of its systems has a price,
+// or a withheld price, and those after the last, so that a table for years only partly covered by the data, such
+// as the first, is not mostly columns of dashes. The quarters between are all shown, with or without prices.
+
+// Return the date-indices of the first and last quarters shown in the table for a group of years:
+// every quarter of its years, unless -trim-quarters leaves out the empty quarters at either end
+func (table priceTable) groupQuarters(group yearGroup) (first int, last int) {
+	first, last = hcp.BuildIndexFromYearAndQuarter(group.first, 1), hcp.BuildIndexFromYearAndQuarter(group.last, 4)
+	if !table.trimQuarters {
+		return first, last
+	}
+	keys := table.groupKeys(group)
+	shown := func(index int) bool {
+		if (index < table.minDate) || (index > table.maxDate) {
+			return false
+		}
+		for _, key := range keys {
+			if (table.systems[key][index-table.minDate] > 0) || (table.kind(key, index) == withheldPrice) {
+				return true
+			}
+		}
+		return false
+	}
+	trimmedFirst, trimmedLast := first, last
+	for (trimmedFirst <= last) && !shown(trimmedFirst) {
+		trimmedFirst++
+	}
+	for (trimmedLast >= trimmedFirst) && !shown(trimmedLast) {
+		trimmedLast--
+	}
+	if trimmedFirst > trimmedLast {
+		// Nothing in the table has a price, so there is nothing to trim to
+		return first, last
+	}
+	return trimmedFirst, trimmedLast
+}
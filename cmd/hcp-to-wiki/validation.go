@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/AntonioCarlini/home-computer-prices/hcp"
+)
+
+// Print each problem found in the rows of the input files
+func printRowProblems(validations []hcp.FileValidation) {
+	for _, validation := range validations {
+		for _, problem := range validation.Problems {
+			logf("%s\n", problem)
+		}
+	}
+}
+
+// Print a one-line validation summary for each input file
+func printValidationSummary(validations []hcp.FileValidation) {
+	for _, validation := range validations {
+		logf("%s: %d rows, %d accepted, %d rejected, %d warnings\n", validation.Filename, validation.Rows, validation.Accepted, validation.Rejected, validation.Warnings)
+	}
+}
+
+// Write the per-file validation results as a JSON report
+func writeValidationReport(filename string, validations []hcp.FileValidation) error {
+	data, err := json.MarshalIndent(validations, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, append(data, '\n'), 0644)
+}
+
+// Read a JSON report previously written by writeValidationReport
+func readValidationReport(filename string) ([]hcp.FileValidation, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var validations []hcp.FileValidation
+	if err := json.Unmarshal(data, &validations); err != nil {
+		return nil, fmt.Errorf("bad validation report [%s] (%w)", filename, err)
+	}
+	return validations, nil
+}
+
+// A file whose validation results are worse than in the baseline
+type validationRegression struct {
+	before hcp.FileValidation
+	after  hcp.FileValidation
+}
+
+func (regression validationRegression) String() string {
+	before, after := regression.before, regression.after
+	return fmt.Sprintf("%s: validation regressed (rejected %d => %d, warnings %d => %d)", after.Filename, before.Rejected, after.Rejected, before.Warnings, after.Warnings)
+}
+
+// Compare the current validation results against a baseline and return the files that regressed.
+// A file regresses if it has more rejected rows or more warnings than it had in the baseline.
+// A file that is not in the baseline is compared against a clean result, so any problem in it counts as a regression.
+func findRegressions(current []hcp.FileValidation, baseline []hcp.FileValidation) []validationRegression {
+	previous := make(map[string]hcp.FileValidation)
+	for _, validation := range baseline {
+		previous[validation.Filename] = validation
+	}
+
+	regressed := make([]validationRegression, 0)
+	for _, validation := range current {
+		before := previous[validation.Filename]
+		if (validation.Rejected > before.Rejected) || (validation.Warnings > before.Warnings) {
+			regression := validationRegression{before, validation}
+			logf("%s\n", regression)
+			regressed = append(regressed, regression)
+		}
+	}
+	return regressed
+}
+
+// Compare the current validation results against a baseline and describe each file whose number of accepted rows
+// has changed by more than the given percentage, which may mean that a merge has lost or duplicated data.
+// Files that are not in the baseline are new rather than changed, so are not reported.
+func findDataChanges(current []hcp.FileValidation, baseline []hcp.FileValidation, percent int) []string {
+	previous := make(map[string]hcp.FileValidation)
+	for _, validation := range baseline {
+		previous[validation.Filename] = validation
+	}
+
+	changes := make([]string, 0)
+	for _, validation := range current {
+		before, ok := previous[validation.Filename]
+		if !ok || (before.Accepted == validation.Accepted) {
+			continue
+		}
+		change := 100
+		if before.Accepted > 0 {
+			change = abs(validation.Accepted-before.Accepted) * 100 / before.Accepted
+		}
+		if change > percent {
+			changes = append(changes, fmt.Sprintf("%s: accepted rows changed by %d%% (%d => %d)", validation.Filename, change, before.Accepted, validation.Accepted))
+		}
+	}
+	return changes
+}
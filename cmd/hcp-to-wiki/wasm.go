@@ -0,0 +1,140 @@
+//go:build js && wasm
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"syscall/js"
+
+	"github.com/AntonioCarlini/home-computer-prices/hcp"
+)
+
+// Built with "GOOS=js GOARCH=wasm go build -o hcp.wasm ./cmd/hcp-to-wiki", the program does not act on a command line
+// but installs an "hcp" object in the JavaScript global scope and waits to be called. This lets a browser page for
+// data entry and preview check and render rows with exactly the same code as the command line. Load it with the
+// wasm_exec.js that comes with Go, then call:
+//
+//	hcp.parse(csvText)                  => { adverts: [...], validation: {...} }
+//	hcp.aggregate(csvText, options)     => the document written to plugins (see pluginDocument)
+//	hcp.render(csvText, options)        => { output: "..." }
+//
+// options is an optional object whose fields mirror the command line:
+// { format: "wiki", aggregate: "min", rounding: "trunc", interpolate: false, carryForward: false, config: "{...}" },
+// where config is the text of a configuration file. Every function returns { error: "..." } if it fails.
+func init() {
+	wasmMain = func() {
+		js.Global().Set("hcp", js.ValueOf(map[string]interface{}{
+			"parse":     js.FuncOf(wasmParse),
+			"aggregate": js.FuncOf(wasmAggregate),
+			"render":    js.FuncOf(wasmRender),
+		}))
+		// Keep running so that the functions remain callable
+		select {}
+	}
+}
+
+// The name under which CSV text passed from JavaScript appears in the validation results
+const wasm_input_name = "input"
+
+// Implements hcp.parse(csvText)
+func wasmParse(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 {
+		return wasmError("parse needs the CSV text")
+	}
+	adverts, _, _, validation, err := hcp.ParseAdverts(wasm_input_name, strings.NewReader(args[0].String()))
+	if err != nil {
+		return wasmError(err.Error())
+	}
+	problems := make([]string, 0, len(validation.Problems))
+	for _, problem := range validation.Problems {
+		problems = append(problems, problem.String())
+	}
+	return wasmValue(map[string]interface{}{"adverts": adverts, "validation": validation, "problems": problems})
+}
+
+// Implements hcp.aggregate(csvText, options)
+func wasmAggregate(this js.Value, args []js.Value) interface{} {
+	table, _, err := wasmTable(args)
+	if err != "" {
+		return wasmError(err)
+	}
+	return wasmValue(newPluginDocument(table))
+}
+
+// Implements hcp.render(csvText, options)
+func wasmRender(this js.Value, args []js.Value) interface{} {
+	table, options, err := wasmTable(args)
+	if err != "" {
+		return wasmError(err)
+	}
+	format := "wiki"
+	if value := options.Get("format"); value.Type() == js.TypeString {
+		format = value.String()
+	}
+	renderer, ok := lookupFormat(format)
+	if !ok {
+		return wasmError("unknown output format [" + format + "]")
+	}
+	var output bytes.Buffer
+	renderer.render(&output, table)
+	return wasmValue(map[string]string{"output": output.String()})
+}
+
+// Given the arguments of aggregate or render, build the price table from the CSV text according to the options.
+// Return the options too; if anything is wrong, the error is not empty.
+func wasmTable(args []js.Value) (priceTable, js.Value, string) {
+	if len(args) < 1 {
+		return priceTable{}, js.Undefined(), "the CSV text is needed"
+	}
+	options := js.Global().Get("Object").New()
+	if (len(args) > 1) && (args[1].Type() == js.TypeObject) {
+		options = args[1]
+	}
+	text := func(name string, otherwise string) string {
+		if value := options.Get(name); value.Type() == js.TypeString {
+			return value.String()
+		}
+		return otherwise
+	}
+	flag := func(name string) bool {
+		value := options.Get(name)
+		return (value.Type() == js.TypeBoolean) && value.Bool()
+	}
+
+	config := hcp.DefaultConfiguration()
+	if configText := text("config", ""); configText != "" {
+		var err error
+		if config, err = hcp.ParseConfiguration("config", []byte(configText)); err != nil {
+			return priceTable{}, options, err.Error()
+		}
+	}
+	rounding := text("rounding", "trunc")
+	if _, ok := priceRoundings[rounding]; !ok {
+		return priceTable{}, options, "unknown price rounding [" + rounding + "]"
+	}
+	dataset, err := hcp.LoadCSV(wasm_input_name, strings.NewReader(args[0].String()), hcp.Options{Config: &config, Aggregation: text("aggregate", "min")})
+	if err != nil {
+		return priceTable{}, options, err.Error()
+	}
+	table := newPriceTable(dataset, tableOptions{interpolate: flag("interpolate"), carry: flag("carryForward"), rounding: rounding})
+	table.attribution = config.Attribution
+	table.lifespans = config.Lifespans
+	table.placeholders = config.Placeholders
+	return table, options, ""
+}
+
+// Convert a Go value into the equivalent JavaScript value, by way of JSON
+func wasmValue(value interface{}) interface{} {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return wasmError(err.Error())
+	}
+	return js.Global().Get("JSON").Call("parse", string(encoded))
+}
+
+// Return the object that reports an error to JavaScript
+func wasmError(message string) interface{} {
+	return js.ValueOf(map[string]interface{}{"error": message})
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// After a run, -webhook posts a short summary of anything that dataset maintainers should look at to a chat webhook,
+// so that a bad merge is noticed quickly. With -validation-baseline the summary covers the files whose validation
+// regressed or whose number of accepted rows changed by more than -webhook-change percent; without a baseline it
+// covers every file with rejected rows. Nothing is posted if there is nothing to report.
+
+// The webhook styles accepted by -webhook-format, each of which wraps the message in the JSON its service expects.
+// A Matrix webhook is one provided by a bridge such as matrix-hookshot, which takes the same form as Slack.
+var webhookStyles = map[string]func(message string) interface{}{
+	"slack": func(message string) interface{} { return map[string]string{"text": message} },
+	"discord": func(message string) interface{} {
+		return map[string]string{"content": truncate(message, discord_max_message)}
+	},
+	"matrix": func(message string) interface{} { return map[string]string{"text": message} },
+}
+
+// Discord refuses messages longer than this many characters
+const discord_max_message = 2000
+
+// Given the lines describing what was found, build the message posted to the webhook
+func webhookMessage(inputs []string, lines []string) string {
+	return fmt.Sprintf("hcp-to-wiki found problems in the data from %s:\n%s", strings.Join(inputs, ", "), strings.Join(lines, "\n"))
+}
+
+// POST a message to a webhook in the given style
+func postWebhook(url string, style string, message string) error {
+	body, err := json.Marshal(webhookStyles[style](message))
+	if err != nil {
+		return err
+	}
+	request, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", wiki_user_agent)
+	client := &http.Client{Timeout: 30 * time.Second}
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	response.Body.Close()
+	if (response.StatusCode < 200) || (response.StatusCode > 299) {
+		return fmt.Errorf("webhook returned [%s]", response.Status)
+	}
+	return nil
+}
+
+// Return text cut down to at most limit characters, marking where it was cut
+func truncate(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return string(runes[:limit-1]) + "…"
+}
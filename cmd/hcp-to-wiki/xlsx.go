@@ -0,0 +1,263 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/AntonioCarlini/home-computer-prices/hcp"
+)
+
+// The xlsx output is an Excel workbook holding the same tables as the wiki output, a worksheet per group of years,
+// for contributors who work in spreadsheets. Each worksheet is laid out as the wiki table is, with the years above
+// the quarters, and its two header rows and the system column are frozen, so that they stay in view while scrolling.
+// Prices are numbers of pounds, so that they can be calculated with, marked as the wiki marks them: interpolated
+// prices in italics, carried prices in grey and the quarters a system was not on sale shaded. A price with a marker,
+// or with its median beside it, is text instead. The notes follow each table.
+// The workbook is written directly, following the Office Open XML format (ECMA-376), with inline strings and no
+// shared string table, as nothing more is needed.
+
+// The XML declaration that starts each part of the workbook
+const xlsx_header = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n"
+
+// The namespace of the SpreadsheetML parts and of the relationships between the parts
+const (
+	xlsx_main_namespace = "http://schemas.openxmlformats.org/spreadsheetml/2006/main"
+	xlsx_rels_namespace = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+)
+
+// The styles of the workbook's cells: the header and text, then each kind of price, whole or with pennies, and
+// either plain or shaded as off sale, in the order of xlsxPriceStyle
+var xlsx_styles = xlsx_header + `<styleSheet xmlns="` + xlsx_main_namespace + `">
+<numFmts count="2"><numFmt numFmtId="164" formatCode="&quot;£&quot;#,##0"/><numFmt numFmtId="165" formatCode="&quot;£&quot;#,##0.00"/></numFmts>
+<fonts count="4"><font><sz val="11"/><name val="Calibri"/></font><font><i/><sz val="11"/><name val="Calibri"/></font><font><sz val="11"/><color rgb="FF808080"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>
+<fills count="3"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill><fill><patternFill patternType="solid"><fgColor rgb="FFEEEEEE"/></patternFill></fill></fills>
+<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>
+<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>
+<cellXfs count="16">
+<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>
+<xf numFmtId="0" fontId="3" fillId="0" borderId="0" xfId="0" applyFont="1" applyAlignment="1"><alignment horizontal="center"/></xf>
+<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0" applyAlignment="1"><alignment horizontal="center"/></xf>
+<xf numFmtId="0" fontId="0" fillId="2" borderId="0" xfId="0" applyFill="1" applyAlignment="1"><alignment horizontal="center"/></xf>
+` + xlsx_price_styles + `</cellXfs>
+<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>
+</styleSheet>
+`
+
+// The cell styles of the prices, which follow the four others
+var xlsx_price_styles = func() string {
+	var styles strings.Builder
+	for font := 0; font < 3; font++ {
+		for format := 164; format <= 165; format++ {
+			for fill := 0; fill <= 2; fill += 2 {
+				fmt.Fprintf(&styles, "<xf numFmtId=\"%d\" fontId=\"%d\" fillId=\"%d\" borderId=\"0\" xfId=\"0\" applyNumberFormat=\"1\" applyFont=\"1\" applyFill=\"1\"/>\n", format, font, fill)
+			}
+		}
+	}
+	return styles.String()
+}()
+
+// Cell styles, as numbered in xlsx_styles
+const (
+	xlsx_style_header      = 1
+	xlsx_style_text        = 2
+	xlsx_style_shaded_text = 3
+)
+
+// Return the style of a price of the given kind, whole or with pennies, and shaded if the system was not on sale
+func xlsxPriceStyle(kind priceKind, pennies bool, shaded bool) int {
+	style := 4
+	switch kind {
+	case interpolatedPrice:
+		style += 4
+	case carriedPrice:
+		style += 8
+	}
+	if pennies {
+		style += 2
+	}
+	if shaded {
+		style++
+	}
+	return style
+}
+
+// Given advert data for a range of systems, outputs that data as an Excel workbook
+func outputXLSX(w io.Writer, table priceTable) {
+	groups := table.groupYears()
+	worksheets := make([]string, 0, len(groups))
+	var sheets, rels, overrides strings.Builder
+	for i, group := range groups {
+		fmt.Fprintf(&sheets, "<sheet name=\"%d - %d\" sheetId=\"%d\" r:id=\"rId%d\"/>", group.first, group.last, i+1, i+1)
+		fmt.Fprintf(&rels, "<Relationship Id=\"rId%d\" Type=\"%s/worksheet\" Target=\"worksheets/sheet%d.xml\"/>", i+1, xlsx_rels_namespace, i+1)
+		fmt.Fprintf(&overrides, "<Override PartName=\"/xl/worksheets/sheet%d.xml\" ContentType=\"application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml\"/>", i+1)
+		worksheets = append(worksheets, xlsxWorksheet(table, group))
+		progress.update("Rendering", i+1, len(groups), "worksheets")
+	}
+	fmt.Fprintf(&rels, "<Relationship Id=\"rId%d\" Type=\"%s/styles\" Target=\"styles.xml\"/>", len(groups)+1, xlsx_rels_namespace)
+	// The content types come first, as some readers expect, and the worksheets last
+	parts := []struct{ name, content string }{
+		{"[Content_Types].xml", xlsx_header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+			`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/>` +
+			`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+			`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` + overrides.String() + `</Types>`},
+		{"_rels/.rels", xlsx_header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="` + xlsx_rels_namespace + `/officeDocument" Target="xl/workbook.xml"/></Relationships>`},
+		{"xl/workbook.xml", xlsx_header + `<workbook xmlns="` + xlsx_main_namespace + `" xmlns:r="` + xlsx_rels_namespace + `"><sheets>` + sheets.String() + `</sheets></workbook>`},
+		{"xl/_rels/workbook.xml.rels", xlsx_header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` + rels.String() + `</Relationships>`},
+		{"xl/styles.xml", xlsx_styles},
+	}
+	for i, worksheet := range worksheets {
+		parts = append(parts, struct{ name, content string }{fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1), worksheet})
+	}
+
+	archive := zip.NewWriter(w)
+	for _, part := range parts {
+		f, err := archive.Create(part.name)
+		if err == nil {
+			_, err = io.WriteString(f, part.content)
+		}
+		if err != nil {
+			log.Fatalln("Cannot write workbook:", err.Error())
+		}
+	}
+	if err := archive.Close(); err != nil {
+		log.Fatalln("Cannot write workbook:", err.Error())
+	}
+}
+
+// Return the worksheet of the table for a group of years
+func xlsxWorksheet(table priceTable, group yearGroup) string {
+	first, last := table.groupQuarters(group)
+	var rows, merges strings.Builder
+	merged := 0
+
+	// The years, each above its quarters, then the quarters
+	fmt.Fprintf(&rows, "<row r=\"1\">")
+	column := 2
+	for year := group.first; year <= group.last; year++ {
+		shown := 0
+		for quarter := 1; quarter <= 4; quarter++ {
+			if index := hcp.BuildIndexFromYearAndQuarter(year, quarter); (index >= first) && (index <= last) {
+				shown++
+			}
+		}
+		if shown == 0 {
+			continue
+		}
+		rows.WriteString(xlsxNumberCell(xlsxCellName(column, 1), strconv.Itoa(year), xlsx_style_header))
+		if shown > 1 {
+			fmt.Fprintf(&merges, "<mergeCell ref=\"%s:%s\"/>", xlsxCellName(column, 1), xlsxCellName(column+shown-1, 1))
+			merged++
+		}
+		column += shown
+	}
+	fmt.Fprintf(&rows, "</row><row r=\"2\">%s", xlsxTextCell("A2", table.systemHeading(), xlsx_style_header))
+	for index := first; index <= last; index++ {
+		_, quarter := hcp.DecodeIndexByQuarter(index)
+		rows.WriteString(xlsxTextCell(xlsxCellName(index-first+2, 2), table.quarterHeadings()[quarter-1], xlsx_style_header))
+	}
+	rows.WriteString("</row>")
+
+	row := 3
+	for _, key := range table.groupKeys(group) {
+		fmt.Fprintf(&rows, "<row r=\"%d\">%s", row, xlsxTextCell(xlsxCellName(1, row), key, 0))
+		for index := first; index <= last; index++ {
+			rows.WriteString(table.xlsxCell(xlsxCellName(index-first+2, row), key, index))
+		}
+		rows.WriteString("</row>")
+		row++
+	}
+	if table.totals {
+		counts := xlsxTextCell(xlsxCellName(1, row), table.note("systems-priced", "Systems priced"), xlsx_style_header)
+		medians := xlsxTextCell(xlsxCellName(1, row+1), table.note("median-price", "Median price"), xlsx_style_header)
+		for index := first; index <= last; index++ {
+			priced, median := table.quarterSummary(index)
+			counts += xlsxNumberCell(xlsxCellName(index-first+2, row), strconv.Itoa(priced), xlsx_style_text)
+			if priced > 0 {
+				price := formatPrice(median, table.rounding)
+				medians += xlsxNumberCell(xlsxCellName(index-first+2, row+1), price, xlsxPriceStyle(observedPrice, strings.Contains(price, "."), false))
+			}
+		}
+		fmt.Fprintf(&rows, "<row r=\"%d\">%s</row><row r=\"%d\">%s</row>", row, counts, row+1, medians)
+		row += 2
+	}
+
+	// The notes, then the attribution and the stamp, each in a row of its own after a blank row
+	row++
+	notes := table.legend()
+	if !table.attribution.IsEmpty() {
+		notes = append(notes, attributionText(table.attribution))
+	}
+	if table.stamp != "" {
+		notes = append(notes, table.stamp)
+	}
+	for _, note := range notes {
+		fmt.Fprintf(&rows, "<row r=\"%d\">%s</row>", row, xlsxTextCell(xlsxCellName(1, row), note, 0))
+		row++
+	}
+
+	var sheet strings.Builder
+	sheet.WriteString(xlsx_header + `<worksheet xmlns="` + xlsx_main_namespace + `">`)
+	sheet.WriteString(`<sheetViews><sheetView workbookViewId="0"><pane xSplit="1" ySplit="2" topLeftCell="B3" activePane="bottomRight" state="frozen"/></sheetView></sheetViews>`)
+	sheet.WriteString(`<cols><col min="1" max="1" width="32" customWidth="1"/></cols>`)
+	fmt.Fprintf(&sheet, "<sheetData>%s</sheetData>", rows.String())
+	if merged > 0 {
+		fmt.Fprintf(&sheet, "<mergeCells count=\"%d\">%s</mergeCells>", merged, merges.String())
+	}
+	sheet.WriteString("</worksheet>")
+	return sheet.String()
+}
+
+// Return the cell of a system's row for its price at a date-index
+func (table priceTable) xlsxCell(name string, key string, index int) string {
+	shaded := table.offSale(key, index)
+	textStyle := xlsx_style_text
+	if shaded {
+		textStyle = xlsx_style_shaded_text
+	}
+	switch {
+	case (index >= table.minDate) && (index <= table.maxDate) && (table.kind(key, index) == withheldPrice):
+		return xlsxTextCell(name, withheld_marker, textStyle)
+	case (index < table.minDate) || (index > table.maxDate) || (table.systems[key][index-table.minDate] <= 0):
+		if placeholder, ok := table.placeholder(key, index); ok {
+			return xlsxTextCell(name, placeholder, textStyle)
+		}
+		if shaded {
+			return fmt.Sprintf("<c r=\"%s\" s=\"%d\"/>", name, textStyle)
+		}
+		return ""
+	}
+	pence := table.systems[key][index-table.minDate]
+	price := formatPrice(pence, table.rounding)
+	style := xlsxPriceStyle(table.kind(key, index), strings.Contains(price, "."), shaded)
+	if text := table.markedCellText(key, index, table.wikiPrice); text != table.wikiPrice(pence) {
+		return xlsxTextCell(name, text, style)
+	}
+	return xlsxNumberCell(name, price, style)
+}
+
+// Return the name of the cell in the given column and row, both counted from 1, such as "B3"
+func xlsxCellName(column int, row int) string {
+	letters := ""
+	for ; column > 0; column = (column - 1) / 26 {
+		letters = string(rune('A'+(column-1)%26)) + letters
+	}
+	return letters + strconv.Itoa(row)
+}
+
+// Return a cell holding a number, given as text
+func xlsxNumberCell(name string, number string, style int) string {
+	return fmt.Sprintf("<c r=\"%s\" s=\"%d\"><v>%s</v></c>", name, style, number)
+}
+
+// Return a cell holding text
+func xlsxTextCell(name string, text string, style int) string {
+	var escaped strings.Builder
+	xml.EscapeText(&escaped, []byte(text))
+	return fmt.Sprintf("<c r=\"%s\" s=\"%d\" t=\"inlineStr\"><is><t xml:space=\"preserve\">%s</t></is></c>", name, style, escaped.String())
+}
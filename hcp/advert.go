@@ -0,0 +1,660 @@
+package hcp
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// These constants represent the offset of the items in each advert read from the CSV file
+const ( // iota is reset to 0
+	adv_magazine = 0 //
+	adv_yyyy_mm  = 1 //
+	adv_page_num = 2 //
+	adv_system   = 3 //
+	adv_price    = 4 //
+	adv_blank_1  = 5 //
+	adv_kit      = 6 //
+	adv_board    = 7 //
+)
+
+// The names of optional columns, which may appear after the fixed columns, in any order, and are found by their header.
+// A Notes column, for contributors' annotations, may appear among them but is never read.
+const adv_region_header = "Region"
+const adv_contributor_header = "Contributor" // Who transcribed the row, counted in FileValidation.Contributors
+const adv_street_date_header = "Street date" // When the issue went on sale, as "YYYY-MM", if not its cover date; used to place the advert in a quarter
+const adv_size_header = "Advert size"        // How prominent the advert was, one of AdvertSizes, as classified prices behave differently
+const adv_currency_header = "Currency"       // The symbol of the currency the price was quoted in, such as "$", if it was converted into pounds
+
+// The sizes of advert that the Advert size column may hold (the case does not matter): a display advert of a page
+// or more, an entry in the classified listings, or a line in a dealer's price grid
+var AdvertSizes = []string{"full-page", "classified", "grid"}
+
+// A row whose first field begins with this is a comment, for contributors' annotations, and is never read
+const comment_prefix = "#"
+
+const max_page_num = 500 // Maximum magazine page number: anything higher than this is likely to be an error in the data
+const min_year = 1945    // Earliest acceptable year
+const max_year = 2099    // Latest acceptable year
+
+// An Advert is one row of the CSV data that passed validation
+type Advert struct {
+	File            string // CSV file the advert was read from
+	Row             int    // Row within the CSV file, counting from 1
+	Magazine        string // Magazine Title
+	Year            int    // Year (1945..current)
+	Month           int    // Month (1..12)
+	Page            int    // page number
+	System          string // Computer system name
+	Price           int    // Price in pence, including VAT
+	ExVAT           bool   // True if the advert quoted the price excluding VAT, so VAT has been added to Price
+	Kit             string // TODO: True if the system had to be assembled
+	Board           string // TODO: True if the system was a system board
+	Region          string // Where the advert was published, such as "US", from the optional Region column; "" for UK magazines
+	ApproximateDate bool   // True if the issue's date was known only to the year, so the advert was placed in a quarter by the configuration
+	CoverDateLead   int    // Months by which the issue's cover date, Year and Month, leads the date it went on sale: from the Street date column, or else Configuration.CoverDateLeads
+	Size            string // One of AdvertSizes, from the optional Advert size column; "" if it was not recorded
+	Currency        string // The symbol of the currency the price was quoted in before it was converted into pounds, from the optional Currency column; "" for prices in pounds
+}
+
+// A RowProblem is something wrong with one row of a CSV file
+type RowProblem struct {
+	Row      int      // Row within the CSV file, counting from 1
+	Field    string   // The field that was wrong, such as "price"
+	Text     string   // The text of that field
+	Err      error    // What was wrong with it
+	Record   []string // The whole row
+	Rejected bool     // True if the row was dropped; otherwise it was used despite the problem
+}
+
+func (problem RowProblem) String() string {
+	return fmt.Sprintf("Line %d: Bad %s [%s] (%s) in [%v]", problem.Row, problem.Field, problem.Text, problem.Err, problem.Record)
+}
+
+// Read data from a CSV file, opened with open or, if that is nil, os.Open
+// A file whose name ends in ".gz" is decompressed as it is read.
+// Fields are separated by delimiter, or by the delimiter detected from the data if that is 0 (see readCSVData).
+// Each row of data is represented as an array
+func readCSV(filename string, open OpenFunc, delimiter rune) ([][]string, error) {
+	f, err := openData(filename, open)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(strings.ToLower(filename), ".gz") {
+		decompressed, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, fmt.Errorf("cannot decompress [%s] (%w)", filename, err)
+		}
+		defer decompressed.Close()
+		r = decompressed
+	}
+
+	transactions, err := readCSVData(r, delimiter)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read CSV data from [%s] (%w)", filename, err)
+	}
+
+	return transactions, nil
+}
+
+// Open the named data with open or, if that is nil, os.Open
+func openData(filename string, open OpenFunc) (io.ReadCloser, error) {
+	if open == nil {
+		return os.Open(filename)
+	}
+	return open(filename)
+}
+
+// Parse CSV data that is not in a file, such as text pasted into a web page; name identifies the data in the validation results.
+// Return the adverts, the minimum and maximum date-indices seen and the validation results.
+func ParseAdverts(name string, r io.Reader) (adverts []Advert, minDate int, maxDate int, validation FileValidation, err error) {
+	data, err := readCSVData(r, 0)
+	if err != nil {
+		return nil, 0, 0, validation, fmt.Errorf("cannot read CSV data from [%s] (%w)", name, err)
+	}
+	if err := checkSchema(csvFile{name, data}); err != nil {
+		return nil, 0, 0, validation, err
+	}
+	result := rejectDateOutliers([]parsedFile{parseFile(csvFile{name, data}, DefaultConfiguration(), nil)}, DefaultConfiguration(), nil)[0]
+	return result.adverts, result.minDate, result.maxDate, result.validation, nil
+}
+
+// Read and parse each of the named CSV files, combining the adverts from all of them.
+// A file may be compressed with gzip (".csv.gz") or be a zip archive (".zip") of CSV files, each of which is read;
+// a file inside an archive has its own validation results, named "bundle.zip/file.csv".
+// Return the adverts, the minimum and maximum date-indices seen across all files and the validation results for each file.
+// Rows that fail validation are not an error; they are described in the validation results.
+func ReadAdverts(filenames []string) (adverts []Advert, minDate int, maxDate int, validations []FileValidation, err error) {
+	return readAdverts(filenames, Options{})
+}
+
+// ReadIssueAdverts is ReadAdverts limited to the rows from one issue of a magazine (see Options.Issue)
+func ReadIssueAdverts(filenames []string, issue *Issue) (adverts []Advert, minDate int, maxDate int, validations []FileValidation, err error) {
+	return readAdverts(filenames, Options{Issue: issue})
+}
+
+// As ReadAdverts, but the files are opened with opts.Open if it is set, and if opts.Progress is set it is called after each file has been parsed.
+// Prices are checked against the price limits of opts.Config; if opts.Issue is set, only its rows are read.
+// Rows dated far outside the bulk of the data are rejected once every file has been parsed (see rejectDateOutliers).
+func readAdverts(filenames []string, opts Options) (adverts []Advert, minDate int, maxDate int, validations []FileValidation, err error) {
+	parsed := make([]parsedFile, 0, len(filenames))
+	rows := 0
+	for i, filename := range filenames {
+		files, err := readCSVFiles(filename, opts.Open, opts.Delimiter)
+		if err != nil {
+			return nil, 0, 0, nil, err
+		}
+		for _, file := range files {
+			if err := checkSchema(file); err != nil {
+				return nil, 0, 0, nil, err
+			}
+			result := parseFile(file, opts.configuration(), opts.Issue)
+			parsed = append(parsed, result)
+			rows += result.validation.Rows
+		}
+		if opts.Progress != nil {
+			opts.Progress(i+1, len(filenames), rows)
+		}
+	}
+
+	minDate = (max_year + 1) * 4
+	maxDate = -1
+	adverts = make([]Advert, 0)
+	validations = make([]FileValidation, 0, len(parsed))
+	for _, result := range rejectDateOutliers(parsed, opts.configuration(), opts.Issue) {
+		adverts = append(adverts, result.adverts...)
+		minDate = min(minDate, result.minDate)
+		maxDate = max(maxDate, result.maxDate)
+		validations = append(validations, result.validation)
+	}
+	return adverts, minDate, maxDate, validations, nil
+}
+
+// Parse the CSV data.
+// Skip everything until the header line (with "Source" in the first column) is seen.
+// Ignore comment lines (beginning with "#") and the Notes column.
+// A later header line starts a new block of data, with its own optional columns, as when several exports are concatenated.
+// Ignore empty lines.
+// Perform some integrity checks on the data, recording any problems in the validation summary.
+// Build up an array of Advert containing the data that passes validation.
+// A price above the limit for the year of its advert is taken to be a mistake in the data (see PriceLimit).
+// If issue is not nil, rows from other issues are skipped as if they were not there, so they are neither counted nor checked.
+// If bulk is not nil, rows dated far outside it are rejected as outliers (see bulkDateSpan).
+//
+// Return the data, the minimum and maximum date-indices seen when processing the data and a summary of the validation.
+func parseData(filename string, data [][]string, config Configuration, issue *Issue, bulk *dateSpan) (adverts []Advert, minDate int, maxDate int, validation FileValidation) {
+	minDate = (max_year + 1) * 4
+	maxDate = -1
+	adverts = make([]Advert, 0)
+	validation.Filename = filename
+	validation.Problems = make([]RowProblem, 0)
+
+	searching_for_header := true
+	regionColumn, contributorColumn, streetDateColumn, sizeColumn, currencyColumn := -1, -1, -1, -1, -1
+	for i, row := range data {
+		csvRowIndex := i + 1
+		valid := true
+
+		// Comments are ignored wherever they appear
+		if IsComment(row) {
+			continue
+		}
+
+		// A row too short to hold every fixed column is taken to have empty fields at the end
+		for len(row) <= adv_board {
+			row = append(row, "")
+		}
+
+		// Skip all data until a row with a suitable header line is seen; each header line begins a new block
+		if IsHeader(row) {
+			searching_for_header = false
+			regionColumn = columnIndex(row, adv_region_header)
+			contributorColumn = columnIndex(row, adv_contributor_header)
+			streetDateColumn = columnIndex(row, adv_street_date_header)
+			sizeColumn = columnIndex(row, adv_size_header)
+			currencyColumn = columnIndex(row, adv_currency_header)
+			if (contributorColumn >= 0) && (validation.Contributors == nil) {
+				validation.Contributors = make(map[string]*ContributorCounts)
+			}
+			continue
+		}
+		if searching_for_header {
+			continue
+		}
+
+		// Make sure the system name has no leading or trailing spaces
+		system := strings.TrimSpace(row[adv_system])
+
+		// Entirely empty lines must be ignored. As an approximation, ignore any line without a system title, as that cannot contain meaningful data.
+		if len(system) == 0 {
+			continue
+		}
+		if (issue != nil) && !issue.HasRow(row) {
+			continue
+		}
+		validation.Rows++
+		// The rows of a block without a Contributor column are not counted by contributor
+		counts := &ContributorCounts{}
+		if contributorColumn >= 0 {
+			contributor := optionalField(row, contributorColumn)
+			if _, ok := validation.Contributors[contributor]; !ok {
+				validation.Contributors[contributor] = counts
+			}
+			counts = validation.Contributors[contributor]
+		}
+		counts.Rows++
+		warnings := validation.Warnings
+
+		// The YYYY-DD field must be of the correct format DD must be 01..12 and YYYY must be greater than 1945 but less than 2099
+		// A less precise date is accepted in its place, placed in one or more quarters (see handle_approximate_date)
+		year, month, err := handle_yyyy_mm(row[adv_yyyy_mm])
+		months := []int{month}
+		approximate := false
+		if err != nil {
+			// A date written almost correctly, such as "83-06", is used but warned about so that it can be corrected
+			if normalised, ok := normalise_yyyy_mm(strings.TrimSpace(row[adv_yyyy_mm])); ok && (normalised != strings.TrimSpace(row[adv_yyyy_mm])) {
+				if normalisedYear, normalisedMonth, normalisedErr := handle_yyyy_mm(normalised); normalisedErr == nil {
+					year, month, months, err = normalisedYear, normalisedMonth, []int{normalisedMonth}, nil
+					validation.Warnings++
+					validation.Problems = append(validation.Problems, RowProblem{csvRowIndex, "YYYY-DD", row[adv_yyyy_mm], fmt.Errorf("nonstandard date, read as [%s]", normalised), row, false})
+				} else {
+					err = fmt.Errorf("nonstandard date read as [%s]: %w", normalised, normalisedErr)
+				}
+			}
+		}
+		if err != nil {
+			approximateYear, approximateMonths, isApproximate, ok, problem := handle_approximate_date(strings.TrimSpace(row[adv_yyyy_mm]), config)
+			if ok {
+				year, month, months, approximate, err = approximateYear, approximateMonths[0], approximateMonths, isApproximate, nil
+			} else if problem != nil {
+				err = problem
+			}
+		}
+		if (err == nil) && (bulk != nil) && bulk.farFrom(year, months) {
+			err = fmt.Errorf("date far outside the rest of the data, which runs from %s to %s", FormatQuarter(bulk.first), FormatQuarter(bulk.last))
+		}
+		if err != nil {
+			valid = false
+			validation.Problems = append(validation.Problems, RowProblem{csvRowIndex, "YYYY-DD", row[adv_yyyy_mm], err, row, true})
+		}
+
+		// The street date, if given, places the advert in a quarter; the cover date is kept, as that is how the issue is cited.
+		// A street date that cannot be read is warned about and the cover date used instead.
+		lead := config.CoverDateLeads[strings.TrimSpace(row[adv_magazine])]
+		if street := optionalField(row, streetDateColumn); (street != "") && valid {
+			if streetYear, streetMonth, streetErr := handle_yyyy_mm(street); streetErr != nil {
+				validation.Warnings++
+				validation.Problems = append(validation.Problems, RowProblem{csvRowIndex, "street date", street, streetErr, row, false})
+			} else {
+				months = months[:1]
+				lead = (year*12 + months[0]) - (streetYear*12 + streetMonth)
+			}
+		}
+
+		// The page format must be pN{1,5}}, so at least one N but no more than 5.
+		// Note that the page number does not influence the final output, so "valid" is not adjusted and the data may be used
+		page, err := handle_page_number(row[adv_page_num])
+		if err != nil {
+			validation.Warnings++
+			validation.Problems = append(validation.Problems, RowProblem{csvRowIndex, "page number", row[adv_page_num], err, row, false})
+		}
+
+		// The price must be in pounds and must be within the limit for its year; it is held in pence from here on
+		// A price quoted as "+ VAT" or "ex VAT" has the VAT rate in force at the time of the advert added
+		price, exVAT, err := handle_price(row[adv_price])
+		if err != nil {
+			valid = false
+			validation.Problems = append(validation.Problems, RowProblem{csvRowIndex, "price", row[adv_price], err, row, true})
+		} else if exVAT && valid {
+			price, err = addVAT(price, year, month)
+			if err != nil {
+				valid = false
+				validation.Problems = append(validation.Problems, RowProblem{csvRowIndex, "price", row[adv_price], err, row, true})
+			}
+		}
+		if limit := maxPriceFor(config.PriceLimits, year); valid && (limit > 0) && (price > limit*100) {
+			valid = false
+			err = fmt.Errorf("unlikely Price Data [%s] (greater than %d for %d)", row[adv_price], limit, year)
+			validation.Problems = append(validation.Problems, RowProblem{csvRowIndex, "price", row[adv_price], err, row, true})
+		}
+
+		// An advert dated outside the lifespan of its system is used, but warned about, as it may be misdated or for another system.
+		// So is an advert that is a trailing price in any of the quarters it is placed in, each of which is left out if trailing prices are excluded
+		if name, ok := config.PublishedName(system); ok && valid {
+			trailing := advertTrailingMonths(config, name, year, months, lead)
+			if !advertOnSale(config, name, year, months, lead) || (len(trailing) > 0) {
+				validation.Warnings++
+				err = fmt.Errorf("outside the lifespan of [%s], %s", name, config.Lifespans[name])
+				if len(trailing) > 0 {
+					err = fmt.Errorf("a trailing price, more than %d months after [%s] was discontinued (%s)", config.TrailingMonths, name, config.Lifespans[name])
+					if len(trailing) < len(months) {
+						err = fmt.Errorf("%w, in %d of the %d quarters it is placed in", err, len(trailing), len(months))
+					}
+					if config.ExcludeTrailing && (len(trailing) < len(months)) {
+						err = fmt.Errorf("%w, where it is not published", err)
+					} else if config.ExcludeTrailing {
+						err = fmt.Errorf("%w, so not published", err)
+					}
+				}
+				validation.Problems = append(validation.Problems, RowProblem{csvRowIndex, "YYYY-DD", row[adv_yyyy_mm], err, row, false})
+			}
+		}
+
+		// The advert size, if given, must be one of AdvertSizes; one that is not is warned about and left unrecorded
+		size := strings.ToLower(optionalField(row, sizeColumn))
+		if (size != "") && !sliceContainsString(AdvertSizes, size) {
+			validation.Warnings++
+			validation.Problems = append(validation.Problems, RowProblem{csvRowIndex, "advert size", optionalField(row, sizeColumn), fmt.Errorf("not one of %s", strings.Join(AdvertSizes, ", ")), row, false})
+			size = ""
+		}
+
+		// A price quoted in pounds has no currency to record, whether or not the Currency column says so
+		currency := optionalField(row, currencyColumn)
+		if (currency == "£") || (currency == "&pound;") {
+			currency = ""
+		}
+
+		// TODO
+		//  The kit field must be Y, N, ? or blank
+
+		counts.Warnings += validation.Warnings - warnings
+		if !valid {
+			validation.Rejected++
+			counts.Rejected++
+			continue
+		}
+
+		validation.Accepted++
+		counts.Accepted++
+		for _, month := range months {
+			advert := Advert{filename, csvRowIndex, row[adv_magazine], year, month, page, system, price, exVAT, row[adv_kit], row[adv_board], optionalField(row, regionColumn), approximate, lead, size, currency}
+			adverts = append(adverts, advert)
+			dateIndex := BuildIndexFromAdvert(advert)
+			if dateIndex < minDate {
+				minDate = dateIndex
+			}
+			if dateIndex > maxDate {
+				maxDate = dateIndex
+			}
+		}
+	}
+
+	return adverts, minDate, maxDate, validation
+}
+
+// Return true if the system, by its published name, was on sale in any of the months an advert is placed in,
+// going by the month the issue went on sale, lead months before its cover date
+func advertOnSale(config Configuration, system string, year int, months []int, lead int) bool {
+	for _, month := range months {
+		if onSaleYear, onSale := onSaleMonth(year, month, lead); config.OnSale(system, onSaleYear, onSale) {
+			return true
+		}
+	}
+	return false
+}
+
+// Given a system name as it is published, return those of the given months of a year in which an advert for it
+// is a trailing price (see Configuration.Trailing), as PublishedObservations leaves out each of them on its own,
+// which it also does by the month the issue went on sale
+func advertTrailingMonths(config Configuration, system string, year int, months []int, lead int) []int {
+	trailing := make([]int, 0)
+	for _, month := range months {
+		if onSaleYear, onSale := onSaleMonth(year, month, lead); config.Trailing(system, onSaleYear, onSale) {
+			trailing = append(trailing, month)
+		}
+	}
+	return trailing
+}
+
+// IsComment returns true if a row of CSV data is a comment line, one whose first field begins with "#"
+func IsComment(row []string) bool {
+	return (len(row) > 0) && strings.HasPrefix(strings.TrimSpace(row[0]), comment_prefix)
+}
+
+// IsHeader returns true if a row of CSV data is a header line, which begins a block of data
+func IsHeader(row []string) bool {
+	return (len(row) > 0) && (row[adv_magazine] == "Source")
+}
+
+// Given a header row, return the index of the column with the given name, or -1 if there is no such column
+func columnIndex(header []string, name string) int {
+	for i, heading := range header {
+		if strings.TrimSpace(heading) == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Return the trimmed field in the given column of a row, or "" if the column is absent (-1) or the row is too short
+func optionalField(row []string, column int) string {
+	if (column < 0) || (column >= len(row)) {
+		return ""
+	}
+	return strings.TrimSpace(row[column])
+}
+
+// Process a date of the form "YYYY-MM".
+// return an error if:
+//  o the string does not conform to the pattern NNNN-NN, where N is a numeral
+//  o the year is not (inclusively) between min_year and max_year constants
+//  o the month is not from 1 to 12
+// Otherwise return the year and month as integers.
+//
+// TODO: make the upper limit for YYYY the current year
+func handle_yyyy_mm(yyyy_mm string) (year int, month int, err error) {
+	year = -1
+	month = -1
+	var local_err error
+
+	if len(yyyy_mm) != 7 {
+		return year, month, fmt.Errorf("bad YYYY-MM: length invalid: [%s]", yyyy_mm)
+	}
+
+	date_sep := yyyy_mm[4:5]
+	if date_sep != "-" {
+		local_err = fmt.Errorf("bad YYYY-MM separator [%s] from [%s]", date_sep, yyyy_mm)
+	}
+	year_text := yyyy_mm[0:4]
+	year, err = strconv.Atoi(year_text)
+	if err != nil {
+		local_err = fmt.Errorf("bad Year digits [%s] (%w)", year_text, err)
+	} else if (year < min_year) || (year > max_year) {
+		local_err = fmt.Errorf("bad Year  [%d] outside range %d-%d", year, min_year, max_year)
+	}
+	month_text := yyyy_mm[5:]
+	month, err = strconv.Atoi(month_text)
+	if err != nil {
+		local_err = fmt.Errorf("bad Month digits [%s]", month_text)
+	} else if (month < 1) || (month > 12) {
+		local_err = fmt.Errorf("bad Month [%d]", month)
+	}
+	return year, month, local_err
+}
+
+// Process a page number of the form "pNNNN".
+// return an error if:
+// Otherwise return the page number as an integer.
+//
+// TODO: allow for roman numberals: e.g. pii
+
+func handle_page_number(page_num_text string) (page int, err error) {
+	page = -1000
+	var local_err error
+
+	if len(page_num_text) < 2 {
+		local_err = fmt.Errorf("bad page number text [%s]", page_num_text)
+	} else {
+		if page_num_text[0] != 'p' {
+			local_err = fmt.Errorf("bad page number format [%s]", page_num_text)
+		} else {
+			page, err = strconv.Atoi(page_num_text[1:])
+			if err != nil {
+				local_err = fmt.Errorf("bad page number data [%s] (%w)", page_num_text, err)
+			}
+			if (page < 0) || (page > max_page_num) {
+				local_err = fmt.Errorf("bad page number value [%d]", page)
+			}
+		}
+	}
+	return page, local_err
+}
+
+// Process a price such as "£1,299.95".
+// Imported and OCR'd data spells the currency in several ways, so all of these are accepted:
+// "£99", "£ 99", "&pound;99", "GBP 99", "GBP99" and "99 pounds".
+// Commas in the amount are ignored and at most two digits may follow a decimal point.
+// The price may be followed by a VAT qualifier such as "+ VAT" or "ex VAT".
+// Prices from before decimalisation, such as "£399 19s 6d", "£399/19/6" or "5 gns", are also accepted (see parse_predecimal).
+// return an error if:
+// o the currency is not one of the spellings above
+// o the amount is not a number
+// Otherwise return the price in pence as an integer and whether VAT still has to be added to it.
+
+func handle_price(price_text string) (price int, exVAT bool, err error) {
+	price = -1
+	var local_err error
+
+	price_text, exVAT = strip_vat_suffix(strings.TrimSpace(price_text))
+	if pence, ok, err := parse_predecimal(price_text); ok {
+		if err != nil {
+			local_err = fmt.Errorf("bad pre-decimal Price Data [%s] (%w)", price_text, err)
+		} else {
+			price = pence
+		}
+		return price, exVAT, local_err
+	}
+	amount_text, ok := strip_currency(price_text)
+	if !ok {
+		local_err = fmt.Errorf("bad Price Currency from [%s]", price_text)
+	} else {
+		amount_text = strings.ReplaceAll(amount_text, ",", "") // Remove all commas
+		possible_price, err := parse_pence(amount_text)
+		if err != nil {
+			local_err = fmt.Errorf("bad Price Data [%s]", amount_text)
+		} else {
+			price = possible_price
+		}
+	}
+	return price, exVAT, local_err
+}
+
+// Given a price, remove the currency marker and any spaces separating it from the amount.
+// The second result is false if no recognised spelling of pounds sterling is present.
+func strip_currency(price_text string) (amount_text string, ok bool) {
+	for _, prefix := range []string{"£", "&pound;", "GBP"} {
+		if len(price_text) >= len(prefix) && strings.EqualFold(price_text[:len(prefix)], prefix) {
+			return strings.TrimSpace(price_text[len(prefix):]), true
+		}
+	}
+	for _, suffix := range []string{"pounds", "pound"} {
+		if len(price_text) >= len(suffix) && strings.EqualFold(price_text[len(price_text)-len(suffix):], suffix) {
+			return strings.TrimSpace(price_text[:len(price_text)-len(suffix)]), true
+		}
+	}
+	return "", false
+}
+
+// Convert an amount in pounds, such as "99" or "99.95", into pence.
+// A single digit after the decimal point is tens of pence ("99.5" is 9950).
+func parse_pence(amount_text string) (int, error) {
+	pounds_text, pence_text, has_pence := strings.Cut(amount_text, ".")
+	if len(pounds_text) == 0 || strings.ContainsAny(pounds_text, "+-") {
+		return 0, fmt.Errorf("bad pounds [%s]", amount_text)
+	}
+	pounds, err := strconv.Atoi(pounds_text)
+	if err != nil {
+		return 0, err
+	}
+	pence := 0
+	if has_pence {
+		if len(pence_text) < 1 || len(pence_text) > 2 || strings.ContainsAny(pence_text, "+-") {
+			return 0, fmt.Errorf("bad pence [%s]", amount_text)
+		}
+		pence, err = strconv.Atoi(pence_text)
+		if err != nil {
+			return 0, err
+		}
+		if len(pence_text) == 1 {
+			pence *= 10
+		}
+	}
+	return pounds*100 + pence, nil
+}
+
+// Given an Advert, this function produces an int that represents that year and quarter.
+// Months 1-3 are 0 (Q1), months 4-6 are 1 (Q2) etc.
+// The final index is (year*12 + quarter)
+// The quarter is that in which the issue went on sale, which is earlier than its cover date if the cover date leads it.
+func BuildIndexFromAdvert(advert Advert) int {
+	onSale := advert.Year*12 + (advert.Month - 1) - advert.CoverDateLead
+	quarter := ((onSale % 12) / 3)
+	return ((onSale / 12) * 4) + quarter
+}
+
+// Given the year and month of an issue's cover date and the months by which it leads the issue going on sale,
+// return the year and month in which the issue went on sale, which is what places its adverts in a quarter
+func onSaleMonth(year int, month int, lead int) (int, int) {
+	onSale := year*12 + (month - 1) - lead
+	return onSale / 12, (onSale % 12) + 1
+}
+
+// Given a year and a quarter, combine them into a date-index integer
+func BuildIndexFromYearAndQuarter(year int, quarter int) int {
+	return (year * 4) + (quarter - 1)
+}
+
+// Given a date-index, return the year and quarter which it represents
+func DecodeIndexByQuarter(index int) (year int, quarter int) {
+	year = index / 4
+	quarter = index - (year * 4) + 1
+	return year, quarter
+}
+
+// Given a date-index, return it in the form "1983Q1"
+func FormatQuarter(index int) string {
+	year, quarter := DecodeIndexByQuarter(index)
+	return fmt.Sprintf("%dQ%d", year, quarter)
+}
+
+// Given a quarter in the form "1983Q1", return its date-index
+// return an error if the text is not of that form or the quarter is not 1 to 4
+func ParseQuarter(text string) (int, error) {
+	yearText, quarterText, ok := strings.Cut(strings.ToUpper(strings.TrimSpace(text)), "Q")
+	year, yearErr := strconv.Atoi(yearText)
+	quarter, quarterErr := strconv.Atoi(quarterText)
+	if !ok || (yearErr != nil) || (quarterErr != nil) || (quarter < 1) || (quarter > 4) {
+		return 0, fmt.Errorf("bad quarter [%s] (expected e.g. 1983Q1)", text)
+	}
+	return BuildIndexFromYearAndQuarter(year, quarter), nil
+}
+
+// golang doesn't have min/max so provide them here
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func sliceContainsString(slice []string, candidate string) bool {
+	for _, member := range slice {
+		if member == candidate {
+			return true
+		}
+	}
+	return false
+}
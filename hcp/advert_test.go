@@ -0,0 +1,83 @@
+package hcp
+
+import "testing"
+
+func TestHandlePrice(t *testing.T) {
+	tests := []struct {
+		price string
+		pence int
+		exVAT bool
+		err   bool
+	}{
+		{"£99", 9900, false, false},
+		{"£ 99", 9900, false, false},
+		{"&pound;99", 9900, false, false},
+		{"GBP 99", 9900, false, false},
+		{"GBP99", 9900, false, false},
+		{"99 pounds", 9900, false, false},
+		{"£1,299.95", 129995, false, false},
+		{"£99.5", 9950, false, false},
+		{"£99 + VAT", 9900, true, false},
+		{"£99 ex VAT", 9900, true, false},
+		{"£399 19s 6d", 39998, false, false},
+		{"5 gns", 525, false, false},
+		{"$39.95", -1, false, true},
+		{"£99.999", -1, false, true},
+		{"£ninety", -1, false, true},
+		{"£12s", -1, false, true},
+		{"£1 25s", -1, false, true},
+	}
+	for _, test := range tests {
+		pence, exVAT, err := handle_price(test.price)
+		if (pence != test.pence) || (exVAT != test.exVAT) || ((err != nil) != test.err) {
+			t.Errorf("handle_price(%q) = %d, %t, %v; want %d, %t, error %t", test.price, pence, exVAT, err, test.pence, test.exVAT, test.err)
+		}
+	}
+}
+
+func TestParsePence(t *testing.T) {
+	tests := []struct {
+		amount string
+		pence  int
+		err    bool
+	}{
+		{"99", 9900, false},
+		{"99.5", 9950, false},
+		{"99.95", 9995, false},
+		{"0.05", 5, false},
+		{"99.", 0, true},
+		{".95", 0, true},
+		{"99.999", 0, true},
+		{"-99", 0, true},
+		{"99.-5", 0, true},
+		{"ninety", 0, true},
+	}
+	for _, test := range tests {
+		pence, err := parse_pence(test.amount)
+		if ((err != nil) != test.err) || (!test.err && (pence != test.pence)) {
+			t.Errorf("parse_pence(%q) = %d, %v; want %d, error %t", test.amount, pence, err, test.pence, test.err)
+		}
+	}
+}
+
+func TestHandleYYYYMM(t *testing.T) {
+	tests := []struct {
+		date  string
+		year  int
+		month int
+		err   bool
+	}{
+		{"1982-06", 1982, 6, false},
+		{"1982-13", 0, 0, true},
+		{"1982/06", 0, 0, true},
+		{"1982-6", 0, 0, true},
+		{"82-06", 0, 0, true},
+		{"1066-06", 0, 0, true},
+	}
+	for _, test := range tests {
+		year, month, err := handle_yyyy_mm(test.date)
+		if ((err != nil) != test.err) || (!test.err && ((year != test.year) || (month != test.month))) {
+			t.Errorf("handle_yyyy_mm(%q) = %d, %d, %v; want %d, %d, error %t", test.date, year, month, err, test.year, test.month, test.err)
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package hcp
+
+import "sort"
+
+// The ways in which the price published for a quarter may be chosen from the prices of that quarter's adverts,
+// selected by name with Options.Aggregation (or -aggregate on the command line).
+// o min takes the lowest price, which is what the tables have always shown
+// o mode takes the price carried by the most adverts (the lowest such price if there is a tie)
+// o median takes the median of every advert's price, so a price carried by many adverts weighs more than a one-off
+// Both mode and median reduce the influence of a single rogue advert on the published price.
+var PriceAggregations = map[string]func(prices []int) int{
+	"min":    lowestPrice,
+	"mode":   modalPrice,
+	"median": medianPrice,
+}
+
+// Return the lowest of the prices
+func lowestPrice(prices []int) int {
+	lowest := prices[0]
+	for _, price := range prices[1:] {
+		lowest = min(lowest, price)
+	}
+	return lowest
+}
+
+// Return the most common of the prices, choosing the lowest if several are equally common
+func modalPrice(prices []int) int {
+	counts := make(map[int]int)
+	for _, price := range prices {
+		counts[price]++
+	}
+	modal, modalCount := 0, 0
+	for price, count := range counts {
+		if (count > modalCount) || ((count == modalCount) && (price < modal)) {
+			modal, modalCount = price, count
+		}
+	}
+	return modal
+}
+
+// Return the median of the prices; for an even number of prices this is the mean of the middle two, rounded down
+func medianPrice(prices []int) int {
+	sorted := append([]int(nil), prices...)
+	sort.Ints(sorted)
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+	return sorted[middle]
+}
+
+// BuildByDate indexes the adverts by date, keeping the cheapest advert for each system in each quarter.
+// The result is a map of date-index => systemsMap, where systemsMap is system => the cheapest advert.
+// Take each advert in turn:
+// is there a map for that index?
+// If not, create and populate it
+// If there is, find this system and replace its advert only if the new price is lower, so the first of equally cheap adverts is kept
+func BuildByDate(adverts []Advert) map[int]map[string]Advert {
+	byDate := make(map[int]map[string]Advert)
+	for _, advert := range adverts {
+		index := BuildIndexFromAdvert(advert)
+		if systemMap, ok := byDate[index]; ok {
+			if storedAdvert, ok := systemMap[advert.System]; ok {
+				if (advert.Price > 0) && (advert.Price < storedAdvert.Price) {
+					systemMap[advert.System] = advert
+				}
+			} else {
+				systemMap[advert.System] = advert
+			}
+		} else {
+			byDate[index] = make(map[string]Advert, 0)
+			byDate[index][advert.System] = advert
+		}
+	}
+	return byDate
+}
+
+// This function applies some pre-processing to the gathered data, as directed by the configuration.
+// The following changes are made:
+// o Data for each system in the suppress list is dropped (e.g. "Apple II", as the configuration is unclear)
+// o Each system named by a rename rule is re-written (e.g. "Science of Cambridge MK14" is re-written as "MK14")
+// If a renamed system's data lands on a name that already has data, the adverts for the two are combined.
+// The names of the systems that were dropped are returned in alphabetical order.
+func PreprocessSystemData(systems map[string][][]Advert, config Configuration) (map[string][][]Advert, []string) {
+	result := make(map[string][][]Advert, 0)
+	dropped := make([]string, 0)
+	for name, _ := range systems {
+		newName, ok := config.PublishedName(name)
+		if !ok {
+			// Drop this data
+			dropped = append(dropped, name)
+			continue
+		}
+		if existing, ok := result[newName]; ok {
+			result[newName] = mergeObservations(existing, systems[name])
+		} else {
+			result[newName] = systems[name]
+		}
+	}
+	sort.Strings(dropped)
+	return result, dropped
+}
+
+// A PreprocessedSystem is a system in the data whose adverts the suppress or rename rules changed (see PreprocessSystemData)
+type PreprocessedSystem struct {
+	System    string   // The system's name in the data
+	Published string   // The name its adverts are published under, or "" if they were dropped
+	Adverts   int      // How many adverts were dropped or renamed
+	Merged    []string // The other systems in the data whose adverts are published under the same name, in alphabetical order
+}
+
+// Given the adverts that passed validation, return the systems whose data the suppress and rename rules drop or
+// publish under another name, in alphabetical order, so that data lost or merged by the rules is not lost silently.
+func PreprocessingChanges(adverts []Advert, config Configuration) []PreprocessedSystem {
+	counts := make(map[string]int)
+	for _, advert := range adverts {
+		counts[advert.System]++
+	}
+	names := make([]string, 0, len(counts))
+	byPublished := make(map[string][]string)
+	for name := range counts {
+		names = append(names, name)
+		if published, ok := config.PublishedName(name); ok {
+			byPublished[published] = append(byPublished[published], name)
+		}
+	}
+	sort.Strings(names)
+
+	changes := make([]PreprocessedSystem, 0)
+	for _, name := range names {
+		published, ok := config.PublishedName(name)
+		if ok && (published == name) {
+			continue
+		}
+		change := PreprocessedSystem{System: name, Published: published, Adverts: counts[name], Merged: []string{}}
+		for _, other := range byPublished[published] {
+			if ok && (other != name) {
+				change.Merged = append(change.Merged, other)
+			}
+		}
+		sort.Strings(change.Merged)
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// Given two observation arrays covering the same dates, return a new observation array holding the adverts from both for each date
+func mergeObservations(a [][]Advert, b [][]Advert) [][]Advert {
+	result := make([][]Advert, len(a))
+	for i := range a {
+		result[i] = append(append([]Advert(nil), a[i]...), b[i]...)
+	}
+	return result
+}
+
+// Given a number of Advert objects, build a map of system => observation-array
+// The observation array index should be 0 for minDate and increase up to (maxDate-minDate) for maxDate;
+// each entry holds every advert for that system in that quarter.
+func BuildObservationsBySystem(adverts []Advert, minDate int, maxDate int) map[string][][]Advert {
+	result := make(map[string][][]Advert, 0)
+
+	for _, advert := range adverts {
+		if _, ok := result[advert.System]; !ok {
+			// This system has been seen for the first time.
+			// Create its observation array
+			result[advert.System] = make([][]Advert, maxDate-minDate+1)
+		}
+		index := BuildIndexFromAdvert(advert)
+		result[advert.System][index-minDate] = append(result[advert.System][index-minDate], advert)
+	}
+	return result
+}
+
+// Given a number of Advert objects, build the map of system => observation-array that is published,
+// applying the configured rename and suppress rules and leaving out the adverts of any size weighted 0 and,
+// if the configuration excludes them, the trailing prices, going by the month each advert's issue went on sale.
+// The names of the systems whose data was suppressed are also returned.
+func PublishedObservations(adverts []Advert, minDate int, maxDate int, config Configuration) (map[string][][]Advert, []string) {
+	weighted := make([]Advert, 0, len(adverts))
+	for _, advert := range adverts {
+		year, month := onSaleMonth(advert.Year, advert.Month, advert.CoverDateLead)
+		if (AdvertWeight(advert, config) > 0) && !(config.ExcludeTrailing && config.Trailing(config.ResolveName(advert.System), year, month)) {
+			weighted = append(weighted, advert)
+		}
+	}
+	observations := BuildObservationsBySystem(weighted, minDate, maxDate)
+	return PreprocessSystemData(observations, config)
+}
+
+// AdvertWeight returns how many times an advert's price counts when a quarter's price is chosen: the weight the
+// configuration gives its size, or 1 if it gives none or the size was not recorded
+func AdvertWeight(advert Advert, config Configuration) int {
+	if weight, ok := config.AdvertSizeWeights[advert.Size]; ok && (advert.Size != "") {
+		return weight
+	}
+	return 1
+}
+
+// WeightedPrices returns the prices of the adverts that a quarter's price is chosen from, each repeated as many
+// times as its weight, so that mode and median favour the heavier adverts; min is unaffected
+func WeightedPrices(adverts []Advert, config Configuration) []int {
+	prices := make([]int, 0, len(adverts))
+	for _, advert := range adverts {
+		for i := 0; i < AdvertWeight(advert, config); i++ {
+			prices = append(prices, advert.Price)
+		}
+	}
+	return prices
+}
+
+// Given a number of Advert objects, build the map of system => price-array that is published.
+// The configured rename and suppress rules are applied and each quarter's price is chosen by the named aggregation.
+// The names of the systems whose data was suppressed are also returned.
+func PublishedPrices(adverts []Advert, minDate int, maxDate int, config Configuration, aggregation string) (map[string][]int, []string) {
+	observations, dropped := PublishedObservations(adverts, minDate, maxDate, config)
+	return AggregateObservations(observations, PriceAggregations[aggregation], config), dropped
+}
+
+// Given a map of system => observation-array, build a map of system => price-array
+// Each quarter's price is chosen from that quarter's adverts by the aggregation function, weighted by their sizes
+// as configured (see WeightedPrices); quarters without adverts have a price of 0.
+func AggregateObservations(observations map[string][][]Advert, aggregate func(prices []int) int, config Configuration) map[string][]int {
+	result := make(map[string][]int, len(observations))
+	for name, quarters := range observations {
+		result[name] = make([]int, len(quarters))
+		for i, quarter := range quarters {
+			if len(quarter) == 0 {
+				continue
+			}
+			result[name][i] = aggregate(WeightedPrices(quarter, config))
+		}
+	}
+	return result
+}
@@ -0,0 +1,66 @@
+package hcp
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// A csvFile is the rows read from one CSV file, which may have come from inside a zip archive
+type csvFile struct {
+	name string // The file's name; inside an archive, the archive's name, "/" and the name within the archive
+	rows [][]string
+}
+
+// Read the CSV data in the named file, opened with open or, if that is nil, os.Open.
+// A zip archive (a name ending in ".zip") holds several CSV files: every file in it whose name ends in ".csv"
+// or ".csv.gz" is read, in the order they are stored, and anything else in it (such as a README) is ignored.
+// Any other file is a single CSV file (see readCSV).
+func readCSVFiles(filename string, open OpenFunc, delimiter rune) ([]csvFile, error) {
+	if !strings.HasSuffix(strings.ToLower(filename), ".zip") {
+		rows, err := readCSV(filename, open, delimiter)
+		if err != nil {
+			return nil, err
+		}
+		return []csvFile{{filename, rows}}, nil
+	}
+
+	// A zip archive is read from its end, so it is read into memory first in case it is not a local file
+	f, err := openData(filename, open)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(f)
+	f.Close()
+	if err != nil {
+		return nil, fmt.Errorf("cannot read [%s] (%w)", filename, err)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("cannot read zip archive [%s] (%w)", filename, err)
+	}
+
+	files := make([]csvFile, 0)
+	for _, entry := range archive.File {
+		name := strings.ToLower(entry.Name)
+		if entry.FileInfo().IsDir() || (path.Base(name)[0] == '.') || (!strings.HasSuffix(name, ".csv") && !strings.HasSuffix(name, ".csv.gz")) {
+			continue
+		}
+		// Each entry is opened through the archive, then read as any other CSV file would be
+		openEntry := func(string) (io.ReadCloser, error) {
+			return entry.Open()
+		}
+		rows, err := readCSV(entry.Name, openEntry, delimiter)
+		if err != nil {
+			return nil, fmt.Errorf("in [%s]: %w", filename, err)
+		}
+		files = append(files, csvFile{filename + "/" + entry.Name, rows})
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no CSV files in zip archive [%s]", filename)
+	}
+	return files, nil
+}
@@ -0,0 +1,345 @@
+package hcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+)
+
+// The configuration file is a JSON document holding the rules applied to the gathered data.
+// When no configuration file is supplied the rules that were originally hard-coded are used.
+//
+// Example:
+//
+//	{
+//	  "renames":  [ { "from": "Science of Cambridge MK14", "to": "MK14" } ],
+//	  "suppress": [ "Apple II", "Exidy Sorcerer" ],
+//	  "attribution": {
+//	    "licence":      "CC BY-SA 4.0",
+//	    "licence_url":  "https://creativecommons.org/licenses/by-sa/4.0/",
+//	    "contributors": [ "Antonio Carlini" ],
+//	    "repository":   "https://github.com/AntonioCarlini/home-computer-prices"
+//	  },
+//	  "price_limits": [ { "from": 1945, "max": 100000 }, { "from": 1983, "max": 10000 } ],
+//	  "year_only_quarter": 4,
+//	  "issue_months": { "Christmas": 12, "Spring": 4, "Annual": 0 },
+//	  "cover_date_leads": { "Your Computer": 1 },
+//	  "lifespans": { "ZX81": { "launched": "1981-03", "discontinued": "1984-12" } },
+//	  "trailing_months": 12,
+//	  "exclude_trailing": true,
+//	  "placeholders": { "pre_launch": "not yet launched", "discontinued": "discontinued", "no_data": "?" },
+//	  "lineages": { "Sinclair": [ "ZX80", "ZX81", "ZX Spectrum 16K" ] },
+//	  "advert_size_weights": { "classified": 0, "full-page": 2 },
+//	  "languages": {
+//	    "de": {
+//	      "wiki":               "https://de.example.org/w/api.php",
+//	      "system":             "System",
+//	      "quarters":           [ "JAN-MÄR", "APR-JUN", "JUL-SEP", "OKT-DEZ" ],
+//	      "decimal_separator":  ",",
+//	      "thousands_separator": ".",
+//	      "notes":              { "interpolated": "Kursive Preise sind Schätzungen ..." },
+//	      "links":              { "Sinclair ZX81": "Sinclair ZX81 (Computer)" }
+//	    }
+//	  }
+//	}
+type Configuration struct {
+	Renames  []RenameRule `json:"renames"`  // Systems whose data is published under a different name
+	Suppress []string     `json:"suppress"` // Systems whose data is dropped, usually because the configuration is unclear
+
+	Attribution Attribution         `json:"attribution"`  // Credit appended to every generated page or file
+	Languages   map[string]Language `json:"languages"`    // Variants of the wiki tables for sister wikis, by language code
+	PriceLimits []PriceLimit        `json:"price_limits"` // The highest plausible price in each era; if absent, DefaultPriceLimits()
+
+	YearOnlyQuarter int            `json:"year_only_quarter"` // The quarter (1 to 4) that an advert dated only by year is placed in; 0 spreads it across the year
+	IssueMonths     map[string]int `json:"issue_months"`      // The month of each kind of special issue, such as "Christmas"; if absent, DefaultIssueMonths()
+	CoverDateLeads  map[string]int `json:"cover_date_leads"`  // Months by which each magazine's cover dates lead its issues going on sale, such as 1 for a "January" issue on sale in December; its adverts are placed in the quarter they went on sale
+
+	Lifespans    map[string]Lifespan `json:"lifespans"`    // When each system, by the name it is published under, was on sale
+	Placeholders Placeholders        `json:"placeholders"` // What the tables show in a quarter without a price
+	Lineages     map[string][]string `json:"lineages"`     // Each manufacturer's successive entry-level systems, by published name, oldest first
+
+	TrailingMonths  int  `json:"trailing_months"`  // Months after its discontinuation beyond which an advert for a system is a trailing price, clearing old stock; 0 finds none
+	ExcludeTrailing bool `json:"exclude_trailing"` // Leave trailing prices out of the published prices, rather than only warning about them
+
+	AdvertSizeWeights map[string]int `json:"advert_size_weights"` // How many times the price of an advert of each of the AdvertSizes counts when a quarter's price is chosen; 0 leaves those adverts out
+}
+
+// The months of the special issues that several magazines published alongside (or instead of) their monthly issues,
+// used when the configuration does not give any. Names are matched regardless of case.
+// A month of 0 means that the issue can only be dated by its year, as with an annual.
+func DefaultIssueMonths() map[string]int {
+	return map[string]int{
+		"Spring":    4,
+		"Summer":    7,
+		"Autumn":    10,
+		"Winter":    12,
+		"Christmas": 12,
+		"Annual":    0,
+	}
+}
+
+// A PriceLimit is the highest plausible price, in whole pounds, for adverts from the year From onwards
+// (until the next limit takes over). Higher prices are taken to be mistakes in the data and rejected.
+// A Max of 0 means that there is no limit.
+type PriceLimit struct {
+	From int `json:"from"`
+	Max  int `json:"max"`
+}
+
+// The price limits used when the configuration does not give any.
+// Business systems costing many thousands of pounds were advertised alongside the first micros, but once
+// home computers took over the magazines a four-figure price was already exceptional.
+func DefaultPriceLimits() []PriceLimit {
+	return []PriceLimit{
+		{From: min_year, Max: 100_000},
+		{From: 1980, Max: 25_000},
+		{From: 1983, Max: 10_000},
+	}
+}
+
+// Given price limits in order of year, return the limit in pounds for adverts from the given year, or 0 if there is none
+func maxPriceFor(limits []PriceLimit, year int) int {
+	limit := 0
+	for _, candidate := range limits {
+		if year >= candidate.From {
+			limit = candidate.Max
+		}
+	}
+	return limit
+}
+
+// A Lifespan is when a system was on sale, from the month it was launched to the month it was discontinued, each as "YYYY-MM".
+// Either may be left empty if it is not known. Adverts for a system outside its lifespan are suspicious, so are warned about,
+// and the quarters outside it are shaded in the tables and charts.
+type Lifespan struct {
+	Launched     string `json:"launched"`
+	Discontinued string `json:"discontinued"`
+}
+
+// Months returns the first and last months of the lifespan, each as year*12 + month - 1.
+// An unknown launch is returned as -1 and an unknown discontinuation as math.MaxInt, so that every month is within them.
+func (lifespan Lifespan) Months() (first int, last int, err error) {
+	first, last = -1, math.MaxInt
+	if lifespan.Launched != "" {
+		year, month, err := handle_yyyy_mm(strings.TrimSpace(lifespan.Launched))
+		if err != nil {
+			return 0, 0, fmt.Errorf("bad launch date (%w)", err)
+		}
+		first = year*12 + month - 1
+	}
+	if lifespan.Discontinued != "" {
+		year, month, err := handle_yyyy_mm(strings.TrimSpace(lifespan.Discontinued))
+		if err != nil {
+			return 0, 0, fmt.Errorf("bad discontinuation date (%w)", err)
+		}
+		last = year*12 + month - 1
+	}
+	return first, last, nil
+}
+
+// String returns the lifespan as "1981-03 to 1984-12", with "?" for a date that is not known
+func (lifespan Lifespan) String() string {
+	launched, discontinued := "?", "?"
+	if lifespan.Launched != "" {
+		launched = lifespan.Launched
+	}
+	if lifespan.Discontinued != "" {
+		discontinued = lifespan.Discontinued
+	}
+	return launched + " to " + discontinued
+}
+
+// Includes returns true if the system was on sale in the given month. A lifespan that cannot be read includes every month.
+func (lifespan Lifespan) Includes(year int, month int) bool {
+	first, last, err := lifespan.Months()
+	when := year*12 + month - 1
+	return (err != nil) || ((when >= first) && (when <= last))
+}
+
+// IncludesQuarter returns true if the system was on sale for any part of the quarter with the given date-index
+func (lifespan Lifespan) IncludesQuarter(index int) bool {
+	year, quarter := DecodeIndexByQuarter(index)
+	for month := quarter*3 - 2; month <= quarter*3; month++ {
+		if lifespan.Includes(year, month) {
+			return true
+		}
+	}
+	return false
+}
+
+// BeforeLaunch returns true if the quarter with the given date-index ended before the system was launched
+func (lifespan Lifespan) BeforeLaunch(index int) bool {
+	first, _, err := lifespan.Months()
+	year, quarter := DecodeIndexByQuarter(index)
+	return (err == nil) && (year*12+quarter*3-1 < first)
+}
+
+// AfterDiscontinuation returns true if the quarter with the given date-index began after the system was discontinued
+func (lifespan Lifespan) AfterDiscontinuation(index int) bool {
+	_, last, err := lifespan.Months()
+	year, quarter := DecodeIndexByQuarter(index)
+	return (err == nil) && (year*12+quarter*3-3 > last)
+}
+
+// Placeholders are what the tables show in a quarter without a price, telling apart a system that had not yet been
+// launched, one that had been discontinued (both as its Lifespan says) and one on sale for which no advert was found.
+// Each placeholder left empty is shown as a dash, as all of them are by default.
+type Placeholders struct {
+	PreLaunch    string `json:"pre_launch"`
+	Discontinued string `json:"discontinued"`
+	NoData       string `json:"no_data"` // Also used for the quarters of a system without a lifespan
+}
+
+// Given a system name as it is published, return true if the system was on sale in the given month.
+// A system without a lifespan in the configuration is taken to have always been on sale.
+func (config Configuration) OnSale(system string, year int, month int) bool {
+	lifespan, ok := config.Lifespans[system]
+	return !ok || lifespan.Includes(year, month)
+}
+
+// Given a system name as it is published, return true if an advert for it in the given month is a trailing price:
+// one more than TrailingMonths after the system was discontinued, as old stock cleared long afterwards is, which
+// would otherwise make the system seem to have been on sale for longer than it was.
+// A system without a discontinuation date in the configuration has no trailing prices.
+func (config Configuration) Trailing(system string, year int, month int) bool {
+	lifespan, ok := config.Lifespans[system]
+	if !ok || (config.TrailingMonths <= 0) {
+		return false
+	}
+	_, last, err := lifespan.Months()
+	return (err == nil) && (last != math.MaxInt) && (year*12+month-1 > last+config.TrailingMonths)
+}
+
+// A Language describes how the wiki tables are written for a sister wiki in another language.
+// Any field left empty takes the value used for the English tables.
+type Language struct {
+	Wiki               string            `json:"wiki"`                // URL of the sister wiki's api.php, used by publish
+	Credentials        string            `json:"credentials"`         // File of the credentials to edit it with, if not those given to publish
+	System             string            `json:"system"`              // Heading of the system column
+	Quarters           []string          `json:"quarters"`            // Headings of the four quarter columns, January to March first
+	DecimalSeparator   string            `json:"decimal_separator"`   // Written between pounds and pence
+	ThousandsSeparator string            `json:"thousands_separator"` // Written between each group of three digits of pounds
+	Notes              map[string]string `json:"notes"`               // The notes explaining marked prices: "interpolated", "carried", "withheld", "single", "approximate", "currency", "min-median", "off-sale", "pre-launch", "discontinued" or "no-data"; and the -totals rows, "systems-priced" and "median-price"
+	Links              map[string]string `json:"links"`               // The page on the sister wiki about each system, which its name links to
+}
+
+// An Attribution credits the data to its contributors and states its licence, as wikis republishing it require.
+// Any field may be left empty; an Attribution with no fields set adds nothing to the output.
+type Attribution struct {
+	Licence      string   `json:"licence,omitempty"`      // Such as "CC BY-SA 4.0"
+	LicenceURL   string   `json:"licence_url,omitempty"`  // Where the licence may be read
+	Contributors []string `json:"contributors,omitempty"` // The people who gathered the data
+	Repository   string   `json:"repository,omitempty"`   // URL of the repository holding the data
+}
+
+// IsEmpty returns true if the attribution has nothing to say
+func (attribution Attribution) IsEmpty() bool {
+	return (attribution.Licence == "") && (attribution.LicenceURL == "") && (len(attribution.Contributors) == 0) && (attribution.Repository == "")
+}
+
+// A RenameRule re-writes the system name "From" as "To"
+type RenameRule struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// The rules used when no configuration file is supplied
+func DefaultConfiguration() Configuration {
+	return Configuration{
+		Renames: []RenameRule{
+			{From: "Science of Cambridge MK14", To: "MK14"},
+		},
+		Suppress:    []string{"Apple II", "Commodore PET", "Exidy Sorcerer", "Tandy TRS-80 Model 1"},
+		PriceLimits: DefaultPriceLimits(),
+		IssueMonths: DefaultIssueMonths(),
+	}
+}
+
+// Read a configuration file.
+// An empty filename selects the default configuration.
+func LoadConfiguration(filename string) (Configuration, error) {
+	if filename == "" {
+		return DefaultConfiguration(), nil
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return Configuration{}, err
+	}
+	return ParseConfiguration(filename, data)
+}
+
+// Parse a configuration held in memory; name identifies it in any error
+func ParseConfiguration(name string, data []byte) (Configuration, error) {
+	var config Configuration
+	if err := json.Unmarshal(data, &config); err != nil {
+		return Configuration{}, fmt.Errorf("bad configuration file [%s] (%w)", name, err)
+	}
+	if config.PriceLimits == nil {
+		config.PriceLimits = DefaultPriceLimits()
+	}
+	if config.IssueMonths == nil {
+		config.IssueMonths = DefaultIssueMonths()
+	}
+	return config, nil
+}
+
+// Given a system name, follow the rename rules until a name that is not renamed is reached.
+// Rename rules may be chained (A => B, B => C means that A is published as C).
+// A cycle of rules stops at the name that would be seen a second time; lint-config reports such cycles.
+func (config Configuration) ResolveName(name string) string {
+	seen := map[string]bool{name: true}
+	for {
+		renamed := false
+		for _, rule := range config.Renames {
+			if rule.From == name {
+				if seen[rule.To] {
+					return name
+				}
+				name = rule.To
+				seen[name] = true
+				renamed = true
+				break
+			}
+		}
+		if !renamed {
+			return name
+		}
+	}
+}
+
+// WithoutPreprocessing returns the configuration without its rename and suppress rules, so that every system's data
+// is published under its name in the data, unmerged and unsuppressed, as when investigating a discrepancy
+func (config Configuration) WithoutPreprocessing() Configuration {
+	config.Renames, config.Suppress = nil, nil
+	return config
+}
+
+// Aliases returns the other names whose data the rename rules publish under the given name, in alphabetical order,
+// such as "Science of Cambridge MK14" for "MK14"
+func (config Configuration) Aliases(published string) []string {
+	aliases := make([]string, 0)
+	seen := map[string]bool{published: true}
+	for _, rule := range config.Renames {
+		for _, name := range []string{rule.From, rule.To} {
+			if !seen[name] && (config.ResolveName(name) == published) {
+				aliases = append(aliases, name)
+			}
+			seen[name] = true
+		}
+	}
+	sort.Strings(aliases)
+	return aliases
+}
+
+// Given a system name as it appears in the data, return the name its data is published under.
+// The second result is false if the system's data is suppressed.
+func (config Configuration) PublishedName(name string) (string, bool) {
+	if sliceContainsString(config.Suppress, name) {
+		return "", false
+	}
+	return config.ResolveName(name), true
+}
@@ -0,0 +1,286 @@
+// Package hcp reads home computer prices gathered from magazine adverts and builds the per-quarter
+// price data that hcp-to-wiki publishes, so that other Go programs can use the same data without
+// scraping the generated wiki tables.
+//
+// Example:
+//
+//	dataset, err := hcp.Load("prices.csv", hcp.Options{})
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	for _, price := range dataset.PricesFor("ZX Spectrum 48K") {
+//		fmt.Printf("%dQ%d: %d\n", price.Year, price.Quarter, price.Price)
+//	}
+package hcp
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Options control how a Dataset is built from the CSV data
+type Options struct {
+	Config      *Configuration // Rename and suppress rules; nil means DefaultConfiguration()
+	Aggregation string         // How each quarter's price is chosen, one of the PriceAggregations; "" means "min"
+	Progress    ProgressFunc   // If set, called after each file has been parsed
+	Open        OpenFunc       // If set, used instead of os.Open to open each named file, e.g. to fetch it from a URL
+	Delimiter   rune           // The CSV field delimiter; 0 means detect it from the data (see ParseDelimiter)
+	Issue       *Issue         // If set, only the rows from this issue are read; the others are ignored
+}
+
+// An OpenFunc opens the CSV data with the given name for reading
+type OpenFunc func(name string) (io.ReadCloser, error)
+
+// A ProgressFunc is told how many of the files have been parsed so far and how many data rows they held
+type ProgressFunc func(filesDone int, files int, rows int)
+
+// A Dataset holds the adverts read from one or more CSV files and the prices published from them.
+// A Dataset is never changed once built, so its methods may be called from many goroutines at once;
+// callers must treat its fields, and the slices its methods return that share them, as read-only.
+// To change the data, build a new Dataset and replace the old one (as serve does when it refreshes).
+type Dataset struct {
+	Adverts     []Advert         // Every advert that passed validation, in file and row order
+	Validations []FileValidation // The validation results for each file
+	Dropped     []string         // The systems whose data was suppressed by the configuration, in alphabetical order
+	MinDate     int              // Date-index of the first quarter with data
+	MaxDate     int              // Date-index of the last quarter with data
+
+	prices       map[string][]int      // Published price in pence for each system, indexed by (date-index - MinDate)
+	observations map[string][][]Advert // The adverts behind each published price, indexed as for prices
+	options      Options               // How the dataset was built, so that a Subset is built the same way
+	systems      []string              // The keys of prices in alphabetical order
+}
+
+// A QuarterPrice is the price published for one system in one quarter
+type QuarterPrice struct {
+	Year    int
+	Quarter int
+	Price   int      // In pence
+	Adverts []Advert // The adverts the price was chosen from
+}
+
+// A SystemPrice is the price published for one system in a particular quarter
+type SystemPrice struct {
+	System  string
+	Price   int      // In pence
+	Adverts []Advert // The adverts the price was chosen from
+}
+
+// Load reads a single CSV file of adverts and builds a Dataset from it
+func Load(path string, opts Options) (*Dataset, error) {
+	return LoadFiles([]string{path}, opts)
+}
+
+// LoadFiles reads several CSV files of adverts and builds a single Dataset from all of them.
+// Rows that fail validation do not cause an error; they are described in the Dataset's Validations.
+func LoadFiles(paths []string, opts Options) (*Dataset, error) {
+	adverts, minDate, maxDate, validations, err := readAdverts(paths, opts)
+	if err != nil {
+		return nil, err
+	}
+	return newDataset(adverts, minDate, maxDate, validations, opts)
+}
+
+// LoadCSV builds a Dataset from CSV data that is not in a file, such as text pasted into a web page.
+// name identifies the data in the Dataset's Validations.
+func LoadCSV(name string, r io.Reader, opts Options) (*Dataset, error) {
+	data, err := readCSVData(r, opts.Delimiter)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read CSV data from [%s] (%w)", name, err)
+	}
+	if err := checkSchema(csvFile{name, data}); err != nil {
+		return nil, err
+	}
+	result := rejectDateOutliers([]parsedFile{parseFile(csvFile{name, data}, opts.configuration(), opts.Issue)}, opts.configuration(), opts.Issue)[0]
+	return newDataset(result.adverts, result.minDate, result.maxDate, []FileValidation{result.validation}, opts)
+}
+
+// Return the configuration to use: the one given, or the default
+func (opts Options) configuration() Configuration {
+	if opts.Config != nil {
+		return *opts.Config
+	}
+	return DefaultConfiguration()
+}
+
+// Build a Dataset from the adverts that have been read
+func newDataset(adverts []Advert, minDate int, maxDate int, validations []FileValidation, opts Options) (*Dataset, error) {
+	config := opts.configuration()
+	aggregation := opts.Aggregation
+	if aggregation == "" {
+		aggregation = "min"
+	}
+	aggregate, ok := PriceAggregations[aggregation]
+	if !ok {
+		return nil, fmt.Errorf("unknown aggregation [%s]", aggregation)
+	}
+
+	observations, dropped := PublishedObservations(adverts, minDate, maxDate, config)
+	prices := AggregateObservations(observations, aggregate, config)
+	systems := make([]string, 0, len(prices))
+	for system := range prices {
+		systems = append(systems, system)
+	}
+	sort.Strings(systems)
+	return &Dataset{
+		Adverts:      adverts,
+		Validations:  validations,
+		Dropped:      dropped,
+		MinDate:      minDate,
+		MaxDate:      maxDate,
+		prices:       prices,
+		observations: observations,
+		options:      opts,
+		systems:      systems,
+	}, nil
+}
+
+// Subset returns a Dataset built, in the same way, from only those adverts for which keep returns true.
+// It covers the same quarters and has the same Validations as the original, so that the two can be compared side by side.
+func (dataset *Dataset) Subset(keep func(advert Advert) bool) *Dataset {
+	adverts := make([]Advert, 0)
+	for _, advert := range dataset.Adverts {
+		if keep(advert) {
+			adverts = append(adverts, advert)
+		}
+	}
+	// The aggregation was checked when the original was built, so this cannot fail
+	subset, _ := newDataset(adverts, dataset.MinDate, dataset.MaxDate, dataset.Validations, dataset.options)
+	return subset
+}
+
+// Preprocessing returns the systems whose data the configuration's suppress and rename rules changed (see PreprocessingChanges)
+func (dataset *Dataset) Preprocessing() []PreprocessedSystem {
+	return PreprocessingChanges(dataset.Adverts, dataset.options.configuration())
+}
+
+// Systems returns the names of the published systems in alphabetical order
+func (dataset *Dataset) Systems() []string {
+	return append([]string(nil), dataset.systems...)
+}
+
+// Digest returns a hash of the dataset's adverts, published prices and validation results, as 16 hexadecimal digits.
+// Two datasets with the same digest answer every query the same way, so it identifies a version of the data,
+// such as for the ETags of a server's responses.
+func (dataset *Dataset) Digest() string {
+	digest := sha256.New()
+	// Adverts, maps and FileValidations are plain data, which cannot fail to encode
+	encoder := json.NewEncoder(digest)
+	encoder.Encode(dataset.Adverts)
+	encoder.Encode(dataset.prices)
+	encoder.Encode(dataset.Validations)
+	fmt.Fprintf(digest, "%d %d\n", dataset.MinDate, dataset.MaxDate)
+	return hex.EncodeToString(digest.Sum(nil))[:16]
+}
+
+// Resolve returns the name that the named system is published under: the name itself, or, for one of its
+// aliases in the rename rules, the name the rules publish it under; so that a system may be looked up by
+// any of its names, such as "Science of Cambridge MK14" for "MK14".
+func (dataset *Dataset) Resolve(name string) string {
+	return dataset.options.configuration().ResolveName(name)
+}
+
+// Search returns the published systems whose names, or the names of their aliases (see Resolve), contain the query,
+// ignoring case, spaces and punctuation, so that "zx-spectrum" finds "ZX Spectrum 48K". Systems with a name that
+// starts with the query come first, then those with a word that does, then the rest, each in alphabetical order.
+// An empty query matches nothing.
+func (dataset *Dataset) Search(query string) []string {
+	return SearchSystems(dataset.systems, query, dataset.options.configuration())
+}
+
+// SearchSystems searches the given systems, in alphabetical order, as Search searches a dataset's, with the aliases of the
+// given configuration; for the programs that have the names of the published systems without the dataset itself.
+func SearchSystems(systems []string, query string, config Configuration) []string {
+	wanted := searchKey(query)
+	if wanted == "" {
+		return []string{}
+	}
+	ranked := make([][]string, 3)
+	for _, system := range systems {
+		rank := len(ranked)
+		for _, name := range append([]string{system}, config.Aliases(system)...) {
+			key := searchKey(name)
+			switch {
+			case strings.HasPrefix(key, wanted):
+				rank = 0
+			case !strings.Contains(key, wanted):
+				continue
+			case strings.Contains(" "+strings.ToLower(name), " "+strings.ToLower(strings.TrimSpace(query))):
+				rank = min(rank, 1)
+			default:
+				rank = min(rank, 2)
+			}
+		}
+		if rank < len(ranked) {
+			ranked[rank] = append(ranked[rank], system)
+		}
+	}
+	return append(append(append([]string{}, ranked[0]...), ranked[1]...), ranked[2]...)
+}
+
+// Return text as it is compared by Search: lower case, with only its letters and digits
+func searchKey(text string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, text)
+}
+
+// PricesFor returns the published prices for a system, named by any of its names (see Resolve), oldest first, skipping quarters without a price.
+// An unknown system has no prices.
+func (dataset *Dataset) PricesFor(system string) []QuarterPrice {
+	system = dataset.Resolve(system)
+	result := make([]QuarterPrice, 0)
+	for i, price := range dataset.prices[system] {
+		if price <= 0 {
+			continue
+		}
+		year, quarter := DecodeIndexByQuarter(dataset.MinDate + i)
+		result = append(result, QuarterPrice{year, quarter, price, dataset.observations[system][i]})
+	}
+	return result
+}
+
+// Quarter returns the prices published for every system in the given year and quarter (1 to 4),
+// in alphabetical order of system, skipping systems without a price in that quarter.
+func (dataset *Dataset) Quarter(year int, quarter int) []SystemPrice {
+	result := make([]SystemPrice, 0)
+	index := BuildIndexFromYearAndQuarter(year, quarter)
+	if (quarter < 1) || (quarter > 4) || (index < dataset.MinDate) || (index > dataset.MaxDate) {
+		return result
+	}
+	for _, system := range dataset.systems {
+		if price := dataset.prices[system][index-dataset.MinDate]; price > 0 {
+			result = append(result, SystemPrice{system, price, dataset.observations[system][index-dataset.MinDate]})
+		}
+	}
+	return result
+}
+
+// Prices returns a copy of the published prices as a map of system => price-array,
+// with each price in pence indexed by (date-index - MinDate) and 0 for quarters without a price.
+func (dataset *Dataset) Prices() map[string][]int {
+	result := make(map[string][]int, len(dataset.prices))
+	for system, prices := range dataset.prices {
+		result[system] = append([]int(nil), prices...)
+	}
+	return result
+}
+
+// Observations returns the adverts behind each published price as a map of system => advert-lists,
+// indexed as for Prices, with no adverts for quarters without a price. The adverts themselves are shared, not copied.
+func (dataset *Dataset) Observations() map[string][][]Advert {
+	result := make(map[string][][]Advert, len(dataset.observations))
+	for system, observations := range dataset.observations {
+		result[system] = append([][]Advert(nil), observations...)
+	}
+	return result
+}
@@ -0,0 +1,317 @@
+package hcp
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Adverts for three published systems, one of them under an alias, and for a suppressed one
+const test_csv = `Home computer prices,,,,,,,
+Source,YYYY-MM,Page,System,Price,,Kit,Board
+PCW,1982-01,p1,ZX81,£69.95,,N,N
+PCW,1982-02,p2,ZX81,£49.95,,N,N
+PCW,1982-04,p3,ZX Spectrum 48K,£175,,N,N
+PCW,1982-05,p4,Science of Cambridge MK14,£39.95,,N,N
+PCW,1982-05,p5,Apple II,£1000,,N,N
+PCW,1982-06,p6,ZX81,£99.999,,N,N
+`
+
+// Return the path of a file holding the given CSV data
+func writeTestCSV(t *testing.T, data string) string {
+	path := filepath.Join(t.TempDir(), "prices.csv")
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// Return the dataset built from test_csv
+func testDataset(t *testing.T) *Dataset {
+	dataset, err := Load(writeTestCSV(t, test_csv), Options{})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	return dataset
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name     string
+		csv      string
+		systems  []string
+		dropped  []string
+		accepted int
+		rejected int
+	}{
+		{"adverts", test_csv, []string{"MK14", "ZX Spectrum 48K", "ZX81"}, []string{"Apple II"}, 5, 1},
+		{"header only", "Source,YYYY-MM,Page,System,Price,,Kit,Board\n", []string{}, []string{}, 0, 0},
+	}
+	for _, test := range tests {
+		dataset, err := Load(writeTestCSV(t, test.csv), Options{})
+		if err != nil {
+			t.Errorf("%s: Load() error = %v", test.name, err)
+			continue
+		}
+		if systems := dataset.Systems(); (len(systems) != len(test.systems)) || ((len(test.systems) > 0) && !reflect.DeepEqual(systems, test.systems)) {
+			t.Errorf("%s: Systems() = %q; want %q", test.name, systems, test.systems)
+		}
+		if (len(dataset.Dropped) != len(test.dropped)) || ((len(test.dropped) > 0) && !reflect.DeepEqual(dataset.Dropped, test.dropped)) {
+			t.Errorf("%s: Dropped = %q; want %q", test.name, dataset.Dropped, test.dropped)
+		}
+		if validation := dataset.Validations[0]; (validation.Accepted != test.accepted) || (validation.Rejected != test.rejected) {
+			t.Errorf("%s: %d accepted and %d rejected; want %d and %d", test.name, validation.Accepted, validation.Rejected, test.accepted, test.rejected)
+		}
+	}
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.csv"), Options{}); err == nil {
+		t.Errorf("Load() of a missing file succeeded")
+	}
+	if _, err := Load(writeTestCSV(t, test_csv), Options{Aggregation: "bogus"}); err == nil {
+		t.Errorf("Load() with an unknown aggregation succeeded")
+	}
+}
+
+func TestLoadCSV(t *testing.T) {
+	tests := []struct {
+		name     string
+		csv      string
+		accepted int
+		rejected int
+		err      bool
+	}{
+		{"adverts", test_csv, 5, 1, false},
+		{"header only", "Source,YYYY-MM,Page,System,Price,,Kit,Board\n", 0, 0, false},
+		{"empty", "", 0, 0, false},
+		{"schema version 1", "# hcp-schema 1\nSource,YYYY-MM,Page,System,Price,,Kit,Board\n", 0, 0, false},
+		{"unknown schema version", "# hcp-schema 99\nSource,YYYY-MM,Page,System,Price,,Kit,Board\n", 0, 0, true},
+		{"bad schema version", "# hcp-schema one\nSource,YYYY-MM,Page,System,Price,,Kit,Board\n", 0, 0, true},
+		{"version 2 header lacking a column", "# hcp-schema 2\nSource,YYYY-MM,Page,System,Price,,Kit,Board\n", 0, 0, true},
+		{"version 2", "# hcp-schema 2\nSource,YYYY-MM,Page,System,Price,,Kit,Board,Region,Contributor,Street date\nPCW,1982-01,p1,ZX81,£69.95,,N,N,UK,,\n", 1, 0, false},
+	}
+	for _, test := range tests {
+		dataset, err := LoadCSV(test.name, strings.NewReader(test.csv), Options{})
+		if (err != nil) != test.err {
+			t.Errorf("%s: LoadCSV() error = %v; want error %t", test.name, err, test.err)
+			continue
+		}
+		if test.err {
+			continue
+		}
+		if validation := dataset.Validations[0]; (validation.Filename != test.name) || (validation.Accepted != test.accepted) || (validation.Rejected != test.rejected) {
+			t.Errorf("%s: [%s] %d accepted and %d rejected; want %d and %d", test.name, validation.Filename, validation.Accepted, validation.Rejected, test.accepted, test.rejected)
+		}
+	}
+	fromCSV, err := LoadCSV("test", strings.NewReader(test_csv), Options{})
+	if err != nil {
+		t.Fatalf("LoadCSV() error = %v", err)
+	}
+	if fromFile := testDataset(t); !reflect.DeepEqual(fromCSV.Prices(), fromFile.Prices()) {
+		t.Errorf("LoadCSV() prices = %v; Load() prices = %v", fromCSV.Prices(), fromFile.Prices())
+	}
+}
+
+func TestHeaderOnly(t *testing.T) {
+	dataset, err := Load(writeTestCSV(t, "Source,YYYY-MM,Page,System,Price,,Kit,Board\n"), Options{})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(dataset.Adverts) != 0 {
+		t.Errorf("Adverts = %v; want none", dataset.Adverts)
+	}
+	if prices := dataset.PricesFor("ZX81"); len(prices) != 0 {
+		t.Errorf("PricesFor() = %v; want none", prices)
+	}
+	if prices := dataset.Quarter(1982, 1); len(prices) != 0 {
+		t.Errorf("Quarter() = %v; want none", prices)
+	}
+	if systems := dataset.Search("zx"); len(systems) != 0 {
+		t.Errorf("Search() = %q; want none", systems)
+	}
+}
+
+func TestPricesFor(t *testing.T) {
+	dataset := testDataset(t)
+	type quarterPrice struct {
+		year    int
+		quarter int
+		price   int
+		adverts int
+	}
+	tests := []struct {
+		system string
+		want   []quarterPrice
+	}{
+		{"ZX81", []quarterPrice{{1982, 1, 4995, 2}}},
+		{"ZX Spectrum 48K", []quarterPrice{{1982, 2, 17500, 1}}},
+		{"MK14", []quarterPrice{{1982, 2, 3995, 1}}},
+		{"Science of Cambridge MK14", []quarterPrice{{1982, 2, 3995, 1}}},
+		{"Apple II", []quarterPrice{}},
+		{"Dragon 32", []quarterPrice{}},
+	}
+	for _, test := range tests {
+		got := make([]quarterPrice, 0)
+		for _, price := range dataset.PricesFor(test.system) {
+			got = append(got, quarterPrice{price.Year, price.Quarter, price.Price, len(price.Adverts)})
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("PricesFor(%q) = %v; want %v", test.system, got, test.want)
+		}
+	}
+}
+
+func TestQuarter(t *testing.T) {
+	dataset := testDataset(t)
+	tests := []struct {
+		year    int
+		quarter int
+		want    map[string]int
+	}{
+		{1982, 1, map[string]int{"ZX81": 4995}},
+		{1982, 2, map[string]int{"MK14": 3995, "ZX Spectrum 48K": 17500}},
+		{1981, 4, map[string]int{}},
+		{1990, 1, map[string]int{}},
+		{1982, 0, map[string]int{}},
+		{1982, 5, map[string]int{}},
+	}
+	for _, test := range tests {
+		prices := dataset.Quarter(test.year, test.quarter)
+		got := make(map[string]int)
+		for i, price := range prices {
+			got[price.System] = price.Price
+			if (i > 0) && (prices[i-1].System >= price.System) {
+				t.Errorf("Quarter(%d, %d) is not in alphabetical order: %q before %q", test.year, test.quarter, prices[i-1].System, price.System)
+			}
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Quarter(%d, %d) = %v; want %v", test.year, test.quarter, got, test.want)
+		}
+	}
+}
+
+func TestSearch(t *testing.T) {
+	dataset := testDataset(t)
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"zx", []string{"ZX Spectrum 48K", "ZX81"}},
+		{"zx-spectrum", []string{"ZX Spectrum 48K"}},
+		{"spectrum", []string{"ZX Spectrum 48K"}},
+		{"48", []string{"ZX Spectrum 48K"}},
+		{"81", []string{"ZX81"}},
+		{"cambridge", []string{"MK14"}},
+		{"apple", []string{}},
+		{"", []string{}},
+		{" - ", []string{}},
+	}
+	for _, test := range tests {
+		if got := dataset.Search(test.query); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Search(%q) = %q; want %q", test.query, got, test.want)
+		}
+	}
+}
+
+func TestTrailing(t *testing.T) {
+	config := DefaultConfiguration()
+	config.Lifespans = map[string]Lifespan{"ZX81": {Launched: "1981-03", Discontinued: "1982-06"}}
+	config.TrailingMonths = 3
+	data := "Source,YYYY-MM,Page,System,Price,,Kit,Board\nPCW,1982-01,p1,ZX81,£69.95,,N,N\nPCW,1982-08,p2,ZX81,£49.95,,N,N\nPCW,1982-11,p3,ZX81,£39.95,,N,N\n"
+	tests := []struct {
+		name     string
+		exclude  bool
+		quarters []int
+		warnings []string
+	}{
+		{"warned", false, []int{1, 3, 4}, []string{"outside the lifespan of [ZX81], 1981-03 to 1982-06", "a trailing price, more than 3 months after [ZX81] was discontinued (1981-03 to 1982-06)"}},
+		{"excluded", true, []int{1, 3}, []string{"outside the lifespan of [ZX81], 1981-03 to 1982-06", "a trailing price, more than 3 months after [ZX81] was discontinued (1981-03 to 1982-06), so not published"}},
+	}
+	for _, test := range tests {
+		config.ExcludeTrailing = test.exclude
+		dataset, err := LoadCSV(test.name, strings.NewReader(data), Options{Config: &config})
+		if err != nil {
+			t.Fatalf("%s: LoadCSV() error = %v", test.name, err)
+		}
+		quarters := make([]int, 0)
+		for _, price := range dataset.PricesFor("ZX81") {
+			quarters = append(quarters, price.Quarter)
+		}
+		if !reflect.DeepEqual(quarters, test.quarters) {
+			t.Errorf("%s: quarters with prices = %v; want %v", test.name, quarters, test.quarters)
+		}
+		warnings := make([]string, 0)
+		for _, problem := range dataset.Validations[0].Problems {
+			if !problem.Rejected {
+				warnings = append(warnings, problem.Err.Error())
+			}
+		}
+		if !reflect.DeepEqual(warnings, test.warnings) {
+			t.Errorf("%s: warnings = %q; want %q", test.name, warnings, test.warnings)
+		}
+	}
+}
+
+func TestTrailingYearOnly(t *testing.T) {
+	config := DefaultConfiguration()
+	config.Lifespans = map[string]Lifespan{"ZX81": {Launched: "1981-03", Discontinued: "1982-06"}}
+	config.TrailingMonths = 3
+	data := "Source,YYYY-MM,Page,System,Price,,Kit,Board\nPCW,1982-01,p1,ZX81,£69.95,,N,N\nPCW,1982,p2,ZX81,£39.95,,N,N\n"
+	tests := []struct {
+		name     string
+		exclude  bool
+		quarters []int
+		warning  string
+	}{
+		{"warned", false, []int{1, 2, 3, 4}, "in 1 of the 4 quarters it is placed in"},
+		{"excluded", true, []int{1, 2, 3}, "in 1 of the 4 quarters it is placed in, where it is not published"},
+	}
+	for _, test := range tests {
+		config.ExcludeTrailing = test.exclude
+		dataset, err := LoadCSV(test.name, strings.NewReader(data), Options{Config: &config})
+		if err != nil {
+			t.Fatalf("%s: LoadCSV() error = %v", test.name, err)
+		}
+		quarters := make([]int, 0)
+		for _, price := range dataset.PricesFor("ZX81") {
+			quarters = append(quarters, price.Quarter)
+		}
+		if !reflect.DeepEqual(quarters, test.quarters) {
+			t.Errorf("%s: quarters with prices = %v; want %v", test.name, quarters, test.quarters)
+		}
+		problems := dataset.Validations[0].Problems
+		if (len(problems) != 1) || problems[0].Rejected || (problems[0].Row != 3) || !strings.HasSuffix(problems[0].Err.Error(), test.warning) {
+			t.Errorf("%s: problems = %v; want one warning for row 3 ending %q", test.name, problems, test.warning)
+		}
+	}
+}
+
+func TestTrailingCoverDateLead(t *testing.T) {
+	config := DefaultConfiguration()
+	config.Lifespans = map[string]Lifespan{"ZX81": {Launched: "1981-03", Discontinued: "1982-06"}}
+	config.TrailingMonths = 3
+	config.ExcludeTrailing = true
+	config.CoverDateLeads = map[string]int{"PCW": 2}
+	// On sale in 1982-02, 1982-08 and 1982-11; only the last is more than 3 months after the ZX81 was discontinued
+	data := "Source,YYYY-MM,Page,System,Price,,Kit,Board\nPCW,1982-04,p1,ZX81,£69.95,,N,N\nPCW,1982-10,p2,ZX81,£49.95,,N,N\nPCW,1983-01,p3,ZX81,£39.95,,N,N\n"
+	dataset, err := LoadCSV("lead", strings.NewReader(data), Options{Config: &config})
+	if err != nil {
+		t.Fatalf("LoadCSV() error = %v", err)
+	}
+	quarters := make([]int, 0)
+	for _, price := range dataset.PricesFor("ZX81") {
+		quarters = append(quarters, price.Quarter)
+	}
+	if want := []int{1, 3}; !reflect.DeepEqual(quarters, want) {
+		t.Errorf("quarters with prices = %v; want %v", quarters, want)
+	}
+	warnings := make([]string, 0)
+	for _, problem := range dataset.Validations[0].Problems {
+		warnings = append(warnings, fmt.Sprintf("%d: %s", problem.Row, problem.Err.Error()))
+	}
+	want := []string{"3: outside the lifespan of [ZX81], 1981-03 to 1982-06", "4: a trailing price, more than 3 months after [ZX81] was discontinued (1981-03 to 1982-06), so not published"}
+	if !reflect.DeepEqual(warnings, want) {
+		t.Errorf("warnings = %q; want %q", warnings, want)
+	}
+}
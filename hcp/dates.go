@@ -0,0 +1,97 @@
+package hcp
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Some adverts come from issues whose month is unknown, so their dates are less precise than "YYYY-MM":
+// o "1981-Q4" (or "1981Q4") is placed in the first month of that quarter; as the tables are by quarter, nothing is lost
+// o "1981" is placed in the quarter given by the configuration's year_only_quarter or, if that is 0 (the default),
+//   spread across the year as a copy of the advert in each of its quarters. Either way the advert is marked as
+//   having an approximate date, so that a price resting only on such adverts can be marked in the tables.
+// o a special issue, such as "Christmas 1983" or "Spring 1980", is placed in the month the configuration's issue_months
+//   gives for that kind of issue; one given the month 0, such as "Annual 1982", is dated by its year alone, as above.
+
+// Matches a date written almost as "YYYY-MM", such as "83-06", "1983/06" or "1983-6"
+var loose_yyyy_mm_date = regexp.MustCompile(`^(\d{2}|\d{4})[-/.](\d{1,2})$`)
+
+// Matches a date given as a year and quarter, such as "1981-Q4"
+var year_quarter_date = regexp.MustCompile(`^(\d{4})-?[Qq]([1-4])$`)
+
+// Matches a date given as a year alone, such as "1981"
+var year_only_date = regexp.MustCompile(`^(\d{4})$`)
+
+// Matches the date of a special issue, such as "Christmas 1983"
+var special_issue_date = regexp.MustCompile(`^([A-Za-z][A-Za-z ]*?)\s+(\d{4})$`)
+
+// Given the text of a date that is not of the form "YYYY-MM", return the year and the months of the quarters that an advert
+// with that date is placed in, and whether the date was approximate (known only to the year).
+// ok is false if the text is not a recognised approximate date or its year is outside the range min_year to max_year;
+// if it looks like a special issue that the configuration does not know, err also says so.
+func handle_approximate_date(text string, config Configuration) (year int, months []int, approximate bool, ok bool, err error) {
+	if match := year_quarter_date.FindStringSubmatch(text); match != nil {
+		year, _ = strconv.Atoi(match[1])
+		quarter, _ := strconv.Atoi(match[2])
+		months = []int{quarter*3 - 2}
+	} else if match := year_only_date.FindStringSubmatch(text); match != nil {
+		year, _ = strconv.Atoi(match[1])
+		approximate = true
+		months = yearOnlyMonths(config)
+	} else if match := special_issue_date.FindStringSubmatch(text); match != nil {
+		month, ok := issueMonth(config, match[1])
+		if !ok {
+			return 0, nil, false, false, fmt.Errorf("unknown special issue [%s] (not in issue_months)", match[1])
+		}
+		year, _ = strconv.Atoi(match[2])
+		if month == 0 {
+			approximate = true
+			months = yearOnlyMonths(config)
+		} else {
+			months = []int{month}
+		}
+	} else {
+		return 0, nil, false, false, nil
+	}
+	if (year < min_year) || (year > max_year) {
+		return 0, nil, false, false, nil
+	}
+	return year, months, approximate, true, nil
+}
+
+// Return the months that an advert dated only by its year is placed in, as the configuration directs
+func yearOnlyMonths(config Configuration) []int {
+	if (config.YearOnlyQuarter >= 1) && (config.YearOnlyQuarter <= 4) {
+		return []int{config.YearOnlyQuarter*3 - 2}
+	}
+	return []int{1, 4, 7, 10}
+}
+
+// Return the month of the named kind of special issue (0 if it is dated only by its year), matching the name regardless of case.
+// ok is false if the configuration does not know the kind of issue or gives it a month that is not 0 to 12.
+func issueMonth(config Configuration, name string) (month int, ok bool) {
+	for issue, month := range config.IssueMonths {
+		if strings.EqualFold(issue, name) {
+			return month, (month >= 0) && (month <= 12)
+		}
+	}
+	return 0, false
+}
+
+// Given the text of a date that is not of the form "YYYY-MM" but is written in one of the forms often found in contributed files,
+// such as "83-06" or "1983/06", return it in the form "YYYY-MM". A two-digit year is taken to be in the 1900s.
+// ok is false if the text is not in such a form.
+func normalise_yyyy_mm(text string) (yyyy_mm string, ok bool) {
+	match := loose_yyyy_mm_date.FindStringSubmatch(text)
+	if match == nil {
+		return "", false
+	}
+	year, _ := strconv.Atoi(match[1])
+	if len(match[1]) == 2 {
+		year += 1900
+	}
+	month, _ := strconv.Atoi(match[2])
+	return fmt.Sprintf("%04d-%02d", year, month), true
+}
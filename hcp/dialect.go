@@ -0,0 +1,122 @@
+package hcp
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"unicode/utf8"
+)
+
+// Spreadsheet tools do not agree on what a CSV file looks like: some separate fields with semicolons or tabs
+// (notably where the comma is the decimal separator), some begin the file with a UTF-8 byte order mark and
+// some leave quotes inside unquoted fields unescaped. The dialect is detected from the data unless a delimiter
+// is given with Options.Delimiter (or -csv-delimiter on the command line).
+
+// The delimiters that are recognised when detecting the dialect, the usual one first
+var csv_delimiters = []rune{',', ';', '\t', '|'}
+
+// The UTF-8 byte order mark that some tools write at the start of a file
+var utf8_bom = []byte{0xEF, 0xBB, 0xBF}
+
+// Given the name of a delimiter, as given on the command line, return it: "auto" (or "") is 0, meaning detect it,
+// "tab" is a tab and anything else must be a single character.
+func ParseDelimiter(name string) (rune, error) {
+	switch name {
+	case "", "auto":
+		return 0, nil
+	case "tab", "\\t":
+		return '\t', nil
+	}
+	delimiter, size := utf8.DecodeRuneInString(name)
+	if (size != len(name)) || (delimiter == '"') || (delimiter == '\r') || (delimiter == '\n') {
+		return 0, fmt.Errorf("bad CSV delimiter [%s]", name)
+	}
+	return delimiter, nil
+}
+
+// Read all the rows of CSV advert data, separated by delimiter or, if that is 0, by the delimiter detected from the data.
+// Rows may have different numbers of fields, as happens when the exports of several spreadsheets,
+// with differing optional columns, are concatenated; parseData pads the short ones.
+// If the data is not properly quoted it is read again leniently, with quotes inside unquoted fields taken literally.
+func readCSVData(r io.Reader, delimiter rune) ([][]string, error) {
+	rows, _, err := ReadCSVRows(r, delimiter)
+	return rows, err
+}
+
+// ReadCSVRows reads all the rows of CSV data as readCSVData does, without interpreting them,
+// and also returns the delimiter that separated their fields, for tools that rewrite the data.
+// Comment lines are returned as rows too, split at the delimiter.
+func ReadCSVRows(r io.Reader, delimiter rune) ([][]string, rune, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, 0, err
+	}
+	data = bytes.TrimPrefix(data, utf8_bom)
+	if delimiter == 0 {
+		delimiter = detectDelimiter(data)
+	}
+
+	read := func(lazyQuotes bool) ([][]string, error) {
+		reader := csv.NewReader(bytes.NewReader(data))
+		reader.Comma = delimiter
+		reader.FieldsPerRecord = -1
+		reader.LazyQuotes = lazyQuotes
+		return reader.ReadAll()
+	}
+	rows, err := read(false)
+	if errors.Is(err, csv.ErrBareQuote) || errors.Is(err, csv.ErrQuote) {
+		if lenient, lenientErr := read(true); lenientErr == nil {
+			return lenient, delimiter, nil
+		}
+	}
+	return rows, delimiter, err
+}
+
+// Return the delimiter used by the CSV data.
+// The header line begins with "Source", so the character following it is the delimiter; if there is no such line,
+// the recognised delimiter that appears most often (outside quotes) in the first few lines is taken, or a comma if none does.
+func detectDelimiter(data []byte) rune {
+	lines := bytes.SplitN(data, []byte("\n"), 50)
+	for _, line := range lines {
+		rest, ok := bytes.CutPrefix(line, []byte("Source"))
+		if !ok {
+			rest, ok = bytes.CutPrefix(line, []byte("\"Source\""))
+		}
+		if !ok || (len(rest) == 0) {
+			continue
+		}
+		if candidate, _ := utf8.DecodeRune(rest); sliceContainsRune(csv_delimiters, candidate) {
+			return candidate
+		}
+	}
+
+	counts := make(map[rune]int)
+	for _, line := range lines[:min(len(lines), 10)] {
+		quoted := false
+		for _, character := range string(line) {
+			if character == '"' {
+				quoted = !quoted
+			} else if !quoted && sliceContainsRune(csv_delimiters, character) {
+				counts[character]++
+			}
+		}
+	}
+	best := csv_delimiters[0]
+	for _, candidate := range csv_delimiters {
+		if counts[candidate] > counts[best] {
+			best = candidate
+		}
+	}
+	return best
+}
+
+func sliceContainsRune(slice []rune, candidate rune) bool {
+	for _, member := range slice {
+		if member == candidate {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,941 @@
+// The gRPC interface to the home computer price data, served by "hcp-to-wiki grpc-serve".
+//
+// Regenerate the Go code after changing this file with:
+//
+//   protoc --go_out=. --go_opt=paths=source_relative \
+//          --go-grpc_out=. --go-grpc_opt=paths=source_relative hcp/hcppb/hcp.proto
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        v4.25.3
+// source: hcp/hcppb/hcp.proto
+
+package hcppb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type ListSystemsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *ListSystemsRequest) Reset() {
+	*x = ListSystemsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_hcp_hcppb_hcp_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListSystemsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSystemsRequest) ProtoMessage() {}
+
+func (x *ListSystemsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_hcp_hcppb_hcp_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSystemsRequest.ProtoReflect.Descriptor instead.
+func (*ListSystemsRequest) Descriptor() ([]byte, []int) {
+	return file_hcp_hcppb_hcp_proto_rawDescGZIP(), []int{0}
+}
+
+type ListSystemsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Systems []string `protobuf:"bytes,1,rep,name=systems,proto3" json:"systems,omitempty"`
+}
+
+func (x *ListSystemsResponse) Reset() {
+	*x = ListSystemsResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_hcp_hcppb_hcp_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListSystemsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSystemsResponse) ProtoMessage() {}
+
+func (x *ListSystemsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_hcp_hcppb_hcp_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSystemsResponse.ProtoReflect.Descriptor instead.
+func (*ListSystemsResponse) Descriptor() ([]byte, []int) {
+	return file_hcp_hcppb_hcp_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ListSystemsResponse) GetSystems() []string {
+	if x != nil {
+		return x.Systems
+	}
+	return nil
+}
+
+type PricesForSystemRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	System string `protobuf:"bytes,1,opt,name=system,proto3" json:"system,omitempty"`
+}
+
+func (x *PricesForSystemRequest) Reset() {
+	*x = PricesForSystemRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_hcp_hcppb_hcp_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PricesForSystemRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PricesForSystemRequest) ProtoMessage() {}
+
+func (x *PricesForSystemRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_hcp_hcppb_hcp_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PricesForSystemRequest.ProtoReflect.Descriptor instead.
+func (*PricesForSystemRequest) Descriptor() ([]byte, []int) {
+	return file_hcp_hcppb_hcp_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *PricesForSystemRequest) GetSystem() string {
+	if x != nil {
+		return x.System
+	}
+	return ""
+}
+
+type PricesForSystemResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Prices []*QuarterPrice `protobuf:"bytes,1,rep,name=prices,proto3" json:"prices,omitempty"`
+}
+
+func (x *PricesForSystemResponse) Reset() {
+	*x = PricesForSystemResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_hcp_hcppb_hcp_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PricesForSystemResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PricesForSystemResponse) ProtoMessage() {}
+
+func (x *PricesForSystemResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_hcp_hcppb_hcp_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PricesForSystemResponse.ProtoReflect.Descriptor instead.
+func (*PricesForSystemResponse) Descriptor() ([]byte, []int) {
+	return file_hcp_hcppb_hcp_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *PricesForSystemResponse) GetPrices() []*QuarterPrice {
+	if x != nil {
+		return x.Prices
+	}
+	return nil
+}
+
+type QuarterPrice struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Year        int32 `protobuf:"varint,1,opt,name=year,proto3" json:"year,omitempty"`
+	Quarter     int32 `protobuf:"varint,2,opt,name=quarter,proto3" json:"quarter,omitempty"` // 1 to 4
+	Pence       int64 `protobuf:"varint,3,opt,name=pence,proto3" json:"pence,omitempty"`
+	AdvertCount int32 `protobuf:"varint,4,opt,name=advert_count,json=advertCount,proto3" json:"advert_count,omitempty"` // How many adverts the price was chosen from
+}
+
+func (x *QuarterPrice) Reset() {
+	*x = QuarterPrice{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_hcp_hcppb_hcp_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *QuarterPrice) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QuarterPrice) ProtoMessage() {}
+
+func (x *QuarterPrice) ProtoReflect() protoreflect.Message {
+	mi := &file_hcp_hcppb_hcp_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QuarterPrice.ProtoReflect.Descriptor instead.
+func (*QuarterPrice) Descriptor() ([]byte, []int) {
+	return file_hcp_hcppb_hcp_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *QuarterPrice) GetYear() int32 {
+	if x != nil {
+		return x.Year
+	}
+	return 0
+}
+
+func (x *QuarterPrice) GetQuarter() int32 {
+	if x != nil {
+		return x.Quarter
+	}
+	return 0
+}
+
+func (x *QuarterPrice) GetPence() int64 {
+	if x != nil {
+		return x.Pence
+	}
+	return 0
+}
+
+func (x *QuarterPrice) GetAdvertCount() int32 {
+	if x != nil {
+		return x.AdvertCount
+	}
+	return 0
+}
+
+type PricesForQuarterRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Year    int32 `protobuf:"varint,1,opt,name=year,proto3" json:"year,omitempty"`
+	Quarter int32 `protobuf:"varint,2,opt,name=quarter,proto3" json:"quarter,omitempty"` // 1 to 4
+}
+
+func (x *PricesForQuarterRequest) Reset() {
+	*x = PricesForQuarterRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_hcp_hcppb_hcp_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PricesForQuarterRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PricesForQuarterRequest) ProtoMessage() {}
+
+func (x *PricesForQuarterRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_hcp_hcppb_hcp_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PricesForQuarterRequest.ProtoReflect.Descriptor instead.
+func (*PricesForQuarterRequest) Descriptor() ([]byte, []int) {
+	return file_hcp_hcppb_hcp_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *PricesForQuarterRequest) GetYear() int32 {
+	if x != nil {
+		return x.Year
+	}
+	return 0
+}
+
+func (x *PricesForQuarterRequest) GetQuarter() int32 {
+	if x != nil {
+		return x.Quarter
+	}
+	return 0
+}
+
+type PricesForQuarterResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Prices []*SystemPrice `protobuf:"bytes,1,rep,name=prices,proto3" json:"prices,omitempty"`
+}
+
+func (x *PricesForQuarterResponse) Reset() {
+	*x = PricesForQuarterResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_hcp_hcppb_hcp_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PricesForQuarterResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PricesForQuarterResponse) ProtoMessage() {}
+
+func (x *PricesForQuarterResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_hcp_hcppb_hcp_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PricesForQuarterResponse.ProtoReflect.Descriptor instead.
+func (*PricesForQuarterResponse) Descriptor() ([]byte, []int) {
+	return file_hcp_hcppb_hcp_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *PricesForQuarterResponse) GetPrices() []*SystemPrice {
+	if x != nil {
+		return x.Prices
+	}
+	return nil
+}
+
+type SystemPrice struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	System      string `protobuf:"bytes,1,opt,name=system,proto3" json:"system,omitempty"`
+	Pence       int64  `protobuf:"varint,2,opt,name=pence,proto3" json:"pence,omitempty"`
+	AdvertCount int32  `protobuf:"varint,3,opt,name=advert_count,json=advertCount,proto3" json:"advert_count,omitempty"` // How many adverts the price was chosen from
+}
+
+func (x *SystemPrice) Reset() {
+	*x = SystemPrice{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_hcp_hcppb_hcp_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SystemPrice) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SystemPrice) ProtoMessage() {}
+
+func (x *SystemPrice) ProtoReflect() protoreflect.Message {
+	mi := &file_hcp_hcppb_hcp_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SystemPrice.ProtoReflect.Descriptor instead.
+func (*SystemPrice) Descriptor() ([]byte, []int) {
+	return file_hcp_hcppb_hcp_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *SystemPrice) GetSystem() string {
+	if x != nil {
+		return x.System
+	}
+	return ""
+}
+
+func (x *SystemPrice) GetPence() int64 {
+	if x != nil {
+		return x.Pence
+	}
+	return 0
+}
+
+func (x *SystemPrice) GetAdvertCount() int32 {
+	if x != nil {
+		return x.AdvertCount
+	}
+	return 0
+}
+
+type ValidateRowsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Rows in the same CSV layout as the data files; the header line may be left out
+	Csv string `protobuf:"bytes,1,opt,name=csv,proto3" json:"csv,omitempty"`
+}
+
+func (x *ValidateRowsRequest) Reset() {
+	*x = ValidateRowsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_hcp_hcppb_hcp_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ValidateRowsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ValidateRowsRequest) ProtoMessage() {}
+
+func (x *ValidateRowsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_hcp_hcppb_hcp_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ValidateRowsRequest.ProtoReflect.Descriptor instead.
+func (*ValidateRowsRequest) Descriptor() ([]byte, []int) {
+	return file_hcp_hcppb_hcp_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ValidateRowsRequest) GetCsv() string {
+	if x != nil {
+		return x.Csv
+	}
+	return ""
+}
+
+type ValidateRowsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Rows     int32         `protobuf:"varint,1,opt,name=rows,proto3" json:"rows,omitempty"`
+	Accepted int32         `protobuf:"varint,2,opt,name=accepted,proto3" json:"accepted,omitempty"`
+	Rejected int32         `protobuf:"varint,3,opt,name=rejected,proto3" json:"rejected,omitempty"`
+	Warnings int32         `protobuf:"varint,4,opt,name=warnings,proto3" json:"warnings,omitempty"`
+	Problems []*RowProblem `protobuf:"bytes,5,rep,name=problems,proto3" json:"problems,omitempty"`
+}
+
+func (x *ValidateRowsResponse) Reset() {
+	*x = ValidateRowsResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_hcp_hcppb_hcp_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ValidateRowsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ValidateRowsResponse) ProtoMessage() {}
+
+func (x *ValidateRowsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_hcp_hcppb_hcp_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ValidateRowsResponse.ProtoReflect.Descriptor instead.
+func (*ValidateRowsResponse) Descriptor() ([]byte, []int) {
+	return file_hcp_hcppb_hcp_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ValidateRowsResponse) GetRows() int32 {
+	if x != nil {
+		return x.Rows
+	}
+	return 0
+}
+
+func (x *ValidateRowsResponse) GetAccepted() int32 {
+	if x != nil {
+		return x.Accepted
+	}
+	return 0
+}
+
+func (x *ValidateRowsResponse) GetRejected() int32 {
+	if x != nil {
+		return x.Rejected
+	}
+	return 0
+}
+
+func (x *ValidateRowsResponse) GetWarnings() int32 {
+	if x != nil {
+		return x.Warnings
+	}
+	return 0
+}
+
+func (x *ValidateRowsResponse) GetProblems() []*RowProblem {
+	if x != nil {
+		return x.Problems
+	}
+	return nil
+}
+
+type RowProblem struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Row      int32  `protobuf:"varint,1,opt,name=row,proto3" json:"row,omitempty"`           // Counting from 1, as the rows were sent
+	Field    string `protobuf:"bytes,2,opt,name=field,proto3" json:"field,omitempty"`        // Such as "price"
+	Text     string `protobuf:"bytes,3,opt,name=text,proto3" json:"text,omitempty"`          // The text of that field
+	Message  string `protobuf:"bytes,4,opt,name=message,proto3" json:"message,omitempty"`    // What was wrong with it
+	Rejected bool   `protobuf:"varint,5,opt,name=rejected,proto3" json:"rejected,omitempty"` // True if the row would be dropped; otherwise it would be used despite the problem
+}
+
+func (x *RowProblem) Reset() {
+	*x = RowProblem{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_hcp_hcppb_hcp_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RowProblem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RowProblem) ProtoMessage() {}
+
+func (x *RowProblem) ProtoReflect() protoreflect.Message {
+	mi := &file_hcp_hcppb_hcp_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RowProblem.ProtoReflect.Descriptor instead.
+func (*RowProblem) Descriptor() ([]byte, []int) {
+	return file_hcp_hcppb_hcp_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *RowProblem) GetRow() int32 {
+	if x != nil {
+		return x.Row
+	}
+	return 0
+}
+
+func (x *RowProblem) GetField() string {
+	if x != nil {
+		return x.Field
+	}
+	return ""
+}
+
+func (x *RowProblem) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *RowProblem) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *RowProblem) GetRejected() bool {
+	if x != nil {
+		return x.Rejected
+	}
+	return false
+}
+
+var File_hcp_hcppb_hcp_proto protoreflect.FileDescriptor
+
+var file_hcp_hcppb_hcp_proto_rawDesc = []byte{
+	0x0a, 0x13, 0x68, 0x63, 0x70, 0x2f, 0x68, 0x63, 0x70, 0x70, 0x62, 0x2f, 0x68, 0x63, 0x70, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x06, 0x68, 0x63, 0x70, 0x2e, 0x76, 0x31, 0x22, 0x14, 0x0a,
+	0x12, 0x4c, 0x69, 0x73, 0x74, 0x53, 0x79, 0x73, 0x74, 0x65, 0x6d, 0x73, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x22, 0x2f, 0x0a, 0x13, 0x4c, 0x69, 0x73, 0x74, 0x53, 0x79, 0x73, 0x74, 0x65,
+	0x6d, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x79,
+	0x73, 0x74, 0x65, 0x6d, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x07, 0x73, 0x79, 0x73,
+	0x74, 0x65, 0x6d, 0x73, 0x22, 0x30, 0x0a, 0x16, 0x50, 0x72, 0x69, 0x63, 0x65, 0x73, 0x46, 0x6f,
+	0x72, 0x53, 0x79, 0x73, 0x74, 0x65, 0x6d, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x16,
+	0x0a, 0x06, 0x73, 0x79, 0x73, 0x74, 0x65, 0x6d, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06,
+	0x73, 0x79, 0x73, 0x74, 0x65, 0x6d, 0x22, 0x47, 0x0a, 0x17, 0x50, 0x72, 0x69, 0x63, 0x65, 0x73,
+	0x46, 0x6f, 0x72, 0x53, 0x79, 0x73, 0x74, 0x65, 0x6d, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x12, 0x2c, 0x0a, 0x06, 0x70, 0x72, 0x69, 0x63, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x14, 0x2e, 0x68, 0x63, 0x70, 0x2e, 0x76, 0x31, 0x2e, 0x51, 0x75, 0x61, 0x72, 0x74,
+	0x65, 0x72, 0x50, 0x72, 0x69, 0x63, 0x65, 0x52, 0x06, 0x70, 0x72, 0x69, 0x63, 0x65, 0x73, 0x22,
+	0x75, 0x0a, 0x0c, 0x51, 0x75, 0x61, 0x72, 0x74, 0x65, 0x72, 0x50, 0x72, 0x69, 0x63, 0x65, 0x12,
+	0x12, 0x0a, 0x04, 0x79, 0x65, 0x61, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x79,
+	0x65, 0x61, 0x72, 0x12, 0x18, 0x0a, 0x07, 0x71, 0x75, 0x61, 0x72, 0x74, 0x65, 0x72, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x05, 0x52, 0x07, 0x71, 0x75, 0x61, 0x72, 0x74, 0x65, 0x72, 0x12, 0x14, 0x0a,
+	0x05, 0x70, 0x65, 0x6e, 0x63, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x70, 0x65,
+	0x6e, 0x63, 0x65, 0x12, 0x21, 0x0a, 0x0c, 0x61, 0x64, 0x76, 0x65, 0x72, 0x74, 0x5f, 0x63, 0x6f,
+	0x75, 0x6e, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0b, 0x61, 0x64, 0x76, 0x65, 0x72,
+	0x74, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x22, 0x47, 0x0a, 0x17, 0x50, 0x72, 0x69, 0x63, 0x65, 0x73,
+	0x46, 0x6f, 0x72, 0x51, 0x75, 0x61, 0x72, 0x74, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x12, 0x12, 0x0a, 0x04, 0x79, 0x65, 0x61, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52,
+	0x04, 0x79, 0x65, 0x61, 0x72, 0x12, 0x18, 0x0a, 0x07, 0x71, 0x75, 0x61, 0x72, 0x74, 0x65, 0x72,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x07, 0x71, 0x75, 0x61, 0x72, 0x74, 0x65, 0x72, 0x22,
+	0x47, 0x0a, 0x18, 0x50, 0x72, 0x69, 0x63, 0x65, 0x73, 0x46, 0x6f, 0x72, 0x51, 0x75, 0x61, 0x72,
+	0x74, 0x65, 0x72, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x2b, 0x0a, 0x06, 0x70,
+	0x72, 0x69, 0x63, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x68, 0x63,
+	0x70, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x79, 0x73, 0x74, 0x65, 0x6d, 0x50, 0x72, 0x69, 0x63, 0x65,
+	0x52, 0x06, 0x70, 0x72, 0x69, 0x63, 0x65, 0x73, 0x22, 0x5e, 0x0a, 0x0b, 0x53, 0x79, 0x73, 0x74,
+	0x65, 0x6d, 0x50, 0x72, 0x69, 0x63, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x79, 0x73, 0x74, 0x65,
+	0x6d, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x79, 0x73, 0x74, 0x65, 0x6d, 0x12,
+	0x14, 0x0a, 0x05, 0x70, 0x65, 0x6e, 0x63, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05,
+	0x70, 0x65, 0x6e, 0x63, 0x65, 0x12, 0x21, 0x0a, 0x0c, 0x61, 0x64, 0x76, 0x65, 0x72, 0x74, 0x5f,
+	0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0b, 0x61, 0x64, 0x76,
+	0x65, 0x72, 0x74, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x22, 0x27, 0x0a, 0x13, 0x56, 0x61, 0x6c, 0x69,
+	0x64, 0x61, 0x74, 0x65, 0x52, 0x6f, 0x77, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12,
+	0x10, 0x0a, 0x03, 0x63, 0x73, 0x76, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x63, 0x73,
+	0x76, 0x22, 0xae, 0x01, 0x0a, 0x14, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x65, 0x52, 0x6f,
+	0x77, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x72, 0x6f,
+	0x77, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x72, 0x6f, 0x77, 0x73, 0x12, 0x1a,
+	0x0a, 0x08, 0x61, 0x63, 0x63, 0x65, 0x70, 0x74, 0x65, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05,
+	0x52, 0x08, 0x61, 0x63, 0x63, 0x65, 0x70, 0x74, 0x65, 0x64, 0x12, 0x1a, 0x0a, 0x08, 0x72, 0x65,
+	0x6a, 0x65, 0x63, 0x74, 0x65, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x72, 0x65,
+	0x6a, 0x65, 0x63, 0x74, 0x65, 0x64, 0x12, 0x1a, 0x0a, 0x08, 0x77, 0x61, 0x72, 0x6e, 0x69, 0x6e,
+	0x67, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x77, 0x61, 0x72, 0x6e, 0x69, 0x6e,
+	0x67, 0x73, 0x12, 0x2e, 0x0a, 0x08, 0x70, 0x72, 0x6f, 0x62, 0x6c, 0x65, 0x6d, 0x73, 0x18, 0x05,
+	0x20, 0x03, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x68, 0x63, 0x70, 0x2e, 0x76, 0x31, 0x2e, 0x52, 0x6f,
+	0x77, 0x50, 0x72, 0x6f, 0x62, 0x6c, 0x65, 0x6d, 0x52, 0x08, 0x70, 0x72, 0x6f, 0x62, 0x6c, 0x65,
+	0x6d, 0x73, 0x22, 0x7e, 0x0a, 0x0a, 0x52, 0x6f, 0x77, 0x50, 0x72, 0x6f, 0x62, 0x6c, 0x65, 0x6d,
+	0x12, 0x10, 0x0a, 0x03, 0x72, 0x6f, 0x77, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x03, 0x72,
+	0x6f, 0x77, 0x12, 0x14, 0x0a, 0x05, 0x66, 0x69, 0x65, 0x6c, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x05, 0x66, 0x69, 0x65, 0x6c, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x65, 0x78, 0x74,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x65, 0x78, 0x74, 0x12, 0x18, 0x0a, 0x07,
+	0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x6d,
+	0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x72, 0x65, 0x6a, 0x65, 0x63, 0x74,
+	0x65, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x72, 0x65, 0x6a, 0x65, 0x63, 0x74,
+	0x65, 0x64, 0x32, 0xc6, 0x02, 0x0a, 0x06, 0x50, 0x72, 0x69, 0x63, 0x65, 0x73, 0x12, 0x46, 0x0a,
+	0x0b, 0x4c, 0x69, 0x73, 0x74, 0x53, 0x79, 0x73, 0x74, 0x65, 0x6d, 0x73, 0x12, 0x1a, 0x2e, 0x68,
+	0x63, 0x70, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x53, 0x79, 0x73, 0x74, 0x65, 0x6d,
+	0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1b, 0x2e, 0x68, 0x63, 0x70, 0x2e, 0x76,
+	0x31, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x53, 0x79, 0x73, 0x74, 0x65, 0x6d, 0x73, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x52, 0x0a, 0x0f, 0x50, 0x72, 0x69, 0x63, 0x65, 0x73, 0x46,
+	0x6f, 0x72, 0x53, 0x79, 0x73, 0x74, 0x65, 0x6d, 0x12, 0x1e, 0x2e, 0x68, 0x63, 0x70, 0x2e, 0x76,
+	0x31, 0x2e, 0x50, 0x72, 0x69, 0x63, 0x65, 0x73, 0x46, 0x6f, 0x72, 0x53, 0x79, 0x73, 0x74, 0x65,
+	0x6d, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1f, 0x2e, 0x68, 0x63, 0x70, 0x2e, 0x76,
+	0x31, 0x2e, 0x50, 0x72, 0x69, 0x63, 0x65, 0x73, 0x46, 0x6f, 0x72, 0x53, 0x79, 0x73, 0x74, 0x65,
+	0x6d, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x55, 0x0a, 0x10, 0x50, 0x72, 0x69,
+	0x63, 0x65, 0x73, 0x46, 0x6f, 0x72, 0x51, 0x75, 0x61, 0x72, 0x74, 0x65, 0x72, 0x12, 0x1f, 0x2e,
+	0x68, 0x63, 0x70, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x72, 0x69, 0x63, 0x65, 0x73, 0x46, 0x6f, 0x72,
+	0x51, 0x75, 0x61, 0x72, 0x74, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x20,
+	0x2e, 0x68, 0x63, 0x70, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x72, 0x69, 0x63, 0x65, 0x73, 0x46, 0x6f,
+	0x72, 0x51, 0x75, 0x61, 0x72, 0x74, 0x65, 0x72, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x12, 0x49, 0x0a, 0x0c, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x65, 0x52, 0x6f, 0x77, 0x73,
+	0x12, 0x1b, 0x2e, 0x68, 0x63, 0x70, 0x2e, 0x76, 0x31, 0x2e, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x61,
+	0x74, 0x65, 0x52, 0x6f, 0x77, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1c, 0x2e,
+	0x68, 0x63, 0x70, 0x2e, 0x76, 0x31, 0x2e, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x65, 0x52,
+	0x6f, 0x77, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x42, 0x3a, 0x5a, 0x38, 0x67,
+	0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x41, 0x6e, 0x74, 0x6f, 0x6e, 0x69,
+	0x6f, 0x43, 0x61, 0x72, 0x6c, 0x69, 0x6e, 0x69, 0x2f, 0x68, 0x6f, 0x6d, 0x65, 0x2d, 0x63, 0x6f,
+	0x6d, 0x70, 0x75, 0x74, 0x65, 0x72, 0x2d, 0x70, 0x72, 0x69, 0x63, 0x65, 0x73, 0x2f, 0x68, 0x63,
+	0x70, 0x2f, 0x68, 0x63, 0x70, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_hcp_hcppb_hcp_proto_rawDescOnce sync.Once
+	file_hcp_hcppb_hcp_proto_rawDescData = file_hcp_hcppb_hcp_proto_rawDesc
+)
+
+func file_hcp_hcppb_hcp_proto_rawDescGZIP() []byte {
+	file_hcp_hcppb_hcp_proto_rawDescOnce.Do(func() {
+		file_hcp_hcppb_hcp_proto_rawDescData = protoimpl.X.CompressGZIP(file_hcp_hcppb_hcp_proto_rawDescData)
+	})
+	return file_hcp_hcppb_hcp_proto_rawDescData
+}
+
+var file_hcp_hcppb_hcp_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_hcp_hcppb_hcp_proto_goTypes = []any{
+	(*ListSystemsRequest)(nil),       // 0: hcp.v1.ListSystemsRequest
+	(*ListSystemsResponse)(nil),      // 1: hcp.v1.ListSystemsResponse
+	(*PricesForSystemRequest)(nil),   // 2: hcp.v1.PricesForSystemRequest
+	(*PricesForSystemResponse)(nil),  // 3: hcp.v1.PricesForSystemResponse
+	(*QuarterPrice)(nil),             // 4: hcp.v1.QuarterPrice
+	(*PricesForQuarterRequest)(nil),  // 5: hcp.v1.PricesForQuarterRequest
+	(*PricesForQuarterResponse)(nil), // 6: hcp.v1.PricesForQuarterResponse
+	(*SystemPrice)(nil),              // 7: hcp.v1.SystemPrice
+	(*ValidateRowsRequest)(nil),      // 8: hcp.v1.ValidateRowsRequest
+	(*ValidateRowsResponse)(nil),     // 9: hcp.v1.ValidateRowsResponse
+	(*RowProblem)(nil),               // 10: hcp.v1.RowProblem
+}
+var file_hcp_hcppb_hcp_proto_depIdxs = []int32{
+	4,  // 0: hcp.v1.PricesForSystemResponse.prices:type_name -> hcp.v1.QuarterPrice
+	7,  // 1: hcp.v1.PricesForQuarterResponse.prices:type_name -> hcp.v1.SystemPrice
+	10, // 2: hcp.v1.ValidateRowsResponse.problems:type_name -> hcp.v1.RowProblem
+	0,  // 3: hcp.v1.Prices.ListSystems:input_type -> hcp.v1.ListSystemsRequest
+	2,  // 4: hcp.v1.Prices.PricesForSystem:input_type -> hcp.v1.PricesForSystemRequest
+	5,  // 5: hcp.v1.Prices.PricesForQuarter:input_type -> hcp.v1.PricesForQuarterRequest
+	8,  // 6: hcp.v1.Prices.ValidateRows:input_type -> hcp.v1.ValidateRowsRequest
+	1,  // 7: hcp.v1.Prices.ListSystems:output_type -> hcp.v1.ListSystemsResponse
+	3,  // 8: hcp.v1.Prices.PricesForSystem:output_type -> hcp.v1.PricesForSystemResponse
+	6,  // 9: hcp.v1.Prices.PricesForQuarter:output_type -> hcp.v1.PricesForQuarterResponse
+	9,  // 10: hcp.v1.Prices.ValidateRows:output_type -> hcp.v1.ValidateRowsResponse
+	7,  // [7:11] is the sub-list for method output_type
+	3,  // [3:7] is the sub-list for method input_type
+	3,  // [3:3] is the sub-list for extension type_name
+	3,  // [3:3] is the sub-list for extension extendee
+	0,  // [0:3] is the sub-list for field type_name
+}
+
+func init() { file_hcp_hcppb_hcp_proto_init() }
+func file_hcp_hcppb_hcp_proto_init() {
+	if File_hcp_hcppb_hcp_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_hcp_hcppb_hcp_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*ListSystemsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_hcp_hcppb_hcp_proto_msgTypes[1].Exporter = func(v any, i int) any {
+			switch v := v.(*ListSystemsResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_hcp_hcppb_hcp_proto_msgTypes[2].Exporter = func(v any, i int) any {
+			switch v := v.(*PricesForSystemRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_hcp_hcppb_hcp_proto_msgTypes[3].Exporter = func(v any, i int) any {
+			switch v := v.(*PricesForSystemResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_hcp_hcppb_hcp_proto_msgTypes[4].Exporter = func(v any, i int) any {
+			switch v := v.(*QuarterPrice); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_hcp_hcppb_hcp_proto_msgTypes[5].Exporter = func(v any, i int) any {
+			switch v := v.(*PricesForQuarterRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_hcp_hcppb_hcp_proto_msgTypes[6].Exporter = func(v any, i int) any {
+			switch v := v.(*PricesForQuarterResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_hcp_hcppb_hcp_proto_msgTypes[7].Exporter = func(v any, i int) any {
+			switch v := v.(*SystemPrice); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_hcp_hcppb_hcp_proto_msgTypes[8].Exporter = func(v any, i int) any {
+			switch v := v.(*ValidateRowsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_hcp_hcppb_hcp_proto_msgTypes[9].Exporter = func(v any, i int) any {
+			switch v := v.(*ValidateRowsResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_hcp_hcppb_hcp_proto_msgTypes[10].Exporter = func(v any, i int) any {
+			switch v := v.(*RowProblem); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_hcp_hcppb_hcp_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   11,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_hcp_hcppb_hcp_proto_goTypes,
+		DependencyIndexes: file_hcp_hcppb_hcp_proto_depIdxs,
+		MessageInfos:      file_hcp_hcppb_hcp_proto_msgTypes,
+	}.Build()
+	File_hcp_hcppb_hcp_proto = out.File
+	file_hcp_hcppb_hcp_proto_rawDesc = nil
+	file_hcp_hcppb_hcp_proto_goTypes = nil
+	file_hcp_hcppb_hcp_proto_depIdxs = nil
+}
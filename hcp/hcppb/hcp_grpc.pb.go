@@ -0,0 +1,254 @@
+// The gRPC interface to the home computer price data, served by "hcp-to-wiki grpc-serve".
+//
+// Regenerate the Go code after changing this file with:
+//
+//   protoc --go_out=. --go_opt=paths=source_relative \
+//          --go-grpc_out=. --go-grpc_opt=paths=source_relative hcp/hcppb/hcp.proto
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v4.25.3
+// source: hcp/hcppb/hcp.proto
+
+package hcppb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Prices_ListSystems_FullMethodName      = "/hcp.v1.Prices/ListSystems"
+	Prices_PricesForSystem_FullMethodName  = "/hcp.v1.Prices/PricesForSystem"
+	Prices_PricesForQuarter_FullMethodName = "/hcp.v1.Prices/PricesForQuarter"
+	Prices_ValidateRows_FullMethodName     = "/hcp.v1.Prices/ValidateRows"
+)
+
+// PricesClient is the client API for Prices service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Queries against the published prices, and validation of rows before they are added to the data
+type PricesClient interface {
+	// List the published systems in alphabetical order
+	ListSystems(ctx context.Context, in *ListSystemsRequest, opts ...grpc.CallOption) (*ListSystemsResponse, error)
+	// The published prices for one system, oldest first
+	PricesForSystem(ctx context.Context, in *PricesForSystemRequest, opts ...grpc.CallOption) (*PricesForSystemResponse, error)
+	// The published prices for every system in one quarter
+	PricesForQuarter(ctx context.Context, in *PricesForQuarterRequest, opts ...grpc.CallOption) (*PricesForQuarterResponse, error)
+	// Check candidate CSV rows with the same validation as the command line
+	ValidateRows(ctx context.Context, in *ValidateRowsRequest, opts ...grpc.CallOption) (*ValidateRowsResponse, error)
+}
+
+type pricesClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewPricesClient(cc grpc.ClientConnInterface) PricesClient {
+	return &pricesClient{cc}
+}
+
+func (c *pricesClient) ListSystems(ctx context.Context, in *ListSystemsRequest, opts ...grpc.CallOption) (*ListSystemsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListSystemsResponse)
+	err := c.cc.Invoke(ctx, Prices_ListSystems_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pricesClient) PricesForSystem(ctx context.Context, in *PricesForSystemRequest, opts ...grpc.CallOption) (*PricesForSystemResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PricesForSystemResponse)
+	err := c.cc.Invoke(ctx, Prices_PricesForSystem_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pricesClient) PricesForQuarter(ctx context.Context, in *PricesForQuarterRequest, opts ...grpc.CallOption) (*PricesForQuarterResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PricesForQuarterResponse)
+	err := c.cc.Invoke(ctx, Prices_PricesForQuarter_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pricesClient) ValidateRows(ctx context.Context, in *ValidateRowsRequest, opts ...grpc.CallOption) (*ValidateRowsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ValidateRowsResponse)
+	err := c.cc.Invoke(ctx, Prices_ValidateRows_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PricesServer is the server API for Prices service.
+// All implementations must embed UnimplementedPricesServer
+// for forward compatibility.
+//
+// Queries against the published prices, and validation of rows before they are added to the data
+type PricesServer interface {
+	// List the published systems in alphabetical order
+	ListSystems(context.Context, *ListSystemsRequest) (*ListSystemsResponse, error)
+	// The published prices for one system, oldest first
+	PricesForSystem(context.Context, *PricesForSystemRequest) (*PricesForSystemResponse, error)
+	// The published prices for every system in one quarter
+	PricesForQuarter(context.Context, *PricesForQuarterRequest) (*PricesForQuarterResponse, error)
+	// Check candidate CSV rows with the same validation as the command line
+	ValidateRows(context.Context, *ValidateRowsRequest) (*ValidateRowsResponse, error)
+	mustEmbedUnimplementedPricesServer()
+}
+
+// UnimplementedPricesServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedPricesServer struct{}
+
+func (UnimplementedPricesServer) ListSystems(context.Context, *ListSystemsRequest) (*ListSystemsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSystems not implemented")
+}
+func (UnimplementedPricesServer) PricesForSystem(context.Context, *PricesForSystemRequest) (*PricesForSystemResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PricesForSystem not implemented")
+}
+func (UnimplementedPricesServer) PricesForQuarter(context.Context, *PricesForQuarterRequest) (*PricesForQuarterResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PricesForQuarter not implemented")
+}
+func (UnimplementedPricesServer) ValidateRows(context.Context, *ValidateRowsRequest) (*ValidateRowsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ValidateRows not implemented")
+}
+func (UnimplementedPricesServer) mustEmbedUnimplementedPricesServer() {}
+func (UnimplementedPricesServer) testEmbeddedByValue()                {}
+
+// UnsafePricesServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to PricesServer will
+// result in compilation errors.
+type UnsafePricesServer interface {
+	mustEmbedUnimplementedPricesServer()
+}
+
+func RegisterPricesServer(s grpc.ServiceRegistrar, srv PricesServer) {
+	// If the following call pancis, it indicates UnimplementedPricesServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Prices_ServiceDesc, srv)
+}
+
+func _Prices_ListSystems_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListSystemsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PricesServer).ListSystems(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Prices_ListSystems_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PricesServer).ListSystems(ctx, req.(*ListSystemsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Prices_PricesForSystem_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PricesForSystemRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PricesServer).PricesForSystem(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Prices_PricesForSystem_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PricesServer).PricesForSystem(ctx, req.(*PricesForSystemRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Prices_PricesForQuarter_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PricesForQuarterRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PricesServer).PricesForQuarter(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Prices_PricesForQuarter_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PricesServer).PricesForQuarter(ctx, req.(*PricesForQuarterRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Prices_ValidateRows_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ValidateRowsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PricesServer).ValidateRows(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Prices_ValidateRows_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PricesServer).ValidateRows(ctx, req.(*ValidateRowsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Prices_ServiceDesc is the grpc.ServiceDesc for Prices service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Prices_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "hcp.v1.Prices",
+	HandlerType: (*PricesServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListSystems",
+			Handler:    _Prices_ListSystems_Handler,
+		},
+		{
+			MethodName: "PricesForSystem",
+			Handler:    _Prices_PricesForSystem_Handler,
+		},
+		{
+			MethodName: "PricesForQuarter",
+			Handler:    _Prices_PricesForQuarter_Handler,
+		},
+		{
+			MethodName: "ValidateRows",
+			Handler:    _Prices_ValidateRows_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "hcp/hcppb/hcp.proto",
+}
@@ -0,0 +1,51 @@
+package hcp
+
+import (
+	"fmt"
+	"strings"
+)
+
+// An Issue is one issue of a magazine, such as "Your Computer 1983-11", by which rows can be picked out of the data
+type Issue struct {
+	Magazine string
+	Year     int
+	Month    int
+}
+
+// ParseIssue reads an issue written as the magazine's title then the issue's YYYY-MM date, such as "PCW 1982-06"
+func ParseIssue(text string) (Issue, error) {
+	text = strings.TrimSpace(text)
+	split := strings.LastIndex(text, " ")
+	if split < 0 {
+		return Issue{}, fmt.Errorf("bad issue [%s] (expected a magazine then YYYY-MM, such as \"PCW 1982-06\")", text)
+	}
+	year, month, err := handle_yyyy_mm(text[split+1:])
+	if err != nil {
+		return Issue{}, fmt.Errorf("bad issue [%s] (%w)", text, err)
+	}
+	return Issue{strings.TrimSpace(text[:split]), year, month}, nil
+}
+
+// String returns the issue as ParseIssue reads it
+func (issue Issue) String() string {
+	return fmt.Sprintf("%s %04d-%02d", issue.Magazine, issue.Year, issue.Month)
+}
+
+// Has returns true if the advert is from the issue
+func (issue Issue) Has(advert Advert) bool {
+	return (advert.Magazine == issue.Magazine) && (advert.Year == issue.Year) && (advert.Month == issue.Month)
+}
+
+// HasRow returns true if a data row of the CSV data is from the issue, whether or not the row is otherwise valid.
+// A date written almost correctly, such as "82-06", is read as it would be when the row is parsed.
+func (issue Issue) HasRow(row []string) bool {
+	if (len(row) <= adv_yyyy_mm) || (strings.TrimSpace(row[adv_magazine]) != issue.Magazine) {
+		return false
+	}
+	date := strings.TrimSpace(row[adv_yyyy_mm])
+	if normalised, ok := normalise_yyyy_mm(date); ok {
+		date = normalised
+	}
+	year, month, err := handle_yyyy_mm(date)
+	return (err == nil) && (year == issue.Year) && (month == issue.Month)
+}
@@ -0,0 +1,93 @@
+package hcp
+
+import "sort"
+
+// A single mistyped year, such as 2019 for 1982, would otherwise stretch the range of the data out to that year,
+// and with it every system's prices and every table. So once all the data has been parsed, its adverts' dates are
+// split wherever outlier_gap_years or more pass without any advert; the run of dates holding the most adverts is the
+// bulk of the data, and the rows dated outside it are rejected, with a message saying why, for the data to be corrected.
+
+// The gap in the dates of the adverts, in years, that separates the bulk of the data from outliers
+const outlier_gap_years = 10
+
+// A span of quarters, as date-indices, from first to last inclusive
+type dateSpan struct {
+	first int
+	last  int
+}
+
+// Return true if every month of the year is at least outlier_gap_years from the span, and so an outlier of it.
+// Dates between the span and the gap, which no accepted advert has, are rows rejected for some other reason.
+func (span dateSpan) farFrom(year int, months []int) bool {
+	for _, month := range months {
+		index := BuildIndexFromAdvert(Advert{Year: year, Month: month})
+		if (index > span.first-outlier_gap_years*4) && (index < span.last+outlier_gap_years*4) {
+			return false
+		}
+	}
+	return true
+}
+
+// The results of parsing one CSV file
+type parsedFile struct {
+	file       csvFile
+	adverts    []Advert
+	minDate    int
+	maxDate    int
+	validation FileValidation
+}
+
+// Parse one CSV file (see parseData)
+func parseFile(file csvFile, config Configuration, issue *Issue) parsedFile {
+	adverts, minDate, maxDate, validation := parseData(file.name, file.rows, config, issue, nil)
+	return parsedFile{file, adverts, minDate, maxDate, validation}
+}
+
+// Given the adverts of all the data, return the span of the bulk of their dates.
+// ok is false if every advert is within the bulk, so that there are no outliers.
+func bulkDateSpan(adverts []Advert) (span dateSpan, ok bool) {
+	counts := make(map[int]int)
+	for _, advert := range adverts {
+		counts[BuildIndexFromAdvert(advert)]++
+	}
+	indices := make([]int, 0, len(counts))
+	for index := range counts {
+		indices = append(indices, index)
+	}
+	sort.Ints(indices)
+
+	best, bestCount := dateSpan{}, 0
+	start, count := 0, 0
+	for i, index := range indices {
+		if (i > 0) && (index-indices[i-1] >= outlier_gap_years*4) {
+			start, count = i, 0
+		}
+		count += counts[index]
+		if count > bestCount {
+			best, bestCount = dateSpan{indices[start], index}, count
+		}
+	}
+	return best, (bestCount > 0) && (bestCount < len(adverts))
+}
+
+// Given every file as parsed, parse again those holding adverts outside the bulk of the data, rejecting those rows
+func rejectDateOutliers(parsed []parsedFile, config Configuration, issue *Issue) []parsedFile {
+	all := make([]Advert, 0)
+	for _, result := range parsed {
+		all = append(all, result.adverts...)
+	}
+	span, ok := bulkDateSpan(all)
+	if !ok {
+		return parsed
+	}
+	for i, result := range parsed {
+		for _, advert := range result.adverts {
+			if span.farFrom(advert.Year, []int{advert.Month}) {
+				adverts, minDate, maxDate, validation := parseData(result.file.name, result.file.rows, config, issue, &span)
+				parsed[i] = parsedFile{result.file, adverts, minDate, maxDate, validation}
+				break
+			}
+		}
+	}
+	return parsed
+}
@@ -0,0 +1,50 @@
+package hcp
+
+import "testing"
+
+func TestBulkDateSpan(t *testing.T) {
+	advert := func(year int, month int) Advert {
+		return Advert{Year: year, Month: month}
+	}
+	tests := []struct {
+		name    string
+		adverts []Advert
+		span    dateSpan
+		ok      bool
+	}{
+		{"no adverts", nil, dateSpan{}, false},
+		{"no outliers", []Advert{advert(1981, 1), advert(1983, 6), advert(1985, 12)}, dateSpan{}, false},
+		{"mistyped year", []Advert{advert(1981, 1), advert(1982, 4), advert(1983, 7), advert(2019, 1)},
+			dateSpan{BuildIndexFromYearAndQuarter(1981, 1), BuildIndexFromYearAndQuarter(1983, 3)}, true},
+		{"gap shorter than outlier_gap_years", []Advert{advert(1981, 1), advert(1990, 12)}, dateSpan{}, false},
+		{"bulk after outliers", []Advert{advert(1950, 1), advert(1981, 1), advert(1981, 2), advert(1982, 1)},
+			dateSpan{BuildIndexFromYearAndQuarter(1981, 1), BuildIndexFromYearAndQuarter(1982, 1)}, true},
+	}
+	for _, test := range tests {
+		span, ok := bulkDateSpan(test.adverts)
+		if (ok != test.ok) || (ok && (span != test.span)) {
+			t.Errorf("%s: bulkDateSpan() = %v, %t; want %v, %t", test.name, span, ok, test.span, test.ok)
+		}
+	}
+}
+
+func TestFarFrom(t *testing.T) {
+	span := dateSpan{BuildIndexFromYearAndQuarter(1981, 1), BuildIndexFromYearAndQuarter(1985, 4)}
+	tests := []struct {
+		year   int
+		months []int
+		far    bool
+	}{
+		{1983, []int{6}, false},
+		{1995, []int{7}, false},
+		{1995, []int{10}, true},
+		{1971, []int{1}, true},
+		{1971, []int{4}, false},
+		{2019, []int{1, 4, 7, 10}, true},
+	}
+	for _, test := range tests {
+		if far := span.farFrom(test.year, test.months); far != test.far {
+			t.Errorf("farFrom(%d, %v) = %t; want %t", test.year, test.months, far, test.far)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package hcp
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Before decimalisation on 15th February 1971 a pound was 20 shillings and a shilling 12 pence, so a price such as
+// "£399 19s 6d" (or "£399/19/6") is converted into decimal pence, rounded to the nearest new penny.
+// Some goods, professional equipment in particular, were priced in guineas of 21 shillings ("5 gns" is £5.25).
+
+// Old pence in a pound and in a shilling
+const lsd_pence_per_pound = 240
+const lsd_pence_per_shilling = 12
+
+// Decimal pence in a guinea
+const guinea_pence = 105
+
+// Matches "£399 19s 6d", "£399 19s. 6d.", "19s 6d", "£5 5s", "6½d" and the like; the shillings or pence must be present,
+// and the pounds must be followed by a space, as "£12s" could be £1 2s or 12 shillings
+var lsdPattern = regexp.MustCompile(`^(?:(?:£|&pound;)\s*(\d[\d,]*)(?:\s+|$))?(?:(\d+)\s*s\.?)?\s*(?:(\d+)(½)?\s*d\.?)?$`)
+
+// Matches "£399/19/6", "£4/10/-" and "19/6", where "-" means none
+var lsdSlashPattern = regexp.MustCompile(`^(?:(?:£|&pound;)\s*(\d[\d,]*)\s*/\s*)?(\d+|-)\s*/\s*(\d+|-)$`)
+
+// Matches "5 gns", "5 guineas" and the like
+var guineaPattern = regexp.MustCompile(`(?i)^(\d[\d,]*)\s*(?:gns|gn|guineas|guinea)\.?$`)
+
+// Given a price, return it in decimal pence if it is written in pounds, shillings and pence or in guineas.
+// The second result is false if the price is not written in either way; the error reports an impossible amount,
+// such as 25 shillings.
+func parse_predecimal(price_text string) (pence int, ok bool, err error) {
+	if match := guineaPattern.FindStringSubmatch(price_text); match != nil {
+		guineas, err := strconv.Atoi(strings.ReplaceAll(match[1], ",", ""))
+		if err != nil {
+			return 0, true, err
+		}
+		return guineas * guinea_pence, true, nil
+	}
+
+	var pounds_text, shillings_text, pence_text string
+	halfpenny := false
+	if match := lsdSlashPattern.FindStringSubmatch(price_text); match != nil {
+		pounds_text, shillings_text, pence_text = match[1], strings.Trim(match[2], "-"), strings.Trim(match[3], "-")
+	} else if match := lsdPattern.FindStringSubmatch(price_text); (match != nil) && ((match[2] != "") || (match[3] != "")) {
+		pounds_text, shillings_text, pence_text, halfpenny = match[1], match[2], match[3], match[4] != ""
+	} else {
+		return 0, false, nil
+	}
+
+	amount := func(text string) (int, error) {
+		if text == "" {
+			return 0, nil
+		}
+		return strconv.Atoi(strings.ReplaceAll(text, ",", ""))
+	}
+	pounds, err := amount(pounds_text)
+	if err != nil {
+		return 0, true, err
+	}
+	shillings, err := amount(shillings_text)
+	if err != nil {
+		return 0, true, err
+	}
+	oldPence, err := amount(pence_text)
+	if err != nil {
+		return 0, true, err
+	}
+	if shillings >= lsd_pence_per_pound/lsd_pence_per_shilling {
+		return 0, true, fmt.Errorf("bad shillings [%d]", shillings)
+	}
+	if oldPence >= lsd_pence_per_shilling {
+		return 0, true, fmt.Errorf("bad old pence [%d]", oldPence)
+	}
+
+	// Count in halfpennies so that "½d" is exact, then round to the nearest new penny
+	halfpennies := 2 * (pounds*lsd_pence_per_pound + shillings*lsd_pence_per_shilling + oldPence)
+	if halfpenny {
+		halfpennies++
+	}
+	return (halfpennies*100 + lsd_pence_per_pound) / (2 * lsd_pence_per_pound), true, nil
+}
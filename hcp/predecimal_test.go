@@ -0,0 +1,38 @@
+package hcp
+
+import "testing"
+
+func TestParsePredecimal(t *testing.T) {
+	tests := []struct {
+		price string
+		pence int
+		ok    bool
+		err   bool
+	}{
+		{"£399 19s 6d", 39998, true, false},
+		{"£399 19s. 6d.", 39998, true, false},
+		{"&pound;399 19s 6d", 39998, true, false},
+		{"£1 2s", 110, true, false},
+		{"£5 5s", 525, true, false},
+		{"19s 6d", 98, true, false},
+		{"6½d", 3, true, false},
+		{"£399/19/6", 39998, true, false},
+		{"£4/10/-", 450, true, false},
+		{"19/6", 98, true, false},
+		{"5 gns", 525, true, false},
+		{"5 Guineas", 525, true, false},
+		{"£1 25s", 0, true, true},
+		{"£1 2s 12d", 0, true, true},
+		{"£12s", 0, false, false}, // £1 2s or 12 shillings: neither is assumed
+		{"£5", 0, false, false},
+		{"£99.95", 0, false, false},
+	}
+	for _, test := range tests {
+		pence, ok, err := parse_predecimal(test.price)
+		if (ok != test.ok) || ((err != nil) != test.err) {
+			t.Errorf("parse_predecimal(%q) = %d, %t, %v; want ok %t, error %t", test.price, pence, ok, err, test.ok, test.err)
+		} else if ok && !test.err && (pence != test.pence) {
+			t.Errorf("parse_predecimal(%q) = %d pence; want %d", test.price, pence, test.pence)
+		}
+	}
+}
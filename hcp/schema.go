@@ -0,0 +1,136 @@
+package hcp
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SchemaVersion is the version of the advert CSV format that this package reads and writes.
+// A file declares the version it follows with a comment row such as "# hcp-schema 2" (see SchemaMarker);
+// a file without one is taken to follow version 1, the format used before versions were declared.
+// A file declaring a version this package does not know is refused rather than read with the wrong idea of what its columns hold.
+// When the format changes, SchemaVersion is increased and files following the earlier versions are adapted as they are read.
+//
+// The versions are:
+//   - 1: the fixed columns, followed by any of the optional columns, in any order
+//   - 2: as 1, but every header names each of the schema_columns, so that whichever program wrote a file, its rows
+//     have the same columns; the fields of a column may still be empty
+//
+// MigrateRows upgrades a file to a later version.
+const SchemaVersion = 2
+
+// The start of the comment row declaring a file's schema version
+const schema_marker = "# hcp-schema"
+
+// The optional columns that every header names from schema version 2
+var schema_columns = []string{adv_region_header, adv_contributor_header, adv_street_date_header}
+
+// SchemaMarker returns the row declaring that a file follows SchemaVersion, for programs writing the CSV format
+func SchemaMarker() []string {
+	return schemaMarkerRow(SchemaVersion)
+}
+
+// SchemaHeader returns a header line of SchemaVersion, naming the fixed columns and then the schema_columns
+func SchemaHeader() []string {
+	return append([]string{"Source", "YYYY-MM", "Page", "System", "Price", "", "Kit", "Board"}, schema_columns...)
+}
+
+// Return the row declaring that a file follows the given schema version
+func schemaMarkerRow(version int) []string {
+	return []string{fmt.Sprintf("%s %d", schema_marker, version)}
+}
+
+// Given a row of CSV data, return the schema version it declares; ok is false if it is not a schema marker
+func schemaVersion(row []string) (version int, ok bool, err error) {
+	if (len(row) == 0) || !strings.HasPrefix(strings.TrimSpace(row[0]), schema_marker) {
+		return 0, false, nil
+	}
+	text := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(row[0]), schema_marker))
+	version, err = strconv.Atoi(text)
+	if err != nil {
+		return 0, true, fmt.Errorf("bad schema version [%s]", text)
+	}
+	if (version < 1) || (version > SchemaVersion) {
+		return version, true, fmt.Errorf("schema version %d is not one of versions 1 to %d, which this program reads; a newer release of it may be needed", version, SchemaVersion)
+	}
+	return version, true, nil
+}
+
+// Check the schema versions declared in a file's rows, as several files may have been concatenated into one,
+// and that each header line has the columns its version requires.
+// Return an error if any is not a version this package can read, or a header lacks a column.
+func checkSchema(file csvFile) error {
+	version := 1
+	for i, row := range file.rows {
+		declared, ok, err := schemaVersion(row)
+		if err != nil {
+			return fmt.Errorf("[%s] line %d: %w", file.name, i+1, err)
+		}
+		if ok {
+			version = declared
+			continue
+		}
+		if (version < 2) || !IsHeader(row) {
+			continue
+		}
+		for _, name := range schema_columns {
+			if columnIndex(row, name) < 0 {
+				return fmt.Errorf("[%s] line %d: the header has no [%s] column, which schema version %d requires", file.name, i+1, name, version)
+			}
+		}
+	}
+	return nil
+}
+
+// MigrateRows upgrades the rows of a CSV file to the given schema version, returning the upgraded rows.
+// Each header line gains the columns the version requires that it lacks, and each row of its block gains
+// their fields, holding the value given for the column in defaults, or else left empty.
+// Every schema marker is changed to declare the new version, and one is added at the start if there is none.
+// Comments and everything before the first header line are kept as they were.
+func MigrateRows(rows [][]string, to int, defaults map[string]string) ([][]string, error) {
+	if (to < 1) || (to > SchemaVersion) {
+		return nil, fmt.Errorf("there is no schema version %d", to)
+	}
+	migrated := make([][]string, 0, len(rows)+1)
+	marked := false
+	width, added := 0, []string(nil) // The width of the current block's header line, before its added columns, and their fields
+	for i, row := range rows {
+		declared, ok, err := schemaVersion(row)
+		switch {
+		case err != nil:
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		case ok && (declared > to):
+			return nil, fmt.Errorf("line %d: cannot migrate from schema version %d back to version %d", i+1, declared, to)
+		case ok:
+			migrated = append(migrated, schemaMarkerRow(to))
+			marked = true
+		case IsComment(row):
+			migrated = append(migrated, row)
+		case IsHeader(row):
+			width, added = len(row), nil
+			header := append([]string(nil), row...)
+			if to >= 2 {
+				for _, name := range schema_columns {
+					if columnIndex(row, name) < 0 {
+						header = append(header, name)
+						added = append(added, defaults[name])
+					}
+				}
+			}
+			migrated = append(migrated, header)
+		case (added == nil) || (len(strings.Join(row, "")) == 0):
+			migrated = append(migrated, row)
+		default:
+			fields := append([]string(nil), row...)
+			for len(fields) < width {
+				fields = append(fields, "")
+			}
+			migrated = append(migrated, append(fields, added...))
+		}
+	}
+	if !marked {
+		migrated = append([][]string{schemaMarkerRow(to)}, migrated...)
+	}
+	return migrated, nil
+}
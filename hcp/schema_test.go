@@ -0,0 +1,46 @@
+package hcp
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMigrateRows(t *testing.T) {
+	header := []string{"Source", "YYYY-MM", "Page", "System", "Price", "", "Kit", "Board"}
+	advert := []string{"PCW", "1982-01", "p1", "ZX81", "£69.95", "", "N", "N"}
+	migrated := append(append([]string(nil), header...), schema_columns...)
+	tests := []struct {
+		name     string
+		rows     [][]string
+		to       int
+		defaults map[string]string
+		want     [][]string
+		err      bool
+	}{
+		{"version 1 to 2", [][]string{header, advert}, 2, nil,
+			[][]string{{"# hcp-schema 2"}, migrated, append(append([]string(nil), advert...), "", "", "")}, false},
+		{"defaults", [][]string{header, advert}, 2, map[string]string{adv_region_header: "UK"},
+			[][]string{{"# hcp-schema 2"}, migrated, append(append([]string(nil), advert...), "UK", "", "")}, false},
+		{"short row padded", [][]string{header, advert[:5]}, 2, nil,
+			[][]string{{"# hcp-schema 2"}, migrated, append(append([]string(nil), advert[:5]...), "", "", "", "", "", "")}, false},
+		{"comments, preamble and empty rows kept", [][]string{{"Home computer prices"}, header, {"# checked"}, {"", ""}, advert}, 2, nil,
+			[][]string{{"# hcp-schema 2"}, {"Home computer prices"}, migrated, {"# checked"}, {"", ""}, append(append([]string(nil), advert...), "", "", "")}, false},
+		{"marker rewritten", [][]string{{"# hcp-schema 1"}, header, advert}, 2, nil,
+			[][]string{{"# hcp-schema 2"}, migrated, append(append([]string(nil), advert...), "", "", "")}, false},
+		{"already version 2", [][]string{{"# hcp-schema 2"}, migrated, append(append([]string(nil), advert...), "UK", "", "")}, 2, nil,
+			[][]string{{"# hcp-schema 2"}, migrated, append(append([]string(nil), advert...), "UK", "", "")}, false},
+		{"to version 1", [][]string{header, advert}, 1, nil, [][]string{{"# hcp-schema 1"}, header, advert}, false},
+		{"header only", [][]string{header}, 2, nil, [][]string{{"# hcp-schema 2"}, migrated}, false},
+		{"no such version", [][]string{header, advert}, 3, nil, nil, true},
+		{"back to an earlier version", [][]string{{"# hcp-schema 2"}, migrated}, 1, nil, nil, true},
+		{"bad marker", [][]string{{"# hcp-schema two"}, header}, 2, nil, nil, true},
+	}
+	for _, test := range tests {
+		rows, err := MigrateRows(test.rows, test.to, test.defaults)
+		if (err != nil) != test.err {
+			t.Errorf("%s: MigrateRows() error = %v; want error %t", test.name, err, test.err)
+		} else if !test.err && !reflect.DeepEqual(rows, test.want) {
+			t.Errorf("%s: MigrateRows() = %q; want %q", test.name, rows, test.want)
+		}
+	}
+}
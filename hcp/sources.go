@@ -0,0 +1,25 @@
+package hcp
+
+import "fmt"
+
+// The ways in which adverts may be counted as independent sources of a price:
+// o magazine counts each magazine once, however many of its issues carried the advert
+// o issue counts each issue of each magazine once, so the same advert repeated month after month counts each time
+var SourceCountings = []string{"magazine", "issue"}
+
+// Given the adverts behind a price, return how many independent sources they come from, counted in the given way.
+// return an error if the way of counting is not one of the SourceCountings.
+func CountSources(adverts []Advert, by string) (int, error) {
+	seen := make(map[string]bool)
+	for _, advert := range adverts {
+		switch by {
+		case "magazine":
+			seen[advert.Magazine] = true
+		case "issue":
+			seen[fmt.Sprintf("%s %04d-%02d", advert.Magazine, advert.Year, advert.Month)] = true
+		default:
+			return 0, fmt.Errorf("unknown source counting [%s]", by)
+		}
+	}
+	return len(seen), nil
+}
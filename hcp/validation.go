@@ -0,0 +1,83 @@
+package hcp
+
+// A FileValidation is the outcome of validating one CSV file
+type FileValidation struct {
+	Filename string       `json:"file"`
+	Rows     int          `json:"rows"`     // Data rows seen, ignoring empty lines and anything before the header
+	Accepted int          `json:"accepted"` // Rows that passed validation
+	Rejected int          `json:"rejected"` // Rows dropped because of a bad date or price
+	Warnings int          `json:"warnings"` // Rows used despite a problem, such as a bad page number
+	Problems []RowProblem `json:"-"`        // Every problem found, in row order
+
+	// The rows counted by the Contributor column, for files that have one (otherwise nil), keyed by contributor;
+	// rows with an empty Contributor field are counted under "". Left out of reports, as it names the volunteers.
+	Contributors map[string]*ContributorCounts `json:"-"`
+}
+
+// A ContributorCounts counts the rows of a file that were transcribed by one contributor
+type ContributorCounts struct {
+	Rows     int // Data rows, as for FileValidation
+	Accepted int // Rows that passed validation
+	Rejected int // Rows dropped
+	Warnings int // Problems that did not stop a row being used
+}
+
+// A PriceJump is an advert whose price is implausibly far from the other prices seen for the same system around that time
+type PriceJump struct {
+	Advert  Advert
+	Nearest int // The closest of the other prices, in pence
+	Percent int // How far the advert's price is from Nearest, as a percentage of the lower of the two
+}
+
+// Look for prices that differ wildly from the other prices seen for the same system in the same or adjacent quarters,
+// which usually means that a decimal point was lost or added when the advert was transcribed.
+// An advert is flagged if even the closest of those other prices differs from it by more than maxJumpPercent,
+// measured as the higher price over the lower, so a price ten times too high or ten times too low is a 900% jump.
+// Each flagged advert counts as a warning against the file it came from.
+func CheckPriceJumps(adverts []Advert, maxJumpPercent int, validations []FileValidation) []PriceJump {
+	jumps := make([]PriceJump, 0)
+	byIndex := make(map[string]map[int][]Advert)
+	for _, advert := range adverts {
+		if _, ok := byIndex[advert.System]; !ok {
+			byIndex[advert.System] = make(map[int][]Advert)
+		}
+		index := BuildIndexFromAdvert(advert)
+		byIndex[advert.System][index] = append(byIndex[advert.System][index], advert)
+	}
+
+	for _, advert := range adverts {
+		index := BuildIndexFromAdvert(advert)
+		closestJump := -1
+		closestPrice := 0
+		for neighbour := index - 1; neighbour <= index+1; neighbour++ {
+			for _, other := range byIndex[advert.System][neighbour] {
+				if (other.File == advert.File) && (other.Row == advert.Row) {
+					continue
+				}
+				jump := priceJumpPercent(advert.Price, other.Price)
+				if (closestJump < 0) || (jump < closestJump) {
+					closestJump = jump
+					closestPrice = other.Price
+				}
+			}
+		}
+		if closestJump > maxJumpPercent {
+			jumps = append(jumps, PriceJump{advert, closestPrice, closestJump})
+			for i := range validations {
+				if validations[i].Filename == advert.File {
+					validations[i].Warnings++
+				}
+			}
+		}
+	}
+	return jumps
+}
+
+// Given two prices, return how much higher the higher one is than the lower one, as a percentage
+func priceJumpPercent(a int, b int) int {
+	low, high := min(a, b), max(a, b)
+	if low <= 0 {
+		return 0
+	}
+	return (high - low) * 100 / low
+}
@@ -0,0 +1,57 @@
+package hcp
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// A VAT rate that applied from a given month onwards, in tenths of a percent (so 175 is 17.5%)
+type vatRate struct {
+	year     int
+	month    int
+	perMille int
+}
+
+// The UK standard rate of VAT over time, oldest first.
+// Changes that happened part way through a month are treated as starting in the next whole month.
+// Only the standard rate is used: the higher rates of 1974-1979 applied to some electrical goods are ignored.
+var vatRates = []vatRate{
+	{1973, 4, 100},
+	{1974, 8, 80},
+	{1979, 7, 150},
+	{1991, 4, 175},
+	{2008, 12, 150},
+	{2010, 1, 175},
+	{2011, 2, 200},
+}
+
+// Matches a trailing VAT qualifier such as "+ VAT", "plus VAT", "ex VAT", "exc. VAT" or "inc VAT"
+var vatSuffixPattern = regexp.MustCompile(`(?i)\s*(\+|plus|ex|exc|excl|excluding|inc|incl|including)\.?\s*VAT$`)
+
+// Given a price, remove any trailing VAT qualifier.
+// exVAT is true if the qualifier says that VAT has to be added to the price.
+func strip_vat_suffix(price_text string) (remaining string, exVAT bool) {
+	match := vatSuffixPattern.FindStringSubmatchIndex(price_text)
+	if match == nil {
+		return price_text, false
+	}
+	qualifier := strings.ToLower(price_text[match[2]:match[3]])
+	return price_text[:match[0]], !strings.HasPrefix(qualifier, "inc")
+}
+
+// Given a price in pence that excludes VAT, return the VAT-inclusive price for an advert of the given year and month.
+// The result is rounded to the nearest penny.
+// return an error if VAT did not exist at that date.
+func addVAT(pence int, year int, month int) (int, error) {
+	rate := -1
+	for _, candidate := range vatRates {
+		if (year > candidate.year) || ((year == candidate.year) && (month >= candidate.month)) {
+			rate = candidate.perMille
+		}
+	}
+	if rate < 0 {
+		return pence, fmt.Errorf("VAT quoted for %04d-%02d, before VAT was introduced", year, month)
+	}
+	return (pence*(1000+rate) + 500) / 1000, nil
+}
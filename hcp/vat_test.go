@@ -0,0 +1,54 @@
+package hcp
+
+import "testing"
+
+func TestAddVAT(t *testing.T) {
+	tests := []struct {
+		year  int
+		month int
+		pence int
+		want  int
+		err   bool
+	}{
+		{1973, 3, 10000, 10000, true},
+		{1973, 4, 10000, 11000, false},
+		{1974, 7, 10000, 11000, false},
+		{1974, 8, 10000, 10800, false},
+		{1979, 6, 10000, 10800, false}, // 15% from 18 June 1979, so from July
+		{1979, 7, 10000, 11500, false},
+		{1991, 4, 10000, 11750, false}, // 17.5% from 19 March 1991, so from April
+		{2008, 12, 10000, 11500, false},
+		{2010, 1, 10000, 11750, false},
+		{2011, 1, 10000, 11750, false}, // 20% from 4 January 2011, so from February
+		{2011, 2, 10000, 12000, false},
+		{1983, 6, 39999, 45999, false},
+	}
+	for _, test := range tests {
+		pence, err := addVAT(test.pence, test.year, test.month)
+		if ((err != nil) != test.err) || (!test.err && (pence != test.want)) {
+			t.Errorf("addVAT(%d, %d, %d) = %d, %v; want %d, error %t", test.pence, test.year, test.month, pence, err, test.want, test.err)
+		}
+	}
+}
+
+func TestStripVATSuffix(t *testing.T) {
+	tests := []struct {
+		price     string
+		remaining string
+		exVAT     bool
+	}{
+		{"£99 + VAT", "£99", true},
+		{"£99 plus VAT", "£99", true},
+		{"£99 ex VAT", "£99", true},
+		{"£99 exc. VAT", "£99", true},
+		{"£99 inc VAT", "£99", false},
+		{"£99 including vat", "£99", false},
+		{"£99", "£99", false},
+	}
+	for _, test := range tests {
+		remaining, exVAT := strip_vat_suffix(test.price)
+		if (remaining != test.remaining) || (exVAT != test.exVAT) {
+			t.Errorf("strip_vat_suffix(%q) = %q, %t; want %q, %t", test.price, remaining, exVAT, test.remaining, test.exVAT)
+		}
+	}
+}